@@ -0,0 +1,100 @@
+// Command keygen generates and rotates the keys that sign admin session cookies.
+//
+//	go run ./cmd/keygen                               print a new key for COOKIE_KEYS
+//	go run ./cmd/keygen -file db/cookie-keys          add a new primary key to a key file
+//	go run ./cmd/keygen -file db/cookie-keys -keep 2  ... and keep only the two newest keys
+//
+// After a rotation, cookies signed with the previous keys stay valid as long as those keys are
+// kept in the file. Restart every instance so they all pick up the new primary key.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/AlexTLDR/pizzeria/internal/middleware"
+)
+
+func main() {
+	file := flag.String("file", "", "key file to add a new primary key to; prints a single key when empty")
+	keep := flag.Int("keep", 3, "number of keys to keep in the file, including the new one")
+	flag.Parse()
+
+	log.SetFlags(0)
+
+	key, err := middleware.GenerateCookieKey()
+	if err != nil {
+		log.Fatalf("generating key: %v", err)
+	}
+
+	if *file == "" {
+		fmt.Println(key)
+		log.Println("Put it first in COOKIE_KEYS, followed by the previous keys: COOKIE_KEYS=<new key>,<previous keys>")
+
+		return
+	}
+
+	if *keep < 1 {
+		log.Fatalf("-keep must be at least 1")
+	}
+
+	keyring, err := rotate(*file, key, *keep)
+	if err != nil {
+		log.Fatalf("rotating %s: %v", *file, err)
+	}
+
+	log.Printf("New primary key %s written to %s (%d key(s) in total)", key.ID, *file, len(keyring.Keys()))
+}
+
+// rotate adds key as the new primary key to the key file, creating the file if needed
+func rotate(path string, key middleware.CookieKey, keep int) (*middleware.Keyring, error) {
+	keyring := middleware.NewKeyring(key)
+
+	data, err := os.ReadFile(path)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		// A new file starts with the new key alone
+
+	case err != nil:
+		return nil, err
+
+	default:
+		existing, err := middleware.ParseKeyring(string(data))
+		if err != nil && !errors.Is(err, middleware.ErrNoCookieKeys) {
+			return nil, err
+		}
+
+		if existing != nil {
+			keyring = existing.Rotate(key, keep)
+		}
+	}
+
+	// Write to a temporary file first, so a running server never reads a half-written key file
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cookie-keys-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // fails harmlessly once the file has been renamed
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close() //nolint:errcheck // the chmod error is reported
+		return nil, err
+	}
+
+	if _, err := tmp.WriteString(keyring.Format()); err != nil {
+		tmp.Close() //nolint:errcheck // the write error is reported
+		return nil, err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	return keyring, os.Rename(tmp.Name(), path)
+}
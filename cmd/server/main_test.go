@@ -326,8 +326,7 @@ func TestGoogleUserInfo(t *testing.T) {
 	
 	// Create a mock OAuth config
 	config := &auth.OAuthConfig{
-		GoogleOAuthConfig: nil, // We'll mock the HTTP client
-		AllowedEmails:     []string{"test@example.com"},
+		AllowedEmails: []string{"test@example.com"},
 	}
 
 	// Test the IsAllowedEmail method
@@ -0,0 +1,66 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+CREATE TABLE categories (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    slug TEXT NOT NULL UNIQUE, -- Used as the anchor on the public menu, e.g. #pizza
+    name_it TEXT NOT NULL,
+    name_de TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '', -- Optional subtitle shown under the heading
+    position INTEGER NOT NULL DEFAULT 0,
+    visible BOOLEAN NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The sections that used to be hard-coded in index.html and category-nav.html
+INSERT INTO categories (slug, name_it, name_de, description, position) VALUES
+('antipasti', 'Antipasti', 'Vorspeisen', '', 1),
+('insalate', 'Insalate', 'Salate', '', 2),
+('pizza', 'Pizza', '', 'Größen: klein ca. 26cm | normal ca. 32cm ⌀', 3),
+('spaghetti', 'Spaghetti', '', '', 4),
+('penne', 'Penne', '', '', 5),
+('rigatoni', 'Rigatoni', '', '', 6),
+('pasta-al-forno', 'Pasta al Forno', 'Nudelgerichte überbacken', '', 7),
+('pesce', 'Pesce Fritto', 'Fisch fritiert', '', 8),
+('carne', 'Carne', 'Fleisch', '', 9);
+
+-- Any free-text category that does not match a known section becomes its own category
+INSERT INTO categories (slug, name_it, position)
+SELECT lower(replace(trim(mi.category), ' ', '-')), trim(mi.category), 100
+FROM menu_items mi
+WHERE NOT EXISTS (
+    SELECT 1 FROM categories c
+    WHERE lower(c.name_it) = lower(trim(mi.category))
+       OR lower(c.name_it || ' / ' || c.name_de) = lower(trim(mi.category))
+)
+GROUP BY lower(trim(mi.category));
+
+ALTER TABLE menu_items ADD COLUMN category_id INTEGER REFERENCES categories(id);
+
+UPDATE menu_items SET category_id = (
+    SELECT c.id FROM categories c
+    WHERE lower(c.name_it) = lower(trim(menu_items.category))
+       OR lower(c.name_it || ' / ' || c.name_de) = lower(trim(menu_items.category))
+       OR c.slug = lower(replace(trim(menu_items.category), ' ', '-'))
+    ORDER BY c.position
+    LIMIT 1
+);
+
+ALTER TABLE menu_items DROP COLUMN category;
+
+CREATE INDEX idx_menu_items_category_id ON menu_items(category_id);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_menu_items_category_id;
+
+ALTER TABLE menu_items ADD COLUMN category TEXT NOT NULL DEFAULT '';
+
+UPDATE menu_items SET category = COALESCE(
+    (SELECT c.name_it FROM categories c WHERE c.id = menu_items.category_id),
+    ''
+);
+
+ALTER TABLE menu_items DROP COLUMN category_id;
+
+DROP TABLE categories;
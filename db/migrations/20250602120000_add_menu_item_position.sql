@@ -0,0 +1,14 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+ALTER TABLE menu_items ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+
+-- Keep the previous alphabetical order as the starting point within each category
+UPDATE menu_items SET position = (
+    SELECT COUNT(*) FROM menu_items other
+    WHERE other.category_id IS menu_items.category_id
+      AND (other.name < menu_items.name OR (other.name = menu_items.name AND other.id < menu_items.id))
+) + 1;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE menu_items DROP COLUMN position;
@@ -0,0 +1,66 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+CREATE TABLE allergens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code TEXT NOT NULL UNIQUE, -- Letter shown next to the dish, e.g. A
+    name TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE additives (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code TEXT NOT NULL UNIQUE, -- Number shown next to the dish, e.g. 1
+    name TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE menu_item_allergens (
+    menu_item_id INTEGER NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+    allergen_id INTEGER NOT NULL REFERENCES allergens(id) ON DELETE CASCADE,
+    PRIMARY KEY (menu_item_id, allergen_id)
+);
+
+CREATE TABLE menu_item_additives (
+    menu_item_id INTEGER NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+    additive_id INTEGER NOT NULL REFERENCES additives(id) ON DELETE CASCADE,
+    PRIMARY KEY (menu_item_id, additive_id)
+);
+
+-- The 14 main allergens of EU Regulation 1169/2011 (LMIV)
+INSERT INTO allergens (code, name, position) VALUES
+('A', 'Glutenhaltiges Getreide', 1),
+('B', 'Krebstiere', 2),
+('C', 'Eier', 3),
+('D', 'Fisch', 4),
+('E', 'Erdnüsse', 5),
+('F', 'Soja', 6),
+('G', 'Milch und Laktose', 7),
+('H', 'Schalenfrüchte (Nüsse)', 8),
+('I', 'Sellerie', 9),
+('J', 'Senf', 10),
+('K', 'Sesam', 11),
+('L', 'Schwefeldioxid und Sulfite', 12),
+('M', 'Lupinen', 13),
+('N', 'Weichtiere', 14);
+
+-- Additives that must be declared on German menus (ZZulV)
+INSERT INTO additives (code, name, position) VALUES
+('1', 'mit Farbstoff', 1),
+('2', 'mit Konservierungsstoff', 2),
+('3', 'mit Antioxidationsmittel', 3),
+('4', 'mit Geschmacksverstärker', 4),
+('5', 'geschwefelt', 5),
+('6', 'geschwärzt', 6),
+('7', 'gewachst', 7),
+('8', 'mit Phosphat', 8),
+('9', 'mit Süßungsmittel', 9),
+('10', 'enthält eine Phenylalaninquelle', 10),
+('11', 'koffeinhaltig', 11),
+('12', 'chininhaltig', 12);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE menu_item_additives;
+DROP TABLE menu_item_allergens;
+DROP TABLE additives;
+DROP TABLE allergens;
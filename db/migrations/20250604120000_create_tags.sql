@@ -0,0 +1,28 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+CREATE TABLE tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    slug TEXT NOT NULL UNIQUE, -- Used in filter links, e.g. ?diet=vegan
+    name TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE menu_item_tags (
+    menu_item_id INTEGER NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (menu_item_id, tag_id)
+);
+
+CREATE INDEX idx_menu_item_tags_tag_id ON menu_item_tags(tag_id);
+
+INSERT INTO tags (slug, name, position) VALUES
+('vegetarian', 'Vegetarisch', 1),
+('vegan', 'Vegan', 2),
+('spicy', 'Scharf', 3),
+('gluten-free', 'Glutenfrei', 4);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_menu_item_tags_tag_id;
+DROP TABLE menu_item_tags;
+DROP TABLE tags;
@@ -0,0 +1,47 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+CREATE TABLE menu_item_variants (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    menu_item_id INTEGER NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+    label TEXT NOT NULL DEFAULT '', -- e.g. klein, normal, 0,3l; empty for dishes with a single price
+    price REAL NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    is_default BOOLEAN NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_menu_item_variants_menu_item_id ON menu_item_variants(menu_item_id);
+
+-- Items with a small price become "klein" / "normal"
+INSERT INTO menu_item_variants (menu_item_id, label, price, position, is_default)
+SELECT id, 'klein', small_price, 1, 0 FROM menu_items WHERE small_price IS NOT NULL;
+
+INSERT INTO menu_item_variants (menu_item_id, label, price, position, is_default)
+SELECT id, 'normal', price, 2, 1 FROM menu_items WHERE small_price IS NOT NULL;
+
+-- All other items get a single unlabelled variant
+INSERT INTO menu_item_variants (menu_item_id, label, price, position, is_default)
+SELECT id, '', price, 1, 1 FROM menu_items WHERE small_price IS NULL;
+
+ALTER TABLE menu_items DROP COLUMN small_price;
+ALTER TABLE menu_items DROP COLUMN price;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE menu_items ADD COLUMN price REAL NOT NULL DEFAULT 0;
+ALTER TABLE menu_items ADD COLUMN small_price REAL;
+
+-- The default variant becomes the regular price, a "klein" variant the small price.
+-- Any other variants are lost.
+UPDATE menu_items SET price = COALESCE(
+    (SELECT v.price FROM menu_item_variants v WHERE v.menu_item_id = menu_items.id ORDER BY v.is_default DESC, v.position LIMIT 1),
+    0
+);
+
+UPDATE menu_items SET small_price = (
+    SELECT v.price FROM menu_item_variants v
+    WHERE v.menu_item_id = menu_items.id AND LOWER(v.label) = 'klein' AND v.is_default = 0
+    LIMIT 1
+);
+
+DROP INDEX IF EXISTS idx_menu_item_variants_menu_item_id;
+DROP TABLE menu_item_variants;
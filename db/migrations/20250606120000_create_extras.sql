@@ -0,0 +1,30 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+CREATE TABLE extras (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    price REAL NOT NULL, -- Surcharge for the normal size
+    small_price REAL, -- Surcharge for the "klein" size, NULL when it is the same
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- An extra applies either to a whole category or to a single menu item
+CREATE TABLE extra_assignments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    extra_id INTEGER NOT NULL REFERENCES extras(id) ON DELETE CASCADE,
+    menu_item_id INTEGER REFERENCES menu_items(id) ON DELETE CASCADE,
+    category_id INTEGER REFERENCES categories(id) ON DELETE CASCADE,
+    CHECK ((menu_item_id IS NULL) <> (category_id IS NULL))
+);
+
+CREATE UNIQUE INDEX idx_extra_assignments_item ON extra_assignments(extra_id, menu_item_id) WHERE menu_item_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_extra_assignments_category ON extra_assignments(extra_id, category_id) WHERE category_id IS NOT NULL;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_extra_assignments_category;
+DROP INDEX IF EXISTS idx_extra_assignments_item;
+DROP TABLE extra_assignments;
+DROP TABLE extras;
@@ -0,0 +1,34 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Prices move from REAL euros to INTEGER cents to avoid floating point rounding.
+ALTER TABLE menu_item_variants ADD COLUMN price_cents INTEGER NOT NULL DEFAULT 0;
+UPDATE menu_item_variants SET price_cents = CAST(ROUND(price * 100) AS INTEGER);
+ALTER TABLE menu_item_variants DROP COLUMN price;
+ALTER TABLE menu_item_variants RENAME COLUMN price_cents TO price;
+
+ALTER TABLE extras ADD COLUMN price_cents INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE extras ADD COLUMN small_price_cents INTEGER;
+UPDATE extras SET
+    price_cents = CAST(ROUND(price * 100) AS INTEGER),
+    small_price_cents = CASE WHEN small_price IS NULL THEN NULL ELSE CAST(ROUND(small_price * 100) AS INTEGER) END;
+ALTER TABLE extras DROP COLUMN price;
+ALTER TABLE extras DROP COLUMN small_price;
+ALTER TABLE extras RENAME COLUMN price_cents TO price;
+ALTER TABLE extras RENAME COLUMN small_price_cents TO small_price;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE extras ADD COLUMN price_real REAL NOT NULL DEFAULT 0;
+ALTER TABLE extras ADD COLUMN small_price_real REAL;
+UPDATE extras SET
+    price_real = price / 100.0,
+    small_price_real = CASE WHEN small_price IS NULL THEN NULL ELSE small_price / 100.0 END;
+ALTER TABLE extras DROP COLUMN price;
+ALTER TABLE extras DROP COLUMN small_price;
+ALTER TABLE extras RENAME COLUMN price_real TO price;
+ALTER TABLE extras RENAME COLUMN small_price_real TO small_price;
+
+ALTER TABLE menu_item_variants ADD COLUMN price_real REAL NOT NULL DEFAULT 0;
+UPDATE menu_item_variants SET price_real = price / 100.0;
+ALTER TABLE menu_item_variants DROP COLUMN price;
+ALTER TABLE menu_item_variants RENAME COLUMN price_real TO price;
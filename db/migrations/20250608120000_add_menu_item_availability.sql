@@ -0,0 +1,25 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+ALTER TABLE menu_items ADD COLUMN availability TEXT NOT NULL DEFAULT 'available'
+    CHECK (availability IN ('available', 'sold_out', 'hidden'));
+ALTER TABLE menu_items ADD COLUMN sold_out_until DATE; -- last day the item is sold out; NULL means until switched back
+
+-- An item with windows is only offered during them, e.g. the lunch menu on Sunday 11:30-14:00
+CREATE TABLE menu_item_windows (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    menu_item_id INTEGER NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+    weekday INTEGER NOT NULL CHECK (weekday BETWEEN 0 AND 6), -- 0 is Sunday
+    start_time TEXT NOT NULL, -- HH:MM
+    end_time TEXT NOT NULL,   -- HH:MM, exclusive
+    CHECK (start_time < end_time)
+);
+
+CREATE INDEX idx_menu_item_windows_menu_item_id ON menu_item_windows(menu_item_id);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_menu_item_windows_menu_item_id;
+DROP TABLE menu_item_windows;
+
+ALTER TABLE menu_items DROP COLUMN sold_out_until;
+ALTER TABLE menu_items DROP COLUMN availability;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Deleted items go to the trash first; NULL means the item is live
+ALTER TABLE menu_items ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_menu_items_deleted_at ON menu_items(deleted_at);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+-- Items still in the trash are removed for good together with their links
+DELETE FROM menu_item_variants WHERE menu_item_id IN (SELECT id FROM menu_items WHERE deleted_at IS NOT NULL);
+DELETE FROM menu_item_allergens WHERE menu_item_id IN (SELECT id FROM menu_items WHERE deleted_at IS NOT NULL);
+DELETE FROM menu_item_additives WHERE menu_item_id IN (SELECT id FROM menu_items WHERE deleted_at IS NOT NULL);
+DELETE FROM menu_item_tags WHERE menu_item_id IN (SELECT id FROM menu_items WHERE deleted_at IS NOT NULL);
+DELETE FROM menu_item_windows WHERE menu_item_id IN (SELECT id FROM menu_items WHERE deleted_at IS NOT NULL);
+DELETE FROM extra_assignments WHERE menu_item_id IN (SELECT id FROM menu_items WHERE deleted_at IS NOT NULL);
+DELETE FROM menu_items WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_menu_items_deleted_at;
+ALTER TABLE menu_items DROP COLUMN deleted_at;
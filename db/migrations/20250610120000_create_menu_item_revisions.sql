@@ -0,0 +1,18 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Every change to a menu item stores a JSON snapshot of the item as it was after the change
+CREATE TABLE menu_item_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    menu_item_id INTEGER NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore', 'revert')),
+    snapshot TEXT NOT NULL,
+    changed_by TEXT NOT NULL DEFAULT '', -- email of the admin who made the change
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_menu_item_revisions_menu_item_id ON menu_item_revisions(menu_item_id);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_menu_item_revisions_menu_item_id;
+DROP TABLE menu_item_revisions;
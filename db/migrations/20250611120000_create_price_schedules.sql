@@ -0,0 +1,17 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- A scheduled price replaces the variant's price from effective_from on
+CREATE TABLE price_schedules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    variant_id INTEGER NOT NULL REFERENCES menu_item_variants(id) ON DELETE CASCADE,
+    price INTEGER NOT NULL CHECK (price >= 0), -- cents
+    effective_from DATE NOT NULL,              -- YYYY-MM-DD, local date
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_price_schedules_variant_date ON price_schedules(variant_id, effective_from);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX IF EXISTS idx_price_schedules_variant_date;
+DROP TABLE price_schedules;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Translations of menu content. The text in the records themselves is the default
+-- language (German); a row here overrides one field of one record in another language.
+CREATE TABLE translations (
+    entity_type TEXT NOT NULL CHECK (entity_type IN ('menu_item', 'category', 'flash_message')),
+    entity_id INTEGER NOT NULL,
+    field TEXT NOT NULL,
+    lang TEXT NOT NULL,
+    value TEXT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (entity_type, entity_id, field, lang)
+);
+
+CREATE INDEX idx_translations_lang ON translations (entity_type, lang);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX idx_translations_lang;
+DROP TABLE translations;
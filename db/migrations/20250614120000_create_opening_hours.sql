@@ -0,0 +1,45 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Weekly opening hours, possibly several slots a day. Weekdays count from Sunday = 0 like
+-- Go's time.Weekday; a slot closing at or before its opening time runs past midnight.
+CREATE TABLE opening_hours (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    weekday INTEGER NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    opens TEXT NOT NULL,  -- HH:MM
+    closes TEXT NOT NULL, -- HH:MM
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_opening_hours_weekday ON opening_hours (weekday, opens);
+
+-- Days that differ from the weekly hours: holidays and vacation (closed) or special hours
+CREATE TABLE opening_exceptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL, -- inclusive
+    closed BOOLEAN NOT NULL DEFAULT 1,
+    opens TEXT NOT NULL DEFAULT '',  -- HH:MM, only for special hours
+    closes TEXT NOT NULL DEFAULT '', -- HH:MM, only for special hours
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX idx_opening_exceptions_end_date ON opening_exceptions (end_date);
+
+-- The hours printed in the footer so far: closed on Monday
+INSERT INTO opening_hours (weekday, opens, closes) VALUES
+    (2, '17:00', '22:00'),
+    (3, '17:00', '22:00'),
+    (4, '17:00', '22:00'),
+    (5, '17:00', '22:00'),
+    (6, '17:00', '22:00'),
+    (0, '11:30', '14:00'),
+    (0, '17:00', '21:30');
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX idx_opening_exceptions_end_date;
+DROP TABLE opening_exceptions;
+DROP INDEX idx_opening_hours_weekday;
+DROP TABLE opening_hours;
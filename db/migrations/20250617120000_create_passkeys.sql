@@ -0,0 +1,33 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- Passkeys (WebAuthn credentials) let admins sign in without their OpenID Connect provider.
+-- public_key holds the credential's public key in PKIX (DER) form.
+CREATE TABLE passkeys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    credential_id BLOB NOT NULL UNIQUE,
+    public_key BLOB NOT NULL,
+    sign_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP
+);
+
+CREATE INDEX idx_passkeys_email ON passkeys (email);
+
+-- Challenges of passkey ceremonies in progress. Each can be used once, so a recorded sign-in
+-- cannot be replayed; email is set for registrations and empty for sign-ins. client_ip limits
+-- how many challenges one client can hold open.
+CREATE TABLE passkey_challenges (
+    challenge_hash TEXT PRIMARY KEY,
+    email TEXT NOT NULL DEFAULT '',
+    client_ip TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_passkey_challenges_client_ip ON passkey_challenges (client_ip);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE passkey_challenges;
+DROP TABLE passkeys;
@@ -2,6 +2,7 @@ package app
 
 import (
 	"database/sql"
+	"fmt"
 	"html/template"
 	"log"
 	"net/http"
@@ -16,6 +17,7 @@ import (
 	"github.com/AlexTLDR/pizzeria/db"
 	"github.com/AlexTLDR/pizzeria/internal/auth"
 	"github.com/AlexTLDR/pizzeria/internal/handlers"
+	"github.com/AlexTLDR/pizzeria/internal/i18n"
 	"github.com/AlexTLDR/pizzeria/internal/middleware"
 	"github.com/AlexTLDR/pizzeria/internal/models"
 )
@@ -25,6 +27,7 @@ type Application struct {
 	DBModel       *models.DBModel
 	TemplateCache map[string]*template.Template
 	OAuthConfig   *auth.OAuthConfig
+	Passkeys      *auth.RelyingParty
 	IsProduction  bool
 }
 
@@ -56,6 +59,11 @@ func New() (*Application, error) {
 		return nil, err
 	}
 
+	// Load the keys signing session cookies; without them no admin can stay logged in
+	if err := middleware.InitializeCookieSecret(); err != nil {
+		return nil, err
+	}
+
 	// Initialize OAuth
 	if err := middleware.InitializeOAuth(); err != nil {
 		log.Printf("OAuth initialization error: %v", err)
@@ -63,6 +71,25 @@ func New() (*Application, error) {
 
 	oauthConfig := middleware.GetOAuthConfig()
 
+	// Passkeys let admins sign in when their OpenID Connect provider is unreachable
+	passkeys, err := auth.NewRelyingParty(os.Getenv("WEBAUTHN_ORIGIN"))
+	if err != nil {
+		log.Printf("Passkeys disabled: %v", err)
+	}
+
+	dbModel := &models.DBModel{DB: database}
+
+	// Seed the first owners from ALLOWED_EMAILS while there are no admin users yet, even when the
+	// sign-in configuration failed to load
+	added, err := dbModel.BootstrapAdminUsers(auth.AllowedEmailsFromEnv(os.Getenv))
+	if err != nil {
+		return nil, fmt.Errorf("seeding admin users: %w", err)
+	}
+
+	if added > 0 {
+		log.Printf("Added %d owner(s) from ALLOWED_EMAILS; manage admin users at /admin/users from now on", added)
+	}
+
 	// Initialize templates
 	templateCache, err := createTemplateCache()
 	if err != nil {
@@ -72,9 +99,10 @@ func New() (*Application, error) {
 	// Create the application
 	app := &Application{
 		DB:            database,
-		DBModel:       &models.DBModel{DB: database},
+		DBModel:       dbModel,
 		TemplateCache: templateCache,
 		OAuthConfig:   oauthConfig,
+		Passkeys:      passkeys,
 		IsProduction:  isProduction,
 	}
 
@@ -93,7 +121,7 @@ func (app *Application) Close() {
 // SetupHandlers initializes the handlers
 func (app *Application) SetupHandlers() http.Handler {
 	// Initialize handlers
-	appServices := handlers.NewAppServices(app.DBModel, app.TemplateCache, app.OAuthConfig)
+	appServices := handlers.NewAppServices(app.DBModel, app.TemplateCache, app.OAuthConfig, app.Passkeys)
 	handlers.NewHandlers(appServices)
 
 	// Create primary mux
@@ -105,12 +133,20 @@ func (app *Application) SetupHandlers() http.Handler {
 
 	// Auth related routes
 	mux.HandleFunc("/login", handlers.Services.ShowLoginPage)
-	mux.HandleFunc("/auth/google/login", handlers.Services.HandleGoogleLogin)
-	mux.HandleFunc("/auth/google/callback", handlers.Services.HandleGoogleCallback)
+	mux.HandleFunc("/auth/{provider}/login", handlers.Services.HandleLogin)
+	mux.HandleFunc("/auth/{provider}/callback", handlers.Services.HandleCallback)
+	mux.HandleFunc("/auth/passkey/options", handlers.Services.PasskeyLoginOptions)
+	mux.HandleFunc("/auth/passkey/login", handlers.Services.PasskeyLogin)
 
 	// Admin routes with custom handler that checks auth for all admin paths
 	mux.HandleFunc("/admin", authenticatedRedirect)
-	mux.HandleFunc("/admin/", authenticatedAdmin)
+	mux.HandleFunc("/admin/", handlers.Services.SessionAuth(authenticatedAdmin))
+
+	// Printable menu
+	mux.HandleFunc("/menu.pdf", handlers.Services.MenuPDF)
+
+	// Public read-only JSON API
+	mux.HandleFunc("/api/v1/", apiV1)
 
 	// Home route MUST be registered LAST to avoid catching other routes
 	mux.HandleFunc("/", handlers.Services.Home)
@@ -144,19 +180,35 @@ func runMigrations(dbPath string) error {
 // createTemplateCache creates a cache of templates
 func createTemplateCache() (map[string]*template.Template, error) {
 	funcMap := template.FuncMap{
-		"deref": func(f *float64) float64 {
-			if f != nil {
-				return *f
-			}
-			return 0
+		// money formats a price German style, e.g. "12,99 €"
+		"money": func(m models.Money) string {
+			return m.String()
 		},
 	}
 
+	// t, tn and tlabel translate UI text. They are registered in the default language here and
+	// rebound to the visitor's language when a page is rendered.
+	for name, fn := range i18n.FuncMap(i18n.Default) {
+		funcMap[name] = fn
+	}
+
 	templates := map[string]*template.Template{
-		"index.html":           template.Must(template.New("index.html").Funcs(funcMap).ParseFiles("templates/index.html", "templates/header.html", "templates/footer.html", "templates/category-nav.html")),
-		"login.html":           template.Must(template.New("login.html").Funcs(funcMap).ParseFiles("templates/login.html")),
-		"admin-dashboard.html": template.Must(template.New("admin-dashboard.html").Funcs(funcMap).ParseFiles("templates/admin-dashboard.html")),
-		"menu-form.html":       template.Must(template.New("menu-form.html").Funcs(funcMap).ParseFiles("templates/menu-form.html")),
+		"index.html":              template.Must(template.New("index.html").Funcs(funcMap).ParseFiles("templates/index.html", "templates/header.html", "templates/footer.html", "templates/category-nav.html")),
+		"login.html":              template.Must(template.New("login.html").Funcs(funcMap).ParseFiles("templates/login.html")),
+		"admin-dashboard.html":    template.Must(template.New("admin-dashboard.html").Funcs(funcMap).ParseFiles("templates/admin-dashboard.html")),
+		"menu-form.html":          template.Must(template.New("menu-form.html").Funcs(funcMap).ParseFiles("templates/menu-form.html")),
+		"admin-categories.html":   template.Must(template.New("admin-categories.html").Funcs(funcMap).ParseFiles("templates/admin-categories.html")),
+		"admin-tags.html":         template.Must(template.New("admin-tags.html").Funcs(funcMap).ParseFiles("templates/admin-tags.html")),
+		"admin-extras.html":       template.Must(template.New("admin-extras.html").Funcs(funcMap).ParseFiles("templates/admin-extras.html")),
+		"admin-trash.html":        template.Must(template.New("admin-trash.html").Funcs(funcMap).ParseFiles("templates/admin-trash.html")),
+		"admin-history.html":      template.Must(template.New("admin-history.html").Funcs(funcMap).ParseFiles("templates/admin-history.html")),
+		"admin-prices.html":       template.Must(template.New("admin-prices.html").Funcs(funcMap).ParseFiles("templates/admin-prices.html")),
+		"admin-price-adjust.html": template.Must(template.New("admin-price-adjust.html").Funcs(funcMap).ParseFiles("templates/admin-price-adjust.html")),
+		"admin-import.html":       template.Must(template.New("admin-import.html").Funcs(funcMap).ParseFiles("templates/admin-import.html")),
+		"admin-hours.html":        template.Must(template.New("admin-hours.html").Funcs(funcMap).ParseFiles("templates/admin-hours.html")),
+		"admin-users.html":        template.Must(template.New("admin-users.html").Funcs(funcMap).ParseFiles("templates/admin-users.html")),
+		"admin-sessions.html":     template.Must(template.New("admin-sessions.html").Funcs(funcMap).ParseFiles("templates/admin-sessions.html")),
+		"admin-passkeys.html":     template.Must(template.New("admin-passkeys.html").Funcs(funcMap).ParseFiles("templates/admin-passkeys.html")),
 	}
 
 	return templates, nil
@@ -168,32 +220,106 @@ func authenticatedRedirect(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/admin/", http.StatusSeeOther)
 }
 
-// authenticatedAdmin handles all admin routes with auth protection
-func authenticatedAdmin(w http.ResponseWriter, r *http.Request) {
-	// Very important debug log
-	log.Printf("Admin route requested: %s", r.URL.Path)
-
-	// First check if user is authenticated
-	userEmail, valid := middleware.VerifySecureSessionCookie(r)
-	if !valid {
-		log.Println("Invalid or expired session, redirecting to login")
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+// apiV1 routes the public JSON API. It is read-only, so only GET and HEAD requests are served.
+// The admin API below /api/v1/admin/ is handed to adminAPIV1 once the bearer token is checked.
+func apiV1(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/api/v1/admin/") {
+		handlers.Services.APITokenAuth(adminAPIV1)(w, r)
+		return
+	}
 
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		handlers.Services.APIMethodNotAllowed(w, r)
 		return
 	}
 
-	// Check if the user is authorized
-	if !middleware.GetOAuthConfig().IsAllowedEmail(userEmail) {
-		log.Printf("Unauthorized access attempt by: %s", userEmail)
-		middleware.ClearSecureSessionCookie(w)
-		http.Error(w, "Unauthorized: Access denied", http.StatusForbidden)
+	path := r.URL.Path
 
-		return
+	switch {
+	case path == "/api/v1/menu":
+		handlers.Services.APIMenu(w, r)
+
+	case strings.HasPrefix(path, "/api/v1/menu/"):
+		handlers.Services.APIMenuItem(w, r)
+
+	case path == "/api/v1/categories":
+		handlers.Services.APICategories(w, r)
+
+	case path == "/api/v1/announcements":
+		handlers.Services.APIAnnouncements(w, r)
+
+	case path == "/api/v1/status":
+		handlers.Services.APIStatus(w, r)
+
+	case path == "/api/v1/openapi.json":
+		handlers.Services.APIOpenAPI(w, r)
+
+	default:
+		handlers.Services.APINotFound(w, r)
+	}
+}
+
+// adminAPIV1 routes the token authenticated admin API. The handlers check the method themselves.
+func adminAPIV1(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+
+	switch {
+	case path == "/api/v1/admin/menu-items":
+		handlers.Services.AdminAPIMenuItems(w, r)
+
+	case strings.HasPrefix(path, "/api/v1/admin/menu-items/") && strings.HasSuffix(path, "/image"):
+		handlers.Services.AdminAPIMenuItemImage(w, r)
+
+	case strings.HasPrefix(path, "/api/v1/admin/menu-items/"):
+		handlers.Services.AdminAPIMenuItem(w, r)
+
+	case path == "/api/v1/admin/flash-messages":
+		handlers.Services.AdminAPIFlashMessages(w, r)
+
+	case strings.HasPrefix(path, "/api/v1/admin/flash-messages/"):
+		handlers.Services.AdminAPIFlashMessage(w, r)
+
+	default:
+		handlers.Services.APINotFound(w, r)
 	}
+}
+
+// requiredRole returns the lowest role that may use an admin route. Staff can only mark dishes as
+// sold out and manage their own sessions and passkeys; managing admin users and API tokens is left
+// to owners.
+func requiredRole(path string) models.Role {
+	switch {
+	case path == "/admin/" || path == "/admin" || path == "/admin/dashboard" || path == "/admin/logout",
+		strings.HasPrefix(path, "/admin/menu/availability/"),
+		strings.HasPrefix(path, "/admin/sessions"),
+		strings.HasPrefix(path, "/admin/passkeys"):
+		return models.RoleStaff
+
+	case strings.HasPrefix(path, "/admin/users"),
+		strings.HasPrefix(path, "/admin/api-tokens/"):
+		return models.RoleOwner
+
+	default:
+		return models.RoleManager
+	}
+}
+
+// authenticatedAdmin handles all admin routes. SessionAuth has already checked the session and
+// that its user is still an admin user.
+func authenticatedAdmin(w http.ResponseWriter, r *http.Request) {
+	// Very important debug log
+	log.Printf("Admin route requested: %s", r.URL.Path)
 
-	// User is authenticated and authorized, handle the route
+	// User is authenticated, check that their role may use the route
 	path := r.URL.Path
 
+	if role := middleware.GetUserRole(r); !role.AtLeast(requiredRole(path)) {
+		log.Printf("Access to %s denied for %s with role %q", path, middleware.GetUserEmail(r), role)
+		http.Error(w, "Forbidden: Your role does not allow this action", http.StatusForbidden)
+
+		return
+	}
+
 	// Log the exact path for debugging
 	log.Printf("Processing authenticated admin path: %s", path)
 
@@ -212,6 +338,15 @@ func authenticatedAdmin(w http.ResponseWriter, r *http.Request) {
 	case path == "/admin/menu/create":
 		handlers.Services.CreateMenuItem(w, r)
 
+	case path == "/admin/menu/reorder":
+		handlers.Services.ReorderMenuItems(w, r)
+
+	case path == "/admin/menu/export.csv" || path == "/admin/menu/export.json":
+		handlers.Services.ExportMenu(w, r)
+
+	case path == "/admin/menu/import":
+		handlers.Services.ImportMenu(w, r)
+
 	case strings.HasPrefix(path, "/admin/menu/edit/"):
 		handlers.Services.ShowEditMenuItem(w, r)
 
@@ -221,12 +356,141 @@ func authenticatedAdmin(w http.ResponseWriter, r *http.Request) {
 	case strings.HasPrefix(path, "/admin/menu/delete/"):
 		handlers.Services.DeleteMenuItem(w, r)
 
+	case strings.HasPrefix(path, "/admin/menu/availability/"):
+		handlers.Services.ToggleMenuItemAvailability(w, r)
+
+	case strings.HasPrefix(path, "/admin/menu/history/"):
+		handlers.Services.MenuItemHistory(w, r)
+
+	case strings.HasPrefix(path, "/admin/menu/revert/"):
+		handlers.Services.RevertMenuItem(w, r)
+
+	case path == "/admin/trash":
+		handlers.Services.AdminTrash(w, r)
+
+	case strings.HasPrefix(path, "/admin/trash/restore/"):
+		handlers.Services.RestoreMenuItem(w, r)
+
+	case strings.HasPrefix(path, "/admin/trash/purge/"):
+		handlers.Services.PurgeMenuItem(w, r)
+
+	case path == "/admin/prices":
+		handlers.Services.AdminPrices(w, r)
+
+	case path == "/admin/prices/schedule":
+		handlers.Services.SchedulePrices(w, r)
+
+	case path == "/admin/prices/adjust":
+		handlers.Services.AdjustPrices(w, r)
+
+	case path == "/admin/prices/preview":
+		handlers.Services.PreviewMenu(w, r)
+
+	case strings.HasPrefix(path, "/admin/prices/delete/"):
+		handlers.Services.DeletePriceSchedule(w, r)
+
+	case path == "/admin/categories":
+		handlers.Services.AdminCategories(w, r)
+
+	case path == "/admin/categories/create":
+		handlers.Services.CreateCategory(w, r)
+
+	case strings.HasPrefix(path, "/admin/categories/update/"):
+		handlers.Services.UpdateCategory(w, r)
+
+	case strings.HasPrefix(path, "/admin/categories/toggle/"):
+		handlers.Services.ToggleCategoryVisibility(w, r)
+
+	case strings.HasPrefix(path, "/admin/categories/move/"):
+		handlers.Services.MoveCategory(w, r)
+
+	case strings.HasPrefix(path, "/admin/categories/delete/"):
+		handlers.Services.DeleteCategory(w, r)
+
+	case path == "/admin/tags":
+		handlers.Services.AdminTags(w, r)
+
+	case path == "/admin/tags/create":
+		handlers.Services.CreateTag(w, r)
+
+	case strings.HasPrefix(path, "/admin/tags/update/"):
+		handlers.Services.UpdateTag(w, r)
+
+	case strings.HasPrefix(path, "/admin/tags/delete/"):
+		handlers.Services.DeleteTag(w, r)
+
+	case path == "/admin/extras":
+		handlers.Services.AdminExtras(w, r)
+
+	case path == "/admin/extras/create":
+		handlers.Services.CreateExtra(w, r)
+
+	case strings.HasPrefix(path, "/admin/extras/update/"):
+		handlers.Services.UpdateExtra(w, r)
+
+	case strings.HasPrefix(path, "/admin/extras/delete/"):
+		handlers.Services.DeleteExtra(w, r)
+
+	case path == "/admin/hours":
+		handlers.Services.AdminOpeningHours(w, r)
+
+	case path == "/admin/hours/slots/create":
+		handlers.Services.CreateOpeningSlot(w, r)
+
+	case strings.HasPrefix(path, "/admin/hours/slots/delete/"):
+		handlers.Services.DeleteOpeningSlot(w, r)
+
+	case path == "/admin/hours/exceptions/create":
+		handlers.Services.CreateOpeningException(w, r)
+
+	case strings.HasPrefix(path, "/admin/hours/exceptions/delete/"):
+		handlers.Services.DeleteOpeningException(w, r)
+
 	case path == "/admin/flash-message":
 		handlers.Services.CreateFlashMessage(w, r)
 
 	case strings.HasPrefix(path, "/admin/flash-message/delete/"):
 		handlers.Services.DeleteFlashMessage(w, r)
 
+	case path == "/admin/api-tokens/create":
+		handlers.Services.CreateAPIToken(w, r)
+
+	case strings.HasPrefix(path, "/admin/api-tokens/revoke/"):
+		handlers.Services.RevokeAPIToken(w, r)
+
+	case path == "/admin/users":
+		handlers.Services.AdminUsers(w, r)
+
+	case path == "/admin/users/create":
+		handlers.Services.CreateAdminUser(w, r)
+
+	case strings.HasPrefix(path, "/admin/users/update/"):
+		handlers.Services.UpdateAdminUser(w, r)
+
+	case strings.HasPrefix(path, "/admin/users/delete/"):
+		handlers.Services.DeleteAdminUser(w, r)
+
+	case path == "/admin/sessions":
+		handlers.Services.AdminSessions(w, r)
+
+	case path == "/admin/sessions/revoke-all":
+		handlers.Services.RevokeAllSessions(w, r)
+
+	case strings.HasPrefix(path, "/admin/sessions/revoke/"):
+		handlers.Services.RevokeSession(w, r)
+
+	case path == "/admin/passkeys":
+		handlers.Services.AdminPasskeys(w, r)
+
+	case path == "/admin/passkeys/options":
+		handlers.Services.PasskeyCreationOptions(w, r)
+
+	case path == "/admin/passkeys/register":
+		handlers.Services.RegisterPasskey(w, r)
+
+	case strings.HasPrefix(path, "/admin/passkeys/delete/"):
+		handlers.Services.DeletePasskey(w, r)
+
 	case path == "/admin/logout":
 		handlers.Services.HandleLogout(w, r)
 
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"errors"
+	"math"
+)
+
+// maxCBORDepth limits the nesting of decoded CBOR, which authenticators keep shallow
+const maxCBORDepth = 8
+
+// errCBOR is returned for CBOR that is malformed or uses features authenticators do not
+var errCBOR = errors.New("malformed or unsupported CBOR")
+
+// decodeCBOR decodes the first CBOR item of data, as used in WebAuthn attestation objects and
+// COSE keys, and returns the rest of data. Integers decode to int64, byte strings to []byte,
+// text to string, arrays to []any and maps to map[any]any. Indefinite lengths and floats are not
+// supported, as authenticators must not use them.
+func decodeCBOR(data []byte) (any, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+// decodeCBORItem decodes one item at the given nesting depth
+func decodeCBORItem(data []byte, depth int) (any, []byte, error) {
+	if len(data) == 0 || depth > maxCBORDepth {
+		return nil, nil, errCBOR
+	}
+
+	major, info := data[0]>>5, data[0]&0x1f
+	data = data[1:]
+
+	// Simple values: false, true and null
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22:
+			return nil, data, nil
+		default:
+			return nil, nil, errCBOR
+		}
+	}
+
+	var arg uint64
+
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info <= 27:
+		size := 1 << (info - 24)
+		if len(data) < size {
+			return nil, nil, errCBOR
+		}
+
+		for _, b := range data[:size] {
+			arg = arg<<8 | uint64(b)
+		}
+
+		data = data[size:]
+	default:
+		return nil, nil, errCBOR
+	}
+
+	switch major {
+	case 0: // Unsigned integer
+		if arg > math.MaxInt64 {
+			return nil, nil, errCBOR
+		}
+
+		return int64(arg), data, nil
+
+	case 1: // Negative integer, -1 - arg
+		if arg > math.MaxInt64 {
+			return nil, nil, errCBOR
+		}
+
+		return -1 - int64(arg), data, nil
+
+	case 2, 3: // Byte and text string
+		if arg > uint64(len(data)) {
+			return nil, nil, errCBOR
+		}
+
+		value := data[:arg]
+		if major == 3 {
+			return string(value), data[arg:], nil
+		}
+
+		return append([]byte(nil), value...), data[arg:], nil
+
+	case 4: // Array
+		if arg > uint64(len(data)) {
+			return nil, nil, errCBOR
+		}
+
+		items := make([]any, 0, arg)
+
+		for range arg {
+			var item any
+
+			var err error
+
+			if item, data, err = decodeCBORItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+
+			items = append(items, item)
+		}
+
+		return items, data, nil
+
+	case 5: // Map
+		if arg > uint64(len(data)) {
+			return nil, nil, errCBOR
+		}
+
+		entries := make(map[any]any, arg)
+
+		for range arg {
+			key, rest, err := decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			// Only integers and text can be keys; anything else cannot be a map key in Go
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errCBOR
+			}
+
+			value, rest, err := decodeCBORItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if _, duplicate := entries[key]; duplicate {
+				return nil, nil, errCBOR
+			}
+
+			entries[key] = value
+			data = rest
+		}
+
+		return entries, data, nil
+
+	default: // Tags are not used by authenticators
+		return nil, nil, errCBOR
+	}
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// OAuthConfig holds the configured sign-in providers
+type OAuthConfig struct {
+	Providers     []*Provider // In the order of OIDC_PROVIDERS, as listed on the login page
+	AllowedEmails []string    // From ALLOWED_EMAILS; seeds the first owners of an empty admin_users table
+}
+
+// providerIDPattern restricts provider IDs to what fits in a URL path and an environment variable
+var providerIDPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// legacyGoogleSettings are the settings Google also reads from GOOGLE_CLIENT_ID and so on
+var legacyGoogleSettings = []string{"CLIENT_ID", "CLIENT_SECRET", "REDIRECT_URL"}
+
+// Initialize loads the sign-in providers from the environment
+func Initialize() (*OAuthConfig, error) {
+	// Try to load .env file, but don't fail if it doesn't exist
+	// (environment variables might be set via docker-compose or system)
+	if err := godotenv.Load(); err != nil {
+		// This is normal in Docker environments where env vars are set directly
+		// Just log a debug message but don't fail
+		fmt.Printf("Info: .env file not found (using environment variables): %v\n", err)
+	}
+
+	configs, err := ProviderConfigsFromEnv(os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(configs) == 0 {
+		return nil, errors.New("missing sign-in configuration - set OIDC_PROVIDERS with the OIDC_<ID>_* variables of each provider, or GOOGLE_CLIENT_ID, GOOGLE_CLIENT_SECRET and GOOGLE_REDIRECT_URL")
+	}
+
+	providers := make([]*Provider, len(configs))
+	for i, config := range configs {
+		providers[i] = NewProvider(config, nil)
+	}
+
+	return &OAuthConfig{
+		Providers:     providers,
+		AllowedEmails: AllowedEmailsFromEnv(os.Getenv),
+	}, nil
+}
+
+// AllowedEmailsFromEnv reads the comma-separated ALLOWED_EMAILS. They only seed the first owners
+// while there are no admin users yet, whether or not sign-in is configured.
+func AllowedEmailsFromEnv(getenv func(string) string) []string {
+	var allowedEmails []string
+
+	for _, email := range strings.Split(getenv("ALLOWED_EMAILS"), ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			allowedEmails = append(allowedEmails, email)
+		}
+	}
+
+	return allowedEmails
+}
+
+// ProviderConfigsFromEnv reads the providers named in OIDC_PROVIDERS, e.g. "google,keycloak". Each
+// provider is configured with OIDC_<ID>_ISSUER, _CLIENT_ID, _CLIENT_SECRET and optionally _NAME,
+// _REDIRECT_URL and _TRUST_EMAIL. Redirect URLs default to OIDC_REDIRECT_BASE_URL followed by
+// /auth/<id>/callback.
+//
+// Google needs no issuer, and falls back to the GOOGLE_CLIENT_ID, GOOGLE_CLIENT_SECRET and
+// GOOGLE_REDIRECT_URL variables; when OIDC_PROVIDERS is not set, Google is the only provider if
+// those are.
+func ProviderConfigsFromEnv(getenv func(string) string) ([]ProviderConfig, error) {
+	ids := strings.Split(getenv("OIDC_PROVIDERS"), ",")
+	if strings.TrimSpace(getenv("OIDC_PROVIDERS")) == "" {
+		ids = nil
+
+		if getenv("GOOGLE_CLIENT_ID") != "" {
+			ids = []string{"google"}
+		}
+	}
+
+	var configs []ProviderConfig
+
+	for _, id := range ids {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if !providerIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid provider ID %q in OIDC_PROVIDERS - use letters, digits and dashes", id)
+		}
+
+		// The passkey sign-in lives at /auth/passkey/
+		if id == "passkey" {
+			return nil, fmt.Errorf("provider ID %q in OIDC_PROVIDERS is reserved for passkeys", id)
+		}
+
+		if slices.ContainsFunc(configs, func(c ProviderConfig) bool { return c.ID == id }) {
+			return nil, fmt.Errorf("provider %q is listed twice in OIDC_PROVIDERS", id)
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(id, "-", "_")) + "_"
+
+		// setting reads a provider variable; Google falls back to the GOOGLE_* variables it used
+		// before other providers were supported
+		setting := func(name string) string {
+			value := strings.TrimSpace(getenv(prefix + name))
+			if value == "" && id == "google" && slices.Contains(legacyGoogleSettings, name) {
+				value = strings.TrimSpace(getenv("GOOGLE_" + name))
+			}
+
+			return value
+		}
+
+		config := ProviderConfig{
+			ID:           id,
+			Name:         setting("NAME"),
+			Issuer:       setting("ISSUER"),
+			ClientID:     setting("CLIENT_ID"),
+			ClientSecret: setting("CLIENT_SECRET"),
+			RedirectURL:  setting("REDIRECT_URL"),
+		}
+
+		if config.Name == "" {
+			config.Name = strings.ToUpper(id[:1]) + id[1:]
+		}
+
+		if config.Issuer == "" && id == "google" {
+			config.Issuer = GoogleIssuer
+		}
+
+		if base := strings.TrimSpace(getenv("OIDC_REDIRECT_BASE_URL")); config.RedirectURL == "" && base != "" {
+			config.RedirectURL = strings.TrimSuffix(base, "/") + "/auth/" + id + "/callback"
+		}
+
+		if trust := setting("TRUST_EMAIL"); trust != "" {
+			value, err := strconv.ParseBool(trust)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %sTRUST_EMAIL: %w", prefix, err)
+			}
+
+			config.TrustEmail = value
+		}
+
+		if config.Issuer == "" || config.ClientID == "" || config.ClientSecret == "" || config.RedirectURL == "" {
+			return nil, fmt.Errorf("incomplete configuration of provider %q - ensure %sISSUER, %sCLIENT_ID, %sCLIENT_SECRET and %sREDIRECT_URL or OIDC_REDIRECT_BASE_URL are set",
+				id, prefix, prefix, prefix, prefix)
+		}
+
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+// Provider returns the provider with the given ID, or nil if there is none
+func (c *OAuthConfig) Provider(id string) *Provider {
+	if c == nil {
+		return nil
+	}
+
+	for _, p := range c.Providers {
+		if p.ID == id {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// IsAllowedEmail checks if the email is in the allowed list
+func (c *OAuthConfig) IsAllowedEmail(email string) bool {
+	return slices.Contains(c.AllowedEmails, email)
+}
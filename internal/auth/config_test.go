@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOAuthConfig_IsAllowedEmail(t *testing.T) {
+	tests := []struct {
+		name          string
+		allowedEmails []string
+		email         string
+		want          bool
+	}{
+		{
+			name:          "Email in allowed list",
+			allowedEmails: []string{"test@example.com", "admin@example.com", "owner@example.com"},
+			email:         "admin@example.com",
+			want:          true,
+		},
+		{
+			name:          "Email not in allowed list",
+			allowedEmails: []string{"test@example.com", "admin@example.com", "owner@example.com"},
+			email:         "unauthorized@example.com",
+			want:          false,
+		},
+		{
+			name:          "Empty email",
+			allowedEmails: []string{"test@example.com", "admin@example.com"},
+			email:         "",
+			want:          false,
+		},
+		{
+			name:          "Case sensitive comparison",
+			allowedEmails: []string{"Test@Example.com"},
+			email:         "test@example.com",
+			want:          false,
+		},
+		{
+			name:          "Single allowed email",
+			allowedEmails: []string{"single@example.com"},
+			email:         "single@example.com",
+			want:          true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			c := &OAuthConfig{
+				AllowedEmails: tt.allowedEmails,
+			}
+
+			if got := c.IsAllowedEmail(tt.email); got != tt.want {
+				t.Errorf("OAuthConfig.IsAllowedEmail() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProviderConfigsFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    []ProviderConfig
+		wantErr bool
+	}{
+		{
+			name: "Nothing configured",
+		},
+		{
+			name: "Google variables only",
+			env: map[string]string{
+				"GOOGLE_CLIENT_ID":     "google-id",
+				"GOOGLE_CLIENT_SECRET": "google-secret",
+				"GOOGLE_REDIRECT_URL":  "http://localhost:8080/auth/google/callback",
+			},
+			want: []ProviderConfig{{
+				ID: "google", Name: "Google", Issuer: GoogleIssuer, ClientID: "google-id", ClientSecret: "google-secret",
+				RedirectURL: "http://localhost:8080/auth/google/callback",
+			}},
+		},
+		{
+			name: "Several providers",
+			env: map[string]string{
+				"OIDC_PROVIDERS":               "google, microsoft,keycloak",
+				"OIDC_REDIRECT_BASE_URL":       "https://pizzeria.example.com/",
+				"GOOGLE_CLIENT_ID":             "google-id",
+				"GOOGLE_CLIENT_SECRET":         "google-secret",
+				"OIDC_MICROSOFT_ISSUER":        "https://login.microsoftonline.com/tenant/v2.0",
+				"OIDC_MICROSOFT_CLIENT_ID":     "ms-id",
+				"OIDC_MICROSOFT_CLIENT_SECRET": "ms-secret",
+				"OIDC_MICROSOFT_TRUST_EMAIL":   "true",
+				"OIDC_KEYCLOAK_NAME":           "Staff SSO",
+				"OIDC_KEYCLOAK_ISSUER":         "https://sso.example.com/realms/pizzeria",
+				"OIDC_KEYCLOAK_CLIENT_ID":      "kc-id",
+				"OIDC_KEYCLOAK_CLIENT_SECRET":  "kc-secret",
+				"OIDC_KEYCLOAK_REDIRECT_URL":   "https://admin.example.com/auth/keycloak/callback",
+			},
+			want: []ProviderConfig{
+				{
+					ID: "google", Name: "Google", Issuer: GoogleIssuer, ClientID: "google-id", ClientSecret: "google-secret",
+					RedirectURL: "https://pizzeria.example.com/auth/google/callback",
+				},
+				{
+					ID: "microsoft", Name: "Microsoft", Issuer: "https://login.microsoftonline.com/tenant/v2.0", ClientID: "ms-id",
+					ClientSecret: "ms-secret", RedirectURL: "https://pizzeria.example.com/auth/microsoft/callback", TrustEmail: true,
+				},
+				{
+					ID: "keycloak", Name: "Staff SSO", Issuer: "https://sso.example.com/realms/pizzeria", ClientID: "kc-id",
+					ClientSecret: "kc-secret", RedirectURL: "https://admin.example.com/auth/keycloak/callback",
+				},
+			},
+		},
+		{
+			name:    "Missing issuer",
+			env:     map[string]string{"OIDC_PROVIDERS": "keycloak", "OIDC_KEYCLOAK_CLIENT_ID": "id", "OIDC_KEYCLOAK_CLIENT_SECRET": "secret", "OIDC_REDIRECT_BASE_URL": "http://localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid ID",
+			env:     map[string]string{"OIDC_PROVIDERS": "key/cloak"},
+			wantErr: true,
+		},
+		{
+			name:    "Reserved ID",
+			env:     map[string]string{"OIDC_PROVIDERS": "passkey"},
+			wantErr: true,
+		},
+		{
+			name: "Listed twice",
+			env: map[string]string{
+				"OIDC_PROVIDERS": "google,google", "GOOGLE_CLIENT_ID": "id", "GOOGLE_CLIENT_SECRET": "secret", "OIDC_REDIRECT_BASE_URL": "http://localhost:8080",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProviderConfigsFromEnv(func(name string) string { return tt.env[name] })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProviderConfigsFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProviderConfigsFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowedEmailsFromEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{name: "Not set"},
+		{name: "Single email", value: "owner@example.com", want: []string{"owner@example.com"}},
+		{name: "Spaces and empty entries", value: " owner@example.com,, admin@example.com ,", want: []string{"owner@example.com", "admin@example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AllowedEmailsFromEnv(func(name string) string {
+				if name == "ALLOWED_EMAILS" {
+					return tt.value
+				}
+
+				return ""
+			})
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AllowedEmailsFromEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // Hashes of the RS256 and ES256 signatures
+	_ "crypto/sha512" // Hashes of the RS384, RS512, ES384 and ES512 signatures
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// clockSkew is how far the clocks of the provider and this server may drift apart
+const clockSkew = time.Minute
+
+// signatureHashes maps the supported ID token signature algorithms to their hash. Unsigned tokens
+// and HMAC signatures, which would need the client secret as key, are rejected.
+var signatureHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// ecdsaCurves maps the elliptic curve signature algorithms to the curve of their keys
+var ecdsaCurves = map[string]elliptic.Curve{
+	"ES256": elliptic.P256(),
+	"ES384": elliptic.P384(),
+	"ES512": elliptic.P521(),
+}
+
+// idTokenClaims holds the claims checked or used from an ID token
+type idTokenClaims struct {
+	Issuer          string       `json:"iss"`
+	Subject         string       `json:"sub"`
+	Audience        audience     `json:"aud"`
+	AuthorizedParty string       `json:"azp"`
+	Expiry          int64        `json:"exp"`
+	IssuedAt        int64        `json:"iat"`
+	Nonce           string       `json:"nonce"`
+	Email           string       `json:"email"`
+	EmailVerified   flexibleBool `json:"email_verified"`
+}
+
+// audience is the aud claim, which is either a single string or a list
+type audience []string
+
+// UnmarshalJSON accepts a string or a list of strings
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	*a = list
+
+	return nil
+}
+
+// flexibleBool is a boolean claim that some providers send as the string "true" or "false"
+type flexibleBool bool
+
+// UnmarshalJSON accepts a boolean or a string holding one
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	var value bool
+	if err := json.Unmarshal(data, &value); err == nil {
+		*b = flexibleBool(value)
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+
+	*b = flexibleBool(text == "true")
+
+	return nil
+}
+
+// VerifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token and
+// returns the identity it describes
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*Identity, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed ID token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed ID token header: %w", err)
+	}
+
+	hash, ok := signatureHashes[header.Alg]
+	if !ok {
+		return nil, fmt.Errorf("unsupported ID token signature algorithm %q", header.Alg)
+	}
+
+	key, err := p.signingKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed ID token signature: %w", err)
+	}
+
+	if err := verifySignature(key, header.Alg, hash, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var claims idTokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed ID token claims: %w", err)
+	}
+
+	if err := p.checkClaims(claims, nonce, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return &Identity{
+		Provider:      p.ID,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+	}, nil
+}
+
+// checkClaims checks that a token was issued by the provider to this client for the given sign-in,
+// and has not expired
+func (p *Provider) checkClaims(claims idTokenClaims, nonce string, now time.Time) error {
+	// Google also issues tokens naming its issuer without the scheme
+	validIssuer := claims.Issuer == p.Issuer ||
+		(p.Issuer == GoogleIssuer && claims.Issuer == strings.TrimPrefix(GoogleIssuer, "https://"))
+
+	switch {
+	case !validIssuer:
+		return fmt.Errorf("ID token issued by %q, want %q", claims.Issuer, p.Issuer)
+
+	case !slices.Contains(claims.Audience, p.ClientID):
+		return errors.New("ID token is not meant for this client")
+
+	case len(claims.Audience) > 1 && claims.AuthorizedParty != p.ClientID:
+		return errors.New("ID token was issued to another client")
+
+	case claims.Subject == "":
+		return errors.New("ID token has no subject")
+
+	case now.After(time.Unix(claims.Expiry, 0).Add(clockSkew)):
+		return errors.New("ID token has expired")
+
+	case time.Unix(claims.IssuedAt, 0).After(now.Add(clockSkew)):
+		return errors.New("ID token was issued in the future")
+
+	case nonce == "" || claims.Nonce != nonce:
+		return errors.New("ID token nonce does not match the sign-in")
+	}
+
+	return nil
+}
+
+// signingKey returns the provider key with the given ID. Unknown keys cause the key set to be
+// fetched again, as providers rotate their keys, but not more than once per keyRefreshInterval.
+func (p *Provider) signingKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := lookupKey(p.keys, kid); ok {
+		return key, nil
+	}
+
+	if p.keys != nil && time.Since(p.keysAt) < keyRefreshInterval {
+		return nil, fmt.Errorf("ID token signed with unknown key %q", kid)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	if err := p.getJSON(ctx, doc.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetching the keys of %s: %w", p.ID, err)
+	}
+
+	p.keys = make(map[string]crypto.PublicKey)
+	p.keysAt = time.Now()
+
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		// Keys of unsupported types cannot sign the tokens we accept anyway
+		if key, err := jwk.publicKey(); err == nil {
+			p.keys[jwk.Kid] = key
+		}
+	}
+
+	if key, ok := lookupKey(p.keys, kid); ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("ID token signed with unknown key %q", kid)
+}
+
+// lookupKey finds a key by ID. A token without key ID is accepted when the provider has a single key.
+func lookupKey(keys map[string]crypto.PublicKey, kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+
+	key, ok := keys[kid]
+
+	return key, ok
+}
+
+// jsonWebKey holds the fields of an RSA or elliptic curve public key in a JSON Web Key Set
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey decodes the key
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return rsaPublicKey(n, e)
+
+	case "EC":
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return ecdsaPublicKey(k.Crv, x, y)
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// rsaPublicKey returns the RSA key with modulus n and exponent e, rejecting keys below 2048 bits
+func rsaPublicKey(n, e *big.Int) (*rsa.PublicKey, error) {
+	if n.BitLen() < 2048 || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, errors.New("weak or invalid RSA key")
+	}
+
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+// ecdsaPublicKey returns the elliptic curve key at point x, y of the named curve, e.g. "P-256"
+func ecdsaPublicKey(crv string, x, y *big.Int) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+
+	var ecdhCurve ecdh.Curve
+
+	switch crv {
+	case "P-256":
+		curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", crv)
+	}
+
+	size := (curve.Params().BitSize + 7) / 8
+	if x.BitLen() > 8*size || y.BitLen() > 8*size {
+		return nil, errors.New("invalid EC key")
+	}
+
+	// Parsing the uncompressed point checks that it lies on the curve
+	point := append([]byte{4}, append(x.FillBytes(make([]byte, size)), y.FillBytes(make([]byte, size))...)...)
+
+	if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+		return nil, fmt.Errorf("invalid EC key: %w", err)
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// verifySignature checks the signature of a token with the given key and algorithm
+func verifySignature(key crypto.PublicKey, alg string, hash crypto.Hash, signed, signature []byte) error {
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") || rsa.VerifyPKCS1v15(key, hash, digest, signature) != nil {
+			return errors.New("invalid ID token signature")
+		}
+
+	case *ecdsa.PublicKey:
+		// The signature is r and s, each padded to the size of the curve
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if key.Curve != ecdsaCurves[alg] || len(signature) != 2*size {
+			return errors.New("invalid ID token signature")
+		}
+
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+
+		if !ecdsa.Verify(key, digest, r, s) {
+			return errors.New("invalid ID token signature")
+		}
+
+	default:
+		return errors.New("unsupported key type")
+	}
+
+	return nil
+}
+
+// decodeSegment decodes a base64url encoded JSON segment of a token
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// decodeBigInt decodes a base64url encoded big-endian integer of a JSON Web Key
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// GoogleIssuer is the issuer of Google accounts
+const GoogleIssuer = "https://accounts.google.com"
+
+// keyRefreshInterval limits how often the signing keys of a provider are fetched again when an ID
+// token names a key that is not known yet
+const keyRefreshInterval = time.Minute
+
+// ProviderConfig describes an OpenID Connect provider admins can sign in with
+type ProviderConfig struct {
+	ID           string // Used in the login and callback URLs, e.g. "google" for /auth/google/login
+	Name         string // Shown on the login page
+	Issuer       string // Its discovery document is at Issuer + "/.well-known/openid-configuration"
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// TrustEmail accepts email addresses the provider does not mark as verified. Only set it for a
+	// provider whose accounts the restaurant controls, e.g. its own Microsoft Entra tenant.
+	TrustEmail bool
+}
+
+// Provider signs admins in with OpenID Connect: the authorization code flow with PKCE, followed
+// by checking the signature, issuer, audience, expiry and nonce of the ID token. The provider's
+// endpoints and keys are discovered on first use.
+type Provider struct {
+	ProviderConfig
+
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *discoveryDocument
+	keys      map[string]crypto.PublicKey
+	keysAt    time.Time
+}
+
+// discoveryDocument holds the fields used from a provider's .well-known/openid-configuration
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// LoginAttempt holds the secrets of one sign-in, from the redirect to the provider until the
+// callback. They are kept in a short-lived cookie in the meantime.
+type LoginAttempt struct {
+	State    string // Returned with the callback; ties it to the browser that started the sign-in
+	Nonce    string // Returned in the ID token; ties the token to this sign-in
+	Verifier string // PKCE code verifier; only the client that started the sign-in can redeem the code
+}
+
+// Identity is the signed-in user as described by a verified ID token
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// NewProvider returns a provider for the given configuration. Requests to the provider use client,
+// or a client with a 10 second timeout when client is nil.
+func NewProvider(config ProviderConfig, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &Provider{ProviderConfig: config, client: client}
+}
+
+// NewLoginAttempt generates the random state, nonce and PKCE verifier of a sign-in
+func NewLoginAttempt() (LoginAttempt, error) {
+	var secrets [2]string
+
+	for i := range secrets {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return LoginAttempt{}, fmt.Errorf("failed to generate random bytes: %w", err)
+		}
+
+		secrets[i] = base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	return LoginAttempt{State: secrets[0], Nonce: secrets[1], Verifier: oauth2.GenerateVerifier()}, nil
+}
+
+// AuthCodeURL returns the URL of the provider's sign-in page for the given attempt
+func (p *Provider) AuthCodeURL(ctx context.Context, attempt LoginAttempt) (string, error) {
+	config, err := p.oauth2Config(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return config.AuthCodeURL(attempt.State,
+		oauth2.S256ChallengeOption(attempt.Verifier),
+		oauth2.SetAuthURLParam("nonce", attempt.Nonce)), nil
+}
+
+// Exchange redeems the authorization code of a callback and returns the identity in its verified
+// ID token
+func (p *Provider) Exchange(ctx context.Context, code string, attempt LoginAttempt) (*Identity, error) {
+	config, err := p.oauth2Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := config.Exchange(context.WithValue(ctx, oauth2.HTTPClient, p.client), code,
+		oauth2.VerifierOption(attempt.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("code exchange failed: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("token response has no ID token")
+	}
+
+	return p.VerifyIDToken(ctx, rawIDToken, attempt.Nonce)
+}
+
+// oauth2Config returns the OAuth 2 configuration for the discovered endpoints
+func (p *Provider) oauth2Config(ctx context.Context) (*oauth2.Config, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		RedirectURL:  p.RedirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+		Endpoint:     oauth2.Endpoint{AuthURL: doc.AuthorizationEndpoint, TokenURL: doc.TokenEndpoint},
+	}, nil
+}
+
+// discover fetches the provider's discovery document once. A failed attempt is retried with the
+// next sign-in.
+func (p *Provider) discover(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var doc discoveryDocument
+
+	discoveryURL := strings.TrimSuffix(p.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, discoveryURL, &doc); err != nil {
+		return nil, fmt.Errorf("discovering %s: %w", p.ID, err)
+	}
+
+	// A provider may only speak for its own issuer
+	if doc.Issuer != p.Issuer {
+		return nil, fmt.Errorf("discovering %s: document is for issuer %q, want %q", p.ID, doc.Issuer, p.Issuer)
+	}
+
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("discovering %s: document lacks an authorization, token or JWKS endpoint", p.ID)
+	}
+
+	p.discovery = &doc
+
+	return p.discovery, nil
+}
+
+// getJSON fetches a JSON document from the provider
+func (p *Provider) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
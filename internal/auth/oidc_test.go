@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/auth/oidctest"
+)
+
+// newTestProvider returns a provider for a fake OIDC server
+func newTestProvider(server *oidctest.Server) *Provider {
+	return NewProvider(ProviderConfig{
+		ID:           "fake",
+		Name:         "Fake",
+		Issuer:       server.URL,
+		ClientID:     oidctest.ClientID,
+		ClientSecret: oidctest.ClientSecret,
+		RedirectURL:  "http://localhost:8080/auth/fake/callback",
+	}, server.Client())
+}
+
+// authorize follows the provider's sign-in page for the attempt and returns the code it redirects back with
+func authorize(t *testing.T, server *oidctest.Server, provider *Provider, attempt LoginAttempt) string {
+	t.Helper()
+
+	authURL, err := provider.AuthCodeURL(context.Background(), attempt)
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+
+	client := server.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("GET %s: %v", authURL, err)
+	}
+	resp.Body.Close()
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorization returned %v to %q, want a redirect", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	if callback.Query().Get("state") != attempt.State {
+		t.Fatalf("callback state = %q, want %q", callback.Query().Get("state"), attempt.State)
+	}
+
+	return callback.Query().Get("code")
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	server := oidctest.NewServer(t)
+	provider := newTestProvider(server)
+	ctx := context.Background()
+
+	attempt, err := NewLoginAttempt()
+	if err != nil {
+		t.Fatalf("NewLoginAttempt() error = %v", err)
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, attempt)
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+
+	for _, param := range []string{"state=" + attempt.State, "nonce=" + attempt.Nonce, "code_challenge_method=S256", "scope=openid+email+profile"} {
+		if !strings.Contains(authURL, param) {
+			t.Errorf("AuthCodeURL() = %q, want it to contain %q", authURL, param)
+		}
+	}
+
+	if strings.Contains(authURL, attempt.Verifier) {
+		t.Error("AuthCodeURL() contains the PKCE verifier")
+	}
+
+	identity, err := provider.Exchange(ctx, authorize(t, server, provider, attempt), attempt)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+
+	if identity.Email != "admin@example.com" || !identity.EmailVerified || identity.Provider != "fake" || identity.Subject == "" {
+		t.Errorf("Exchange() = %+v, want the verified identity of admin@example.com", identity)
+	}
+
+	// The code cannot be redeemed without the right verifier, nor twice
+	code := authorize(t, server, provider, attempt)
+
+	stolen := attempt
+	stolen.Verifier = strings.Repeat("x", 43)
+
+	if _, err := provider.Exchange(ctx, code, stolen); err == nil {
+		t.Error("Exchange() with the wrong PKCE verifier succeeded")
+	}
+
+	if _, err := provider.Exchange(ctx, code, attempt); err == nil {
+		t.Error("Exchange() of a used code succeeded")
+	}
+
+	// The nonce ties the ID token to the sign-in it was requested for
+	replayed := attempt
+	replayed.Nonce = "another-sign-in"
+
+	if _, err := provider.Exchange(ctx, authorize(t, server, provider, attempt), replayed); err == nil {
+		t.Error("Exchange() with a different nonce succeeded")
+	}
+}
+
+func TestProvider_VerifyIDToken(t *testing.T) {
+	server := oidctest.NewServer(t)
+	provider := newTestProvider(server)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		modify  func(claims map[string]any)
+		token   func(token string) string
+		wantErr bool
+	}{
+		{
+			name: "Valid token",
+		},
+		{
+			name: "Audience list with this client as authorized party",
+			modify: func(c map[string]any) {
+				c["aud"] = []string{"other-client", oidctest.ClientID}
+				c["azp"] = oidctest.ClientID
+			},
+		},
+		{
+			name:   "Email verified sent as string",
+			modify: func(c map[string]any) { c["email_verified"] = "true" },
+		},
+		{
+			name:    "Other issuer",
+			modify:  func(c map[string]any) { c["iss"] = "https://evil.example.com" },
+			wantErr: true,
+		},
+		{
+			name:    "Other audience",
+			modify:  func(c map[string]any) { c["aud"] = "other-client" },
+			wantErr: true,
+		},
+		{
+			name: "Issued to another client",
+			modify: func(c map[string]any) {
+				c["aud"] = []string{"other-client", oidctest.ClientID}
+				c["azp"] = "other-client"
+			},
+			wantErr: true,
+		},
+		{
+			name:    "Expired",
+			modify:  func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+			wantErr: true,
+		},
+		{
+			name:    "Issued in the future",
+			modify:  func(c map[string]any) { c["iat"] = time.Now().Add(time.Hour).Unix() },
+			wantErr: true,
+		},
+		{
+			name:    "Wrong nonce",
+			modify:  func(c map[string]any) { c["nonce"] = "other" },
+			wantErr: true,
+		},
+		{
+			name:    "No subject",
+			modify:  func(c map[string]any) { delete(c, "sub") },
+			wantErr: true,
+		},
+		{
+			name: "Tampered claims",
+			token: func(token string) string {
+				parts := strings.Split(token, ".")
+				forged := server.SignIDToken(server.Claims("owner@example.com", "nonce-1"))
+				return parts[0] + "." + strings.Split(forged, ".")[1] + "." + parts[2]
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unsigned",
+			token: func(token string) string {
+				parts := strings.Split(token, ".")
+				return "eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0." + parts[1] + "."
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := server.Claims("admin@example.com", "nonce-1")
+			if tt.modify != nil {
+				tt.modify(claims)
+			}
+
+			token := server.SignIDToken(claims)
+			if tt.token != nil {
+				token = tt.token(token)
+			}
+
+			identity, err := provider.VerifyIDToken(ctx, token, "nonce-1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyIDToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && (identity.Email != "admin@example.com" || !identity.EmailVerified) {
+				t.Errorf("VerifyIDToken() = %+v, want the verified identity of admin@example.com", identity)
+			}
+		})
+	}
+
+	// A rotated key is fetched once it signs a token
+	server.RotateKey(t)
+	provider.keysAt = time.Time{}
+
+	if _, err := provider.VerifyIDToken(ctx, server.SignIDToken(server.Claims("admin@example.com", "nonce-1")), "nonce-1"); err != nil {
+		t.Errorf("VerifyIDToken() after key rotation error = %v", err)
+	}
+}
+
+func TestProvider_DiscoveryIssuerMismatch(t *testing.T) {
+	server := oidctest.NewServer(t)
+
+	provider := newTestProvider(server)
+	provider.Issuer = server.URL + "/"
+
+	if _, err := provider.AuthCodeURL(context.Background(), LoginAttempt{}); err == nil {
+		t.Error("AuthCodeURL() succeeded for a discovery document of another issuer")
+	}
+}
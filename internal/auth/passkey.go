@@ -0,0 +1,423 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+)
+
+// Flags of the authenticator data
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+// COSE algorithms of the passkeys that can be registered, in order of preference
+const (
+	coseES256 = -7
+	coseEdDSA = -8
+	coseRS256 = -257
+)
+
+// Base64URL is binary data, encoded in JSON as unpadded base64url like WebAuthn clients do
+type Base64URL []byte
+
+// MarshalJSON encodes the data as an unpadded base64url string
+func (b Base64URL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+// UnmarshalJSON decodes an unpadded base64url string
+func (b *Base64URL) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(text)
+	if err != nil {
+		return err
+	}
+
+	*b = decoded
+
+	return nil
+}
+
+// RelyingParty registers passkeys (WebAuthn credentials) and verifies sign-ins with them for the
+// admin pages at one origin
+type RelyingParty struct {
+	ID     string // Domain the passkeys are bound to: the host name of Origin
+	Name   string // Shown by the browser when creating a passkey
+	Origin string // Scheme and host the admin pages are served from
+}
+
+// NewRelyingParty returns the relying party for the given origin, e.g. "https://pizzeria.example.com"
+func NewRelyingParty(origin string) (*RelyingParty, error) {
+	if origin == "" {
+		return nil, errors.New("WEBAUTHN_ORIGIN is not set")
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" || (u.Path != "" && u.Path != "/") {
+		return nil, fmt.Errorf("invalid WEBAUTHN_ORIGIN %q - use the scheme and host the admin pages are served from, e.g. https://pizzeria.example.com", origin)
+	}
+
+	return &RelyingParty{ID: u.Hostname(), Name: "La Piccola Sardegna", Origin: u.Scheme + "://" + u.Host}, nil
+}
+
+// CreationOptions are the options for navigator.credentials.create() to register a passkey
+type CreationOptions struct {
+	Challenge              Base64URL              `json:"challenge"`
+	RP                     relyingPartyEntity     `json:"rp"`
+	User                   userEntity             `json:"user"`
+	PubKeyCredParams       []credentialParameters `json:"pubKeyCredParams"`
+	Attestation            string                 `json:"attestation"`
+	AuthenticatorSelection authenticatorSelection `json:"authenticatorSelection"`
+	ExcludeCredentials     []credentialDescriptor `json:"excludeCredentials"`
+}
+
+// RequestOptions are the options for navigator.credentials.get() to sign in with a passkey
+type RequestOptions struct {
+	Challenge        Base64URL              `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	UserVerification string                 `json:"userVerification"`
+	AllowCredentials []credentialDescriptor `json:"allowCredentials"`
+}
+
+type relyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type userEntity struct {
+	ID          Base64URL `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"displayName"`
+}
+
+type credentialParameters struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type authenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+type credentialDescriptor struct {
+	Type string    `json:"type"`
+	ID   Base64URL `json:"id"`
+}
+
+// RegistrationResponse is the credential returned by navigator.credentials.create()
+type RegistrationResponse struct {
+	ID                Base64URL `json:"id"`
+	ClientDataJSON    Base64URL `json:"clientDataJSON"`
+	AttestationObject Base64URL `json:"attestationObject"`
+}
+
+// AssertionResponse is the credential returned by navigator.credentials.get()
+type AssertionResponse struct {
+	ID                Base64URL `json:"id"`
+	ClientDataJSON    Base64URL `json:"clientDataJSON"`
+	AuthenticatorData Base64URL `json:"authenticatorData"`
+	Signature         Base64URL `json:"signature"`
+	UserHandle        Base64URL `json:"userHandle"`
+}
+
+// Credential is a newly registered passkey
+type Credential struct {
+	ID        []byte
+	PublicKey []byte // PKIX (DER) encoded
+	SignCount uint32
+}
+
+// CreationOptions returns the options to register a passkey for the given admin. The user handle
+// identifies the admin to the authenticator; exclude lists the admin's passkeys, so an
+// authenticator holding one of them does not create another.
+func (rp *RelyingParty) CreationOptions(challenge, userHandle []byte, email string, exclude [][]byte) CreationOptions {
+	options := CreationOptions{
+		Challenge: challenge,
+		RP:        relyingPartyEntity{ID: rp.ID, Name: rp.Name},
+		User:      userEntity{ID: userHandle, Name: email, DisplayName: email},
+		PubKeyCredParams: []credentialParameters{
+			{Type: "public-key", Alg: coseES256},
+			{Type: "public-key", Alg: coseEdDSA},
+			{Type: "public-key", Alg: coseRS256},
+		},
+		// Passkeys are trusted because a signed-in admin registers them, not because of their make
+		Attestation: "none",
+		// Discoverable, so signing in needs no email, and unlocked with a PIN or biometrics
+		AuthenticatorSelection: authenticatorSelection{ResidentKey: "required", UserVerification: "required"},
+		ExcludeCredentials:     []credentialDescriptor{},
+	}
+
+	for _, id := range exclude {
+		options.ExcludeCredentials = append(options.ExcludeCredentials, credentialDescriptor{Type: "public-key", ID: id})
+	}
+
+	return options
+}
+
+// RequestOptions returns the options to sign in with any passkey of this relying party
+func (rp *RelyingParty) RequestOptions(challenge []byte) RequestOptions {
+	return RequestOptions{
+		Challenge:        challenge,
+		RPID:             rp.ID,
+		UserVerification: "required",
+		AllowCredentials: []credentialDescriptor{},
+	}
+}
+
+// clientData holds the fields checked in the client data of a response
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// ClientDataChallenge returns the challenge a response answers. Callers look it up among the
+// challenges they issued before verifying the response.
+func ClientDataChallenge(clientDataJSON []byte) ([]byte, error) {
+	var data clientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil {
+		return nil, fmt.Errorf("malformed client data: %w", err)
+	}
+
+	challenge, err := base64.RawURLEncoding.DecodeString(data.Challenge)
+	if err != nil || len(challenge) == 0 {
+		return nil, errors.New("malformed client data challenge")
+	}
+
+	return challenge, nil
+}
+
+// checkClientData checks that the client data belongs to the given ceremony ("webauthn.create" or
+// "webauthn.get") with the challenge, made on a page of our origin
+func (rp *RelyingParty) checkClientData(clientDataJSON []byte, ceremony string, challenge []byte) error {
+	var data clientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil {
+		return fmt.Errorf("malformed client data: %w", err)
+	}
+
+	received, err := base64.RawURLEncoding.DecodeString(data.Challenge)
+
+	switch {
+	case data.Type != ceremony:
+		return fmt.Errorf("client data is for %q, want %q", data.Type, ceremony)
+
+	case err != nil || subtle.ConstantTimeCompare(received, challenge) != 1:
+		return errors.New("client data answers another challenge")
+
+	case data.Origin != rp.Origin || data.CrossOrigin:
+		return fmt.Errorf("passkey used on %q, want %q", data.Origin, rp.Origin)
+	}
+
+	return nil
+}
+
+// authenticatorData holds the parsed authenticator data of a response
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte      // Only when registering
+	publicKey    map[any]any // COSE key; only when registering
+}
+
+// parseAuthenticatorData parses authenticator data: the relying party ID hash, flags, signature
+// counter and, when registering, the new credential
+func parseAuthenticatorData(data []byte) (authenticatorData, error) {
+	if len(data) < 37 {
+		return authenticatorData{}, errors.New("authenticator data too short")
+	}
+
+	ad := authenticatorData{rpIDHash: data[:32], flags: data[32], signCount: binary.BigEndian.Uint32(data[33:37])}
+
+	if ad.flags&flagAttestedData == 0 {
+		return ad, nil
+	}
+
+	// Attested credential data: AAGUID, credential ID length and ID, then the COSE key
+	rest := data[37:]
+	if len(rest) < 18 {
+		return authenticatorData{}, errors.New("attested credential data too short")
+	}
+
+	idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	if idLength == 0 || idLength > 1023 || len(rest) < 18+idLength {
+		return authenticatorData{}, errors.New("invalid credential ID")
+	}
+
+	ad.credentialID = rest[18 : 18+idLength]
+
+	key, _, err := decodeCBOR(rest[18+idLength:])
+	if err != nil {
+		return authenticatorData{}, fmt.Errorf("malformed credential public key: %w", err)
+	}
+
+	if ad.publicKey, _ = key.(map[any]any); ad.publicKey == nil {
+		return authenticatorData{}, errors.New("malformed credential public key")
+	}
+
+	return ad, nil
+}
+
+// checkAuthenticatorData checks that the authenticator acted for our relying party ID and that
+// the admin was present and verified with a PIN or biometrics
+func (rp *RelyingParty) checkAuthenticatorData(ad authenticatorData) error {
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+
+	switch {
+	case !bytes.Equal(ad.rpIDHash, rpIDHash[:]):
+		return errors.New("passkey belongs to another site")
+
+	case ad.flags&flagUserPresent == 0, ad.flags&flagUserVerified == 0:
+		return errors.New("the user was not verified by the authenticator")
+	}
+
+	return nil
+}
+
+// VerifyRegistration checks the response to CreationOptions with the given challenge and returns
+// the new passkey. Attestation statements are not checked, as none is requested.
+func (rp *RelyingParty) VerifyRegistration(response RegistrationResponse, challenge []byte) (*Credential, error) {
+	if err := rp.checkClientData(response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	object, _, err := decodeCBOR(response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("malformed attestation object: %w", err)
+	}
+
+	fields, _ := object.(map[any]any)
+
+	rawAuthData, ok := fields["authData"].([]byte)
+	if !ok {
+		return nil, errors.New("attestation object has no authenticator data")
+	}
+
+	ad, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rp.checkAuthenticatorData(ad); err != nil {
+		return nil, err
+	}
+
+	if ad.credentialID == nil || !bytes.Equal(ad.credentialID, response.ID) {
+		return nil, errors.New("authenticator data does not describe the new credential")
+	}
+
+	key, err := coseKeyToPublicKey(ad.publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{ID: ad.credentialID, PublicKey: der, SignCount: ad.signCount}, nil
+}
+
+// VerifyAssertion checks the response to RequestOptions with the given challenge against the
+// stored passkey: its PKIX public key, the user handle of its admin and its last signature
+// counter. It returns the new signature counter to store.
+func (rp *RelyingParty) VerifyAssertion(response AssertionResponse, challenge, publicKey, userHandle []byte, signCount uint32) (uint32, error) {
+	if err := rp.checkClientData(response.ClientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+
+	ad, err := parseAuthenticatorData(response.AuthenticatorData)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := rp.checkAuthenticatorData(ad); err != nil {
+		return 0, err
+	}
+
+	if len(response.UserHandle) > 0 && !bytes.Equal(response.UserHandle, userHandle) {
+		return 0, errors.New("passkey belongs to another user")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(publicKey)
+	if err != nil {
+		return 0, fmt.Errorf("stored public key: %w", err)
+	}
+
+	// The authenticator signs its data followed by the hash of the client data
+	clientDataHash := sha256.Sum256(response.ClientDataJSON)
+	signed := append(append([]byte(nil), response.AuthenticatorData...), clientDataHash[:]...)
+	digest := sha256.Sum256(signed)
+
+	var valid bool
+
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, digest[:], response.Signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], response.Signature) == nil
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, signed, response.Signature)
+	}
+
+	if !valid {
+		return 0, errors.New("invalid passkey signature")
+	}
+
+	// Authenticators with a counter increase it with every signature; a counter that did not
+	// increase means the passkey was copied
+	if (ad.signCount != 0 || signCount != 0) && ad.signCount <= signCount {
+		return 0, errors.New("passkey signature counter did not increase; it may have been cloned")
+	}
+
+	return ad.signCount, nil
+}
+
+// coseKeyToPublicKey converts a COSE key of one of the accepted algorithms
+func coseKeyToPublicKey(key map[any]any) (crypto.PublicKey, error) {
+	kty, _ := key[int64(1)].(int64)
+	alg, _ := key[int64(3)].(int64)
+	crv, _ := key[int64(-1)].(int64)
+
+	// Parameter -1 is the curve of EC2 and OKP keys, followed by their coordinates; RSA keys have
+	// the modulus and exponent instead
+	param1, _ := key[int64(-1)].([]byte)
+	param2, _ := key[int64(-2)].([]byte)
+	param3, _ := key[int64(-3)].([]byte)
+
+	switch {
+	case kty == 2 && alg == coseES256 && crv == 1:
+		return ecdsaPublicKey("P-256", new(big.Int).SetBytes(param2), new(big.Int).SetBytes(param3))
+
+	case kty == 1 && alg == coseEdDSA && crv == 6 && len(param2) == ed25519.PublicKeySize:
+		return ed25519.PublicKey(param2), nil
+
+	case kty == 3 && alg == coseRS256 && len(param1) > 0 && len(param2) > 0:
+		return rsaPublicKey(new(big.Int).SetBytes(param1), new(big.Int).SetBytes(param2))
+
+	default:
+		return nil, fmt.Errorf("unsupported passkey key type %d with algorithm %d", kty, alg)
+	}
+}
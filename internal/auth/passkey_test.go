@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/AlexTLDR/pizzeria/internal/auth/passkeytest"
+)
+
+// decodeResponse converts the JSON posted by the admin pages into a response to verify
+func decodeResponse[T any](t *testing.T, response passkeytest.Response) T {
+	t.Helper()
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("failed to encode response: %v", err)
+	}
+
+	var decoded T
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	return decoded
+}
+
+func TestNewRelyingParty(t *testing.T) {
+	tests := []struct {
+		origin  string
+		want    RelyingParty
+		wantErr bool
+	}{
+		{origin: "https://pizzeria.example.com", want: RelyingParty{ID: "pizzeria.example.com", Name: "La Piccola Sardegna", Origin: "https://pizzeria.example.com"}},
+		{origin: "http://localhost:8080/", want: RelyingParty{ID: "localhost", Name: "La Piccola Sardegna", Origin: "http://localhost:8080"}},
+		{origin: "", wantErr: true},
+		{origin: "pizzeria.example.com", wantErr: true},
+		{origin: "https://pizzeria.example.com/admin", wantErr: true},
+		{origin: "ftp://pizzeria.example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := NewRelyingParty(tt.origin)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewRelyingParty(%q) error = %v, wantErr %v", tt.origin, err, tt.wantErr)
+			continue
+		}
+
+		if err == nil && *got != tt.want {
+			t.Errorf("NewRelyingParty(%q) = %+v, want %+v", tt.origin, *got, tt.want)
+		}
+	}
+}
+
+func TestRelyingParty_Registration(t *testing.T) {
+	rp, err := NewRelyingParty("https://pizzeria.example.com")
+	if err != nil {
+		t.Fatalf("NewRelyingParty() error = %v", err)
+	}
+
+	challenge := []byte("registration-challenge-0123456789")
+	userHandle := []byte("1")
+
+	tests := []struct {
+		name    string
+		modify  func(a *passkeytest.Authenticator)
+		wantErr bool
+	}{
+		{
+			name: "Valid registration",
+		},
+		{
+			name:    "Created on another site",
+			modify:  func(a *passkeytest.Authenticator) { a.Origin = "https://pizzeria-example.com" },
+			wantErr: true,
+		},
+		{
+			name:    "Created for another relying party",
+			modify:  func(a *passkeytest.Authenticator) { a.RPID = "example.com" },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := passkeytest.New(t, rp.Origin)
+			if tt.modify != nil {
+				tt.modify(authenticator)
+			}
+
+			response := decodeResponse[RegistrationResponse](t, authenticator.Create(t, challenge, userHandle))
+
+			credential, err := rp.VerifyRegistration(response, challenge)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyRegistration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && string(credential.ID) != string(authenticator.CredentialID()) {
+				t.Errorf("VerifyRegistration() credential ID = %x, want %x", credential.ID, authenticator.CredentialID())
+			}
+		})
+	}
+
+	// A response answers only the challenge it was created for
+	authenticator := passkeytest.New(t, rp.Origin)
+	response := decodeResponse[RegistrationResponse](t, authenticator.Create(t, challenge, userHandle))
+
+	if _, err := rp.VerifyRegistration(response, []byte("another-challenge")); err == nil {
+		t.Error("VerifyRegistration() with another challenge succeeded")
+	}
+
+	got, err := ClientDataChallenge(response.ClientDataJSON)
+	if err != nil || string(got) != string(challenge) {
+		t.Errorf("ClientDataChallenge() = %q, %v, want %q", got, err, challenge)
+	}
+
+	response.AttestationObject = response.AttestationObject[:len(response.AttestationObject)-10]
+	if _, err := rp.VerifyRegistration(response, challenge); err == nil {
+		t.Error("VerifyRegistration() of a truncated attestation object succeeded")
+	}
+}
+
+func TestRelyingParty_VerifyAssertion(t *testing.T) {
+	rp, err := NewRelyingParty("https://pizzeria.example.com")
+	if err != nil {
+		t.Fatalf("NewRelyingParty() error = %v", err)
+	}
+
+	userHandle := []byte("1")
+	authenticator := passkeytest.New(t, rp.Origin)
+
+	credential, err := rp.VerifyRegistration(decodeResponse[RegistrationResponse](t, authenticator.Create(t, []byte("registration"), userHandle)), []byte("registration"))
+	if err != nil {
+		t.Fatalf("VerifyRegistration() error = %v", err)
+	}
+
+	challenge := []byte("sign-in-challenge-0123456789")
+	signCount := credential.SignCount
+
+	tests := []struct {
+		name       string
+		modify     func(r *AssertionResponse)
+		userHandle []byte
+		wantErr    bool
+	}{
+		{
+			name:       "Valid sign-in",
+			userHandle: userHandle,
+		},
+		{
+			name:       "Passkey of another user",
+			userHandle: []byte("2"),
+			wantErr:    true,
+		},
+		{
+			name:       "Tampered signature",
+			modify:     func(r *AssertionResponse) { r.Signature[len(r.Signature)-1] ^= 0xff },
+			userHandle: userHandle,
+			wantErr:    true,
+		},
+		{
+			name:       "Tampered authenticator data",
+			modify:     func(r *AssertionResponse) { r.AuthenticatorData[36]++ },
+			userHandle: userHandle,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := decodeResponse[AssertionResponse](t, authenticator.Get(t, challenge))
+			if tt.modify != nil {
+				tt.modify(&response)
+			}
+
+			got, err := rp.VerifyAssertion(response, challenge, credential.PublicKey, tt.userHandle, signCount)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyAssertion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil {
+				if got != authenticator.SignCount {
+					t.Errorf("VerifyAssertion() = %d, want the authenticator's counter %d", got, authenticator.SignCount)
+				}
+
+				signCount = got
+			}
+		})
+	}
+
+	// A passkey whose counter went backwards was copied to another authenticator
+	authenticator.SignCount = 0
+
+	response := decodeResponse[AssertionResponse](t, authenticator.Get(t, challenge))
+	if _, err := rp.VerifyAssertion(response, challenge, credential.PublicKey, userHandle, signCount); err == nil {
+		t.Error("VerifyAssertion() with a decreased signature counter succeeded")
+	}
+
+	// Passkeys used on a phishing site are signed for its origin and relying party ID
+	authenticator.SignCount = signCount
+	authenticator.Origin = "https://pizzeria-example.com"
+
+	response = decodeResponse[AssertionResponse](t, authenticator.Get(t, challenge))
+	if _, err := rp.VerifyAssertion(response, challenge, credential.PublicKey, userHandle, signCount); err == nil {
+		t.Error("VerifyAssertion() for another origin succeeded")
+	}
+}
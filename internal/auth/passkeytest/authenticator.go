@@ -0,0 +1,211 @@
+// Package passkeytest provides a virtual WebAuthn authenticator for tests. It holds one ES256
+// passkey and answers challenges the way a browser and authenticator do together, returning the
+// JSON posted by the admin pages.
+package passkeytest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/url"
+	"testing"
+)
+
+// Response is a passkey credential as posted by the admin pages, with binary fields encoded as
+// unpadded base64url. Registrations have an attestation object, sign-ins the other fields.
+type Response struct {
+	ID                string `json:"id"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AttestationObject string `json:"attestationObject,omitempty"`
+	AuthenticatorData string `json:"authenticatorData,omitempty"`
+	Signature         string `json:"signature,omitempty"`
+	UserHandle        string `json:"userHandle,omitempty"`
+}
+
+// Authenticator is a virtual authenticator holding a single passkey
+type Authenticator struct {
+	// Origin is the page the browser uses the passkey on, and RPID the relying party ID the
+	// authenticator signs for; change them to act like a phishing site
+	Origin string
+	RPID   string
+
+	// SignCount is the signature counter, increased before every sign-in
+	SignCount uint32
+
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+}
+
+// New returns an authenticator with a new passkey for the given origin, e.g. "https://example.com"
+func New(t testing.TB, origin string) *Authenticator {
+	t.Helper()
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		t.Fatalf("invalid origin %q: %v", origin, err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate passkey: %v", err)
+	}
+
+	credentialID := make([]byte, 16)
+	if _, err := rand.Read(credentialID); err != nil {
+		t.Fatalf("failed to generate credential ID: %v", err)
+	}
+
+	return &Authenticator{Origin: origin, RPID: u.Hostname(), key: key, credentialID: credentialID}
+}
+
+// CredentialID returns the ID of the passkey
+func (a *Authenticator) CredentialID() []byte {
+	return a.credentialID
+}
+
+// Create registers the passkey for the user handle, answering the challenge of
+// navigator.credentials.create()
+func (a *Authenticator) Create(t testing.TB, challenge, userHandle []byte) Response {
+	t.Helper()
+
+	a.userHandle = userHandle
+
+	// Attested credential data: an all-zero AAGUID, the credential ID and the COSE public key
+	attested := make([]byte, 16, 18+len(a.credentialID))
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, a.coseKey(t)...)
+
+	authData := a.authenticatorData(0x01|0x04|0x40, attested)
+
+	// {"fmt": "none", "attStmt": {}, "authData": authData}
+	object := cborHead(5, 3)
+	object = append(object, cborText("fmt")...)
+	object = append(object, cborText("none")...)
+	object = append(object, cborText("attStmt")...)
+	object = append(object, cborHead(5, 0)...)
+	object = append(object, cborText("authData")...)
+	object = append(object, cborBytes(authData)...)
+
+	return Response{
+		ID:                encode(a.credentialID),
+		ClientDataJSON:    encode(a.clientData(t, "webauthn.create", challenge)),
+		AttestationObject: encode(object),
+	}
+}
+
+// Get signs in with the passkey, answering the challenge of navigator.credentials.get()
+func (a *Authenticator) Get(t testing.TB, challenge []byte) Response {
+	t.Helper()
+
+	a.SignCount++
+
+	clientData := a.clientData(t, "webauthn.get", challenge)
+	authData := a.authenticatorData(0x01|0x04, nil)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	return Response{
+		ID:                encode(a.credentialID),
+		ClientDataJSON:    encode(clientData),
+		AuthenticatorData: encode(authData),
+		Signature:         encode(signature),
+		UserHandle:        encode(a.userHandle),
+	}
+}
+
+// clientData returns the client data the browser passes to the authenticator
+func (a *Authenticator) clientData(t testing.TB, ceremony string, challenge []byte) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]any{
+		"type":        ceremony,
+		"challenge":   encode(challenge),
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+	if err != nil {
+		t.Fatalf("failed to encode client data: %v", err)
+	}
+
+	return data
+}
+
+// authenticatorData returns the hash of the relying party ID, the flags and the signature
+// counter, followed by the attested credential data when registering
+func (a *Authenticator) authenticatorData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.SignCount)
+
+	return append(data, attested...)
+}
+
+// coseKey returns the public key of the passkey as a COSE EC2 key for ES256
+func (a *Authenticator) coseKey(t testing.TB) []byte {
+	t.Helper()
+
+	public, err := a.key.PublicKey.ECDH()
+	if err != nil {
+		t.Fatalf("failed to encode public key: %v", err)
+	}
+
+	// Uncompressed point: 0x04, x and y
+	point := public.Bytes()
+
+	// {1: 2 (EC2), 3: -7 (ES256), -1: 1 (P-256), -2: x, -3: y}
+	key := cborHead(5, 5)
+	key = append(key, cborHead(0, 1)...)
+	key = append(key, cborHead(0, 2)...)
+	key = append(key, cborHead(0, 3)...)
+	key = append(key, cborHead(1, 6)...)
+	key = append(key, cborHead(1, 0)...)
+	key = append(key, cborHead(0, 1)...)
+	key = append(key, cborHead(1, 1)...)
+	key = append(key, cborBytes(point[1:33])...)
+	key = append(key, cborHead(1, 2)...)
+	key = append(key, cborBytes(point[33:])...)
+
+	return key
+}
+
+// cborHead encodes the major type and argument starting a CBOR item
+func cborHead(major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return []byte{major<<5 | byte(arg)}
+	case arg <= 0xff:
+		return []byte{major<<5 | 24, byte(arg)}
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(arg))
+	default:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(arg))
+	}
+}
+
+// cborBytes encodes a CBOR byte string
+func cborBytes(b []byte) []byte {
+	return append(cborHead(2, uint64(len(b))), b...)
+}
+
+// cborText encodes a CBOR text string
+func cborText(s string) []byte {
+	return append(cborHead(3, uint64(len(s))), s...)
+}
+
+// encode encodes binary data as unpadded base64url
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"log"
@@ -16,6 +17,7 @@ type AppServices struct {
 	DB            *models.DBModel
 	TemplateCache map[string]*template.Template
 	OAuthConfig   *auth.OAuthConfig
+	Passkeys      *auth.RelyingParty // nil when passkeys are disabled
 }
 
 // serverError logs the error and returns a generic 500 error to the user
@@ -31,9 +33,8 @@ func (m *AppServices) clientError(w http.ResponseWriter, status int, message str
 
 // adminError logs the error and returns the error details to admin users
 func (m *AppServices) adminError(w http.ResponseWriter, r *http.Request, err error, status int, source string) {
-	// Check if user is authenticated and authorized (admin)
-	userEmail, valid := middleware.VerifySecureSessionCookie(r)
-	isAdmin := valid && m.OAuthConfig.IsAllowedEmail(userEmail)
+	// Requests by authenticated admins carry their email in the context
+	isAdmin := middleware.GetUserEmail(r) != ""
 
 	log.Printf("ERROR (%s): %v", source, err)
 
@@ -46,15 +47,32 @@ func (m *AppServices) adminError(w http.ResponseWriter, r *http.Request, err err
 	}
 }
 
+// currentUser returns the email of the signed-in admin, recorded with every change to the menu.
+// SessionAuth and APITokenAuth put it in the request context.
+func currentUser(r *http.Request) string {
+	return middleware.GetUserEmail(r)
+}
+
+// writeJSON encodes the value as JSON and writes it with the given status code
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("ERROR: Encoding JSON response failed: %v", err)
+	}
+}
+
 // Services is the global app services instance used by the handlers
 var Services *AppServices
 
 // NewAppServices creates a new app services container
-func NewAppServices(db *models.DBModel, tc map[string]*template.Template, oa *auth.OAuthConfig) *AppServices {
+func NewAppServices(db *models.DBModel, tc map[string]*template.Template, oa *auth.OAuthConfig, pk *auth.RelyingParty) *AppServices {
 	return &AppServices{
 		DB:            db,
 		TemplateCache: tc,
 		OAuthConfig:   oa,
+		Passkeys:      pk,
 	}
 }
 
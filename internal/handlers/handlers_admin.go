@@ -1,49 +1,44 @@
 package handlers
 
 import (
-	"context"
 	"log"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/AlexTLDR/pizzeria/internal/middleware"
 	"github.com/AlexTLDR/pizzeria/internal/models"
 )
 
 // AdminDashboard displays the admin dashboard
 func (m *AppServices) AdminDashboard(w http.ResponseWriter, r *http.Request) {
-	// Direct SQL query as a fallback/temporary solution
-	log.Println("Querying menu items directly from DB in AdminDashboard handler")
+	m.renderDashboard(w, r, nil)
+}
 
-	rows, err := m.DB.DB.Query("SELECT id, name, description, category, price, image_url FROM menu_items")
+// renderDashboard renders the admin dashboard with additional template data, e.g. a message or
+// the secret of a token that was just created
+func (m *AppServices) renderDashboard(w http.ResponseWriter, r *http.Request, extra map[string]interface{}) {
+	// Items come back in menu order: by category position, then item position
+	menuItems, err := m.DB.GetAllMenuItems()
 	if err != nil {
 		m.adminError(w, r, err, http.StatusInternalServerError, "AdminDashboard - querying menu items")
 		return
 	}
 
-	defer rows.Close()
-
-	var menuItems []models.MenuItem
-
-	for rows.Next() {
-		var item models.MenuItem
-
-		err := rows.Scan(&item.ID, &item.Name, &item.Description, &item.Category, &item.Price, &item.ImageURL)
-		if err != nil {
-			log.Printf("ERROR scanning menu item: %v", err)
-			continue
-		}
-
-		menuItems = append(menuItems, item)
+	categories, err := m.DB.GetAllCategories()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "AdminDashboard - fetching categories")
+		return
 	}
 
-	// Check for errors during iteration
-	if err := rows.Err(); err != nil {
-		m.adminError(w, r, err, http.StatusInternalServerError, "AdminDashboard - iterating menu items")
-		return
+	// Group menu items by category so each one can be reordered on its own
+	menuByCategory := make(map[int][]models.MenuItem)
+
+	for _, item := range menuItems {
+		menuByCategory[item.CategoryID] = append(menuByCategory[item.CategoryID], item)
 	}
 
-	log.Printf("Retrieved %d menu items directly via SQL", len(menuItems))
+	log.Printf("Retrieved %d menu items", len(menuItems))
 	menuItemCount := len(menuItems)
 
 	// Get flash messages
@@ -55,15 +50,33 @@ func (m *AppServices) AdminDashboard(w http.ResponseWriter, r *http.Request) {
 
 	flashCount := len(flashMessages)
 
+	apiTokens, err := m.DB.GetAPITokens()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "AdminDashboard - fetching API tokens")
+		return
+	}
+
+	data := map[string]interface{}{
+		"Title":          "Admin Dashboard",
+		"MenuItemCount":  menuItemCount,
+		"FlashMsgCount":  flashCount,
+		"FlashMessages":  flashMessages,
+		"Menu":           menuItems, // Add menu items to the template context
+		"Categories":     categories,
+		"MenuByCategory": menuByCategory,
+		"APITokens":      apiTokens,
+		"Role":           middleware.GetUserRole(r),
+		"Languages":      translationLanguages(),
+		"Now":            time.Now(),
+		"Year":           time.Now().Year(),
+	}
+
+	for key, value := range extra {
+		data[key] = value
+	}
+
 	// Render the dashboard template
-	err = m.TemplateCache["admin-dashboard.html"].Execute(w, map[string]interface{}{
-		"Title":         "Admin Dashboard",
-		"MenuItemCount": menuItemCount,
-		"FlashMsgCount": flashCount,
-		"FlashMessages": flashMessages,
-		"Menu":          menuItems, // Add menu items to the template context
-		"Year":          time.Now().Year(),
-	})
+	err = m.TemplateCache["admin-dashboard.html"].Execute(w, data)
 
 	if err != nil {
 		// Just log the error since template.Execute likely already wrote to the response
@@ -105,43 +118,20 @@ func (m *AppServices) CreateFlashMessage(w http.ResponseWriter, r *http.Request)
 
 	// Create flash message
 	flashMsg := models.FlashMessage{
-		Message:   message,
-		Type:      "info",
-		Active:    true,
-		StartDate: startDate,
-		EndDate:   endDate,
+		Message:      message,
+		Type:         "info",
+		Active:       true,
+		StartDate:    startDate,
+		EndDate:      endDate,
+		Translations: translationsFromForm(r, models.TranslationFlashMessage),
 	}
 
-	// Insert the flash message directly using SQL
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	stmt := `INSERT INTO flash_messages (type, message, start_date, end_date, active, created_at, updated_at)
-            VALUES (?, ?, ?, ?, ?, ?, ?)`
-
-	now := time.Now()
-	result, err := m.DB.DB.ExecContext(ctx, stmt,
-		flashMsg.Type,
-		flashMsg.Message,
-		flashMsg.StartDate,
-		flashMsg.EndDate,
-		flashMsg.Active,
-		now,
-		now,
-	)
-
+	_, err = m.DB.CreateFlashMessage(flashMsg)
 	if err != nil {
 		m.adminError(w, r, err, http.StatusInternalServerError, "CreateFlashMessage - saving message")
 		return
 	}
 
-	// Get the last inserted ID
-	_, err = result.LastInsertId()
-	if err != nil {
-		m.adminError(w, r, err, http.StatusInternalServerError, "CreateFlashMessage - getting inserted ID")
-		return
-	}
-
 	// Redirect back to admin dashboard
 	http.Redirect(w, r, "/admin/dashboard", http.StatusSeeOther)
 }
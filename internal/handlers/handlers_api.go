@@ -0,0 +1,402 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/hours"
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+// openAPIDocument describes the public API in OpenAPI 3 format
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// The types below define the JSON of the public API. Field names are part of the API and must not
+// change when the models change; new fields may be added.
+
+// apiMoney is an amount in the smallest currency unit, e.g. {"amount": 850, "currency": "EUR"}
+type apiMoney struct {
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+	Formatted string `json:"formatted"` // German notation as printed on the menu, e.g. "8,50 €"
+}
+
+type apiPrice struct {
+	ID      int      `json:"id"`
+	Label   string   `json:"label"`
+	Price   apiMoney `json:"price"`
+	Default bool     `json:"default"`
+}
+
+type apiDeclaration struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+type apiExtra struct {
+	ID           int      `json:"id"`
+	Name         string   `json:"name"`
+	Price        apiMoney `json:"price"`
+	SmallPrice   apiMoney `json:"small_price"`
+	FromCategory bool     `json:"from_category"` // Offered for the whole category rather than the item itself
+}
+
+type apiServingTime struct {
+	Weekday string `json:"weekday"` // Lowercase English name, e.g. "sunday"
+	Start   string `json:"start"`   // HH:MM
+	End     string `json:"end"`     // HH:MM, exclusive
+}
+
+type apiMenuItem struct {
+	ID           int              `json:"id"`
+	Name         string           `json:"name"`
+	Description  string           `json:"description"`
+	CategoryID   int              `json:"category_id"`
+	Category     string           `json:"category"` // Category slug
+	ImageURL     string           `json:"image_url"`
+	Prices       []apiPrice       `json:"prices"`
+	Allergens    []apiDeclaration `json:"allergens"`
+	Additives    []apiDeclaration `json:"additives"`
+	Tags         []string         `json:"tags"`
+	Extras       []apiExtra       `json:"extras"`
+	Available    bool             `json:"available"`    // Whether the item can be ordered right now
+	Availability string           `json:"availability"` // "available" or "sold_out"
+	SoldOutUntil string           `json:"sold_out_until,omitempty"`
+	ServingTimes []apiServingTime `json:"serving_times"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
+
+type apiCategory struct {
+	ID          int    `json:"id"`
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`    // Italian name
+	NameDE      string `json:"name_de"` // German name, may be empty
+	Label       string `json:"label"`   // Heading as shown on the menu, e.g. "Carne / Fleisch"
+	Description string `json:"description"`
+	Position    int    `json:"position"`
+}
+
+type apiAnnouncement struct {
+	ID        int    `json:"id"`
+	Type      string `json:"type"`
+	Message   string `json:"message"`
+	StartDate string `json:"start_date"` // YYYY-MM-DD
+	EndDate   string `json:"end_date"`   // YYYY-MM-DD
+}
+
+type apiStatus struct {
+	Open     bool       `json:"open"`
+	OpensAt  *time.Time `json:"opens_at"`  // Next opening; null when none is scheduled
+	ClosesAt *time.Time `json:"closes_at"` // End of the current opening, or of the next one while closed
+	Timezone string     `json:"timezone"`
+}
+
+type apiErrorBody struct {
+	Error   string   `json:"error"`
+	Details []string `json:"details,omitempty"` // Every problem found when validating a request body
+}
+
+// newAPIMoney converts an amount for the API
+func newAPIMoney(m models.Money) apiMoney {
+	currency := m.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+
+	return apiMoney{Amount: m.Cents, Currency: currency, Formatted: m.String()}
+}
+
+// newAPIMenuItem converts a menu item for the API; available is evaluated at the given time
+func newAPIMenuItem(item models.MenuItem, now time.Time) apiMenuItem {
+	out := apiMenuItem{
+		ID:           item.ID,
+		Name:         item.Name,
+		Description:  item.Description,
+		CategoryID:   item.CategoryID,
+		Category:     item.CategorySlug,
+		ImageURL:     item.ImageURL,
+		Prices:       make([]apiPrice, 0, len(item.Variants)),
+		Allergens:    make([]apiDeclaration, 0, len(item.Allergens)),
+		Additives:    make([]apiDeclaration, 0, len(item.Additives)),
+		Tags:         make([]string, 0, len(item.Tags)),
+		Extras:       make([]apiExtra, 0, len(item.Extras)),
+		Available:    item.IsAvailableAt(now),
+		Availability: string(item.Availability),
+		ServingTimes: make([]apiServingTime, 0, len(item.Windows)),
+		UpdatedAt:    item.UpdatedAt.UTC(),
+	}
+
+	for _, v := range item.Variants {
+		out.Prices = append(out.Prices, apiPrice{ID: v.ID, Label: v.Label, Price: newAPIMoney(v.Price), Default: v.IsDefault})
+	}
+
+	for _, a := range item.Allergens {
+		out.Allergens = append(out.Allergens, apiDeclaration{Code: a.Code, Name: a.Name})
+	}
+
+	for _, a := range item.Additives {
+		out.Additives = append(out.Additives, apiDeclaration{Code: a.Code, Name: a.Name})
+	}
+
+	for _, t := range item.Tags {
+		out.Tags = append(out.Tags, t.Slug)
+	}
+
+	for _, e := range item.Extras {
+		out.Extras = append(out.Extras, apiExtra{
+			ID:           e.ID,
+			Name:         e.Name,
+			Price:        newAPIMoney(e.Price),
+			SmallPrice:   newAPIMoney(e.SmallSurcharge()),
+			FromCategory: e.FromCategory,
+		})
+	}
+
+	if item.Availability == models.AvailabilitySoldOut && item.SoldOutUntil != nil {
+		out.SoldOutUntil = item.SoldOutUntil.Format(time.DateOnly)
+	}
+
+	for _, w := range item.Windows {
+		out.ServingTimes = append(out.ServingTimes, apiServingTime{
+			Weekday: strings.ToLower(w.Weekday.String()),
+			Start:   w.Start,
+			End:     w.End,
+		})
+	}
+
+	return out
+}
+
+// publicMenuItems returns the items guests see on the website: not hidden and in a visible category
+func (m *AppServices) publicMenuItems() ([]models.MenuItem, error) {
+	items, err := m.DB.GetAllMenuItems()
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := m.DB.GetVisibleCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make(map[int]bool, len(categories))
+	for _, c := range categories {
+		visible[c.ID] = true
+	}
+
+	public := make([]models.MenuItem, 0, len(items))
+
+	for _, item := range items {
+		if visible[item.CategoryID] && !item.IsHidden() {
+			public = append(public, item)
+		}
+	}
+
+	return public, nil
+}
+
+// APIMenu lists the public menu at /api/v1/menu
+func (m *AppServices) APIMenu(w http.ResponseWriter, r *http.Request) {
+	items, err := m.publicMenuItems()
+	if err != nil {
+		apiServerError(w, err, "APIMenu - fetching menu items")
+		return
+	}
+
+	now := time.Now()
+
+	out := make([]apiMenuItem, 0, len(items))
+	for _, item := range items {
+		out = append(out, newAPIMenuItem(item, now))
+	}
+
+	writeAPIJSON(w, r, map[string]interface{}{"items": out})
+}
+
+// APIMenuItem returns one menu item at /api/v1/menu/{id}
+func (m *AppServices) APIMenuItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Path[len("/api/v1/menu/"):])
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, "menu item not found")
+		return
+	}
+
+	items, err := m.publicMenuItems()
+	if err != nil {
+		apiServerError(w, err, "APIMenuItem - fetching menu items")
+		return
+	}
+
+	for _, item := range items {
+		if item.ID == id {
+			writeAPIJSON(w, r, newAPIMenuItem(item, time.Now()))
+			return
+		}
+	}
+
+	writeAPIError(w, http.StatusNotFound, "menu item not found")
+}
+
+// APICategories lists the visible categories in menu order at /api/v1/categories
+func (m *AppServices) APICategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := m.DB.GetVisibleCategories()
+	if err != nil {
+		apiServerError(w, err, "APICategories - fetching categories")
+		return
+	}
+
+	out := make([]apiCategory, 0, len(categories))
+	for _, c := range categories {
+		out = append(out, apiCategory{
+			ID:          c.ID,
+			Slug:        c.Slug,
+			Name:        c.NameIT,
+			NameDE:      c.NameDE,
+			Label:       c.Label(),
+			Description: c.Description,
+			Position:    c.Position,
+		})
+	}
+
+	writeAPIJSON(w, r, map[string]interface{}{"categories": out})
+}
+
+// APIAnnouncements lists the flash messages currently shown on the website at /api/v1/announcements
+func (m *AppServices) APIAnnouncements(w http.ResponseWriter, r *http.Request) {
+	messages, err := m.DB.GetActiveFlashMessages()
+	if err != nil {
+		apiServerError(w, err, "APIAnnouncements - fetching flash messages")
+		return
+	}
+
+	out := make([]apiAnnouncement, 0, len(messages))
+	for _, msg := range messages {
+		out = append(out, apiAnnouncement{
+			ID:        msg.ID,
+			Type:      msg.Type,
+			Message:   msg.Message,
+			StartDate: msg.StartDate.Format(time.DateOnly),
+			EndDate:   msg.EndDate.Format(time.DateOnly),
+		})
+	}
+
+	writeAPIJSON(w, r, map[string]interface{}{"announcements": out})
+}
+
+// APIStatus tells whether the restaurant is open right now and when it next opens and closes at /api/v1/status
+func (m *AppServices) APIStatus(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+
+	schedule, err := m.openingSchedule(now)
+	if err != nil {
+		apiServerError(w, err, "APIStatus - fetching opening hours")
+		return
+	}
+
+	status := schedule.Status(now)
+
+	out := apiStatus{Open: status.Open, Timezone: hours.Location.String()}
+	if !status.OpensAt.IsZero() {
+		out.OpensAt = &status.OpensAt
+	}
+
+	if !status.ClosesAt.IsZero() {
+		out.ClosesAt = &status.ClosesAt
+	}
+
+	writeAPIJSON(w, r, out)
+}
+
+// APIOpenAPI serves the OpenAPI document of the API at /api/v1/openapi.json
+func (m *AppServices) APIOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeAPIBody(w, r, openAPIDocument)
+}
+
+// APINotFound answers unknown API paths
+func (m *AppServices) APINotFound(w http.ResponseWriter, r *http.Request) {
+	writeAPIError(w, http.StatusNotFound, "not found")
+}
+
+// APIMethodNotAllowed answers requests that try to change data through the read-only API
+func (m *AppServices) APIMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Allow", "GET, HEAD")
+	writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
+
+// writeAPIJSON encodes v and writes it with writeAPIBody
+func writeAPIJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		apiServerError(w, err, "API - encoding response")
+		return
+	}
+
+	writeAPIBody(w, r, body)
+}
+
+// writeAPIBody writes a JSON response with an ETag of its content. Clients sending the ETag back in
+// If-None-Match get 304 Not Modified without a body while the data is unchanged.
+func writeAPIBody(w http.ResponseWriter, r *http.Request, body []byte) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if _, err := w.Write(body); err != nil {
+		log.Printf("ERROR: Writing API response failed: %v", err)
+	}
+}
+
+// etagMatches reports whether an If-None-Match header lists the ETag. Weak validators compare equal
+// to strong ones, as RFC 9110 requires for If-None-Match.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeAPIError writes an error as {"error": "..."}
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	body, err := json.Marshal(apiErrorBody{Error: message})
+	if err != nil {
+		http.Error(w, message, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(status)
+
+	if _, err := w.Write(body); err != nil {
+		log.Printf("ERROR: Writing API error failed: %v", err)
+	}
+}
+
+// apiServerError logs an error and answers with a generic JSON error
+func apiServerError(w http.ResponseWriter, err error, source string) {
+	log.Printf("SERVER ERROR (%s): %v", source, err)
+	writeAPIError(w, http.StatusInternalServerError, "internal server error")
+}
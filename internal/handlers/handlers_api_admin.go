@@ -0,0 +1,630 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/menuio"
+	"github.com/AlexTLDR/pizzeria/internal/middleware"
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+// apiFlashMessage is a flash message in the admin API
+type apiFlashMessage struct {
+	ID        int    `json:"id"`
+	Type      string `json:"type"` // "info", "success" or "error"
+	Message   string `json:"message"`
+	StartDate string `json:"start_date"` // YYYY-MM-DD
+	EndDate   string `json:"end_date"`   // YYYY-MM-DD
+	Active    bool   `json:"active"`
+	Status    string `json:"status"` // Read-only: Active, Scheduled, Expired or Inactive
+}
+
+// newAPIFlashMessage converts a flash message for the admin API
+func newAPIFlashMessage(msg models.FlashMessage) apiFlashMessage {
+	return apiFlashMessage{
+		ID:        msg.ID,
+		Type:      msg.Type,
+		Message:   msg.Message,
+		StartDate: msg.StartDate.Format(time.DateOnly),
+		EndDate:   msg.EndDate.Format(time.DateOnly),
+		Active:    msg.Active,
+		Status:    msg.GetStatus(),
+	}
+}
+
+// APITokenAuth wraps an admin API handler. Requests need an "Authorization: Bearer <token>" header
+// with a token that has not been revoked and whose owner is still a manager or owner; changes are
+// then recorded in the owner's name.
+func (m *AppServices) APITokenAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || strings.TrimSpace(secret) == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="pizzeria"`)
+			writeAPIError(w, http.StatusUnauthorized, "missing bearer token")
+
+			return
+		}
+
+		token, err := m.DB.AuthenticateAPIToken(strings.TrimSpace(secret))
+		if errors.Is(err, models.ErrInvalidAPIToken) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="pizzeria", error="invalid_token"`)
+			writeAPIError(w, http.StatusUnauthorized, err.Error())
+
+			return
+		}
+
+		if err != nil {
+			apiServerError(w, err, "APITokenAuth - checking token")
+			return
+		}
+
+		// Tokens stop working when their owner is removed from the admin users, and can only do
+		// what their owner may do
+		owner, err := m.DB.GetAdminUserByEmail(token.CreatedBy)
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("API token %q of %s used after access was removed", token.Prefix, token.CreatedBy)
+			writeAPIError(w, http.StatusForbidden, "the owner of this token is no longer an admin")
+
+			return
+		}
+
+		if err != nil {
+			apiServerError(w, err, "APITokenAuth - fetching token owner")
+			return
+		}
+
+		if !owner.Role.AtLeast(models.RoleManager) {
+			writeAPIError(w, http.StatusForbidden, "the owner of this token may not change the menu")
+			return
+		}
+
+		next(w, middleware.WithUserRole(middleware.WithUserEmail(r, token.CreatedBy), owner.Role))
+	}
+}
+
+// decodeAPIBody decodes a JSON request body of at most 1 MB, rejecting unknown fields
+func decodeAPIBody(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+
+	return dec.Decode(v)
+}
+
+// apiMethodNotAllowed answers a request with a method the endpoint does not support
+func apiMethodNotAllowed(w http.ResponseWriter, allow string) {
+	w.Header().Set("Allow", allow)
+	writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
+
+// apiPathID reads the numeric ID that follows prefix in the request path
+func apiPathID(r *http.Request, prefix, suffix string) (int, error) {
+	return strconv.Atoi(strings.TrimSuffix(r.URL.Path[len(prefix):], suffix))
+}
+
+// menuItemFromAPI validates a menu item sent to the admin API and converts it. Problems with the
+// item are returned as messages for the client; err is only set on database errors.
+func (m *AppServices) menuItemFromAPI(in apiMenuItem) (models.MenuItem, []string, error) {
+	var problems []string
+
+	// The API item is translated into the import format, which resolves slugs and codes
+	item := menuio.Item{
+		Name:         in.Name,
+		Description:  in.Description,
+		Category:     in.Category,
+		Availability: in.Availability,
+		SoldOutUntil: in.SoldOutUntil,
+		Tags:         in.Tags,
+	}
+
+	for _, p := range in.Prices {
+		if p.Price.Currency != "" && p.Price.Currency != models.DefaultCurrency {
+			problems = append(problems, fmt.Sprintf("unsupported currency %q", p.Price.Currency))
+		}
+
+		item.Prices = append(item.Prices, menuio.Price{
+			Label:   p.Label,
+			Price:   models.EUR(p.Price.Amount).Amount(),
+			Default: p.Default,
+		})
+	}
+
+	for _, a := range in.Allergens {
+		item.Allergens = append(item.Allergens, a.Code)
+	}
+
+	for _, a := range in.Additives {
+		item.Additives = append(item.Additives, a.Code)
+	}
+
+	catalog, err := m.menuCatalog()
+	if err != nil {
+		return models.MenuItem{}, nil, err
+	}
+
+	// Extras of the category are listed with the item but assigned to the category
+	for _, e := range in.Extras {
+		if e.FromCategory {
+			continue
+		}
+
+		name := e.Name
+
+		for _, known := range catalog.Extras {
+			if e.ID != 0 && known.ID == e.ID {
+				name = known.Name
+			}
+		}
+
+		item.Extras = append(item.Extras, name)
+	}
+
+	for _, st := range in.ServingTimes {
+		weekday := ""
+
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.EqualFold(st.Weekday, d.String()) {
+				weekday = d.String()[:3]
+			}
+		}
+
+		if weekday == "" {
+			problems = append(problems, fmt.Sprintf("unknown weekday %q", st.Weekday))
+			continue
+		}
+
+		item.ServingTimes = append(item.ServingTimes, fmt.Sprintf("%s %s-%s", weekday, st.Start, st.End))
+	}
+
+	resolved, errs := catalog.Resolve([]menuio.Item{item})
+
+	for _, err := range errs {
+		var itemErr menuio.ItemError
+		if errors.As(err, &itemErr) {
+			err = itemErr.Err
+		}
+
+		problems = append(problems, err.Error())
+	}
+
+	if len(problems) > 0 {
+		return models.MenuItem{}, problems, nil
+	}
+
+	return resolved[0], nil, nil
+}
+
+// AdminAPIMenuItems lists all menu items, including hidden ones, or creates a menu item
+func (m *AppServices) AdminAPIMenuItems(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items, err := m.DB.GetAllMenuItems()
+		if err != nil {
+			apiServerError(w, err, "AdminAPIMenuItems - fetching menu items")
+			return
+		}
+
+		now := time.Now()
+
+		out := make([]apiMenuItem, 0, len(items))
+		for _, item := range items {
+			out = append(out, newAPIMenuItem(item, now))
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{"items": out})
+
+	case http.MethodPost:
+		var in apiMenuItem
+
+		err := decodeAPIBody(w, r, &in)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+			return
+		}
+
+		item, problems, err := m.menuItemFromAPI(in)
+		if err != nil {
+			apiServerError(w, err, "AdminAPIMenuItems - checking menu item")
+			return
+		}
+
+		if len(problems) > 0 {
+			writeJSON(w, http.StatusUnprocessableEntity, apiErrorBody{Error: "invalid menu item", Details: problems})
+			return
+		}
+
+		id, err := m.DB.InsertMenuItem(item, currentUser(r))
+		if err != nil {
+			apiServerError(w, err, "AdminAPIMenuItems - saving menu item")
+			return
+		}
+
+		m.writeAdminAPIMenuItem(w, id, http.StatusCreated)
+
+	default:
+		apiMethodNotAllowed(w, "GET, POST")
+	}
+}
+
+// AdminAPIMenuItem reads, replaces or deletes the menu item at /api/v1/admin/menu-items/{id}.
+// PUT replaces the whole item; sizes keep their ID, and with it their scheduled prices, when the
+// client sends the ID back or the label is unchanged.
+func (m *AppServices) AdminAPIMenuItem(w http.ResponseWriter, r *http.Request) {
+	id, err := apiPathID(r, "/api/v1/admin/menu-items/", "")
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, "menu item not found")
+		return
+	}
+
+	existing, err := m.DB.GetMenuItemByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeAPIError(w, http.StatusNotFound, "menu item not found")
+		return
+	}
+
+	if err != nil {
+		apiServerError(w, err, "AdminAPIMenuItem - fetching menu item")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, newAPIMenuItem(existing, time.Now()))
+
+	case http.MethodPut:
+		var in apiMenuItem
+
+		err := decodeAPIBody(w, r, &in)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+			return
+		}
+
+		item, problems, err := m.menuItemFromAPI(in)
+		if err != nil {
+			apiServerError(w, err, "AdminAPIMenuItem - checking menu item")
+			return
+		}
+
+		if len(problems) > 0 {
+			writeJSON(w, http.StatusUnprocessableEntity, apiErrorBody{Error: "invalid menu item", Details: problems})
+			return
+		}
+
+		item.ID = id
+		item.ImageURL = existing.ImageURL // Images are changed through the image endpoint
+
+		for i := range item.Variants {
+			item.Variants[i].ID = in.Prices[i].ID
+
+			for _, v := range existing.Variants {
+				if item.Variants[i].ID == 0 && strings.EqualFold(v.Label, item.Variants[i].Label) {
+					item.Variants[i].ID = v.ID
+				}
+			}
+		}
+
+		err = m.DB.UpdateMenuItem(item, currentUser(r))
+		if err != nil {
+			apiServerError(w, err, "AdminAPIMenuItem - saving menu item")
+			return
+		}
+
+		m.writeAdminAPIMenuItem(w, id, http.StatusOK)
+
+	case http.MethodDelete:
+		// Deleted items go to the trash like in the dashboard
+		err := m.DB.DeleteMenuItem(id, currentUser(r))
+		if err != nil {
+			apiServerError(w, err, "AdminAPIMenuItem - deleting menu item")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		apiMethodNotAllowed(w, "GET, PUT, DELETE")
+	}
+}
+
+// AdminAPIMenuItemImage uploads (PUT or POST, multipart field "image") or removes (DELETE) the
+// image of the menu item at /api/v1/admin/menu-items/{id}/image
+func (m *AppServices) AdminAPIMenuItemImage(w http.ResponseWriter, r *http.Request) {
+	id, err := apiPathID(r, "/api/v1/admin/menu-items/", "/image")
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, "menu item not found")
+		return
+	}
+
+	item, err := m.DB.GetMenuItemByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeAPIError(w, http.StatusNotFound, "menu item not found")
+		return
+	}
+
+	if err != nil {
+		apiServerError(w, err, "AdminAPIMenuItemImage - fetching menu item")
+		return
+	}
+
+	oldImage := item.ImageURL
+
+	switch r.Method {
+	case http.MethodPut, http.MethodPost:
+		err := r.ParseMultipartForm(10 << 20) // 10 MB max
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "expected a multipart form with an image field")
+			return
+		}
+
+		file, header, err := r.FormFile("image")
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "expected a multipart form with an image field")
+			return
+		}
+		defer file.Close()
+
+		if !m.isValidImageExtension(header.Filename) {
+			writeAPIError(w, http.StatusUnprocessableEntity, "only image files (jpg, jpeg, png, gif, webp, bmp, svg) are allowed")
+			return
+		}
+
+		item.ImageURL, err = saveMenuImage(file, header.Filename)
+		if err != nil {
+			apiServerError(w, err, "AdminAPIMenuItemImage - saving image")
+			return
+		}
+
+	case http.MethodDelete:
+		item.ImageURL = ""
+
+	default:
+		apiMethodNotAllowed(w, "PUT, POST, DELETE")
+		return
+	}
+
+	err = m.DB.UpdateMenuItem(item, currentUser(r))
+	if err != nil {
+		m.deleteImageFile(item.ImageURL)
+		apiServerError(w, err, "AdminAPIMenuItemImage - saving menu item")
+
+		return
+	}
+
+	// The old file is only removed once the item points to the new one
+	m.deleteImageFile(oldImage)
+
+	m.writeAdminAPIMenuItem(w, id, http.StatusOK)
+}
+
+// writeAdminAPIMenuItem answers with the saved state of a menu item
+func (m *AppServices) writeAdminAPIMenuItem(w http.ResponseWriter, id, status int) {
+	item, err := m.DB.GetMenuItemByID(id)
+	if err != nil {
+		apiServerError(w, err, "AdminAPI - fetching saved menu item")
+		return
+	}
+
+	if status == http.StatusCreated {
+		w.Header().Set("Location", fmt.Sprintf("/api/v1/admin/menu-items/%d", id))
+	}
+
+	writeJSON(w, status, newAPIMenuItem(item, time.Now()))
+}
+
+// flashMessageFromAPI applies a flash message sent to the admin API on top of msg.
+// Fields left out of the request body keep their value in msg.
+func flashMessageFromAPI(w http.ResponseWriter, r *http.Request, msg models.FlashMessage) (models.FlashMessage, []string, error) {
+	in := newAPIFlashMessage(msg)
+
+	err := decodeAPIBody(w, r, &in)
+	if err != nil {
+		return msg, nil, err
+	}
+
+	var problems []string
+
+	msg.Message = strings.TrimSpace(in.Message)
+	if msg.Message == "" {
+		problems = append(problems, "message is required")
+	}
+
+	msg.Type = in.Type
+	if msg.Type != "info" && msg.Type != "success" && msg.Type != "error" {
+		problems = append(problems, fmt.Sprintf("unknown type %q", in.Type))
+	}
+
+	msg.StartDate, err = time.Parse(time.DateOnly, in.StartDate)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("invalid start date %q", in.StartDate))
+	}
+
+	msg.EndDate, err = time.Parse(time.DateOnly, in.EndDate)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("invalid end date %q", in.EndDate))
+	}
+
+	if msg.EndDate.Before(msg.StartDate) {
+		problems = append(problems, "the end date is before the start date")
+	}
+
+	msg.Active = in.Active
+
+	return msg, problems, nil
+}
+
+// AdminAPIFlashMessages lists all flash messages or creates one. New messages are active info
+// messages unless the request says otherwise.
+func (m *AppServices) AdminAPIFlashMessages(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		messages, err := m.DB.GetAllFlashMessages()
+		if err != nil {
+			apiServerError(w, err, "AdminAPIFlashMessages - fetching flash messages")
+			return
+		}
+
+		out := make([]apiFlashMessage, 0, len(messages))
+		for _, msg := range messages {
+			out = append(out, newAPIFlashMessage(msg))
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{"flash_messages": out})
+
+	case http.MethodPost:
+		msg, problems, err := flashMessageFromAPI(w, r, models.FlashMessage{Type: "info", Active: true})
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+			return
+		}
+
+		if len(problems) > 0 {
+			writeJSON(w, http.StatusUnprocessableEntity, apiErrorBody{Error: "invalid flash message", Details: problems})
+			return
+		}
+
+		id, err := m.DB.CreateFlashMessage(msg)
+		if err != nil {
+			apiServerError(w, err, "AdminAPIFlashMessages - saving flash message")
+			return
+		}
+
+		msg, err = m.DB.GetFlashMessageByID(id)
+		if err != nil {
+			apiServerError(w, err, "AdminAPIFlashMessages - fetching saved flash message")
+			return
+		}
+
+		w.Header().Set("Location", fmt.Sprintf("/api/v1/admin/flash-messages/%d", id))
+		writeJSON(w, http.StatusCreated, newAPIFlashMessage(msg))
+
+	default:
+		apiMethodNotAllowed(w, "GET, POST")
+	}
+}
+
+// AdminAPIFlashMessage reads, updates or deletes the flash message at /api/v1/admin/flash-messages/{id}.
+// PUT only changes the fields present in the request body.
+func (m *AppServices) AdminAPIFlashMessage(w http.ResponseWriter, r *http.Request) {
+	id, err := apiPathID(r, "/api/v1/admin/flash-messages/", "")
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, "flash message not found")
+		return
+	}
+
+	msg, err := m.DB.GetFlashMessageByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeAPIError(w, http.StatusNotFound, "flash message not found")
+		return
+	}
+
+	if err != nil {
+		apiServerError(w, err, "AdminAPIFlashMessage - fetching flash message")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, newAPIFlashMessage(msg))
+
+	case http.MethodPut:
+		msg, problems, err := flashMessageFromAPI(w, r, msg)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+			return
+		}
+
+		if len(problems) > 0 {
+			writeJSON(w, http.StatusUnprocessableEntity, apiErrorBody{Error: "invalid flash message", Details: problems})
+			return
+		}
+
+		err = m.DB.UpdateFlashMessage(msg)
+		if err != nil {
+			apiServerError(w, err, "AdminAPIFlashMessage - saving flash message")
+			return
+		}
+
+		msg, err = m.DB.GetFlashMessageByID(id)
+		if err != nil {
+			apiServerError(w, err, "AdminAPIFlashMessage - fetching saved flash message")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newAPIFlashMessage(msg))
+
+	case http.MethodDelete:
+		err := m.DB.DeleteFlashMessage(id)
+		if err != nil {
+			apiServerError(w, err, "AdminAPIFlashMessage - deleting flash message")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		apiMethodNotAllowed(w, "GET, PUT, DELETE")
+	}
+}
+
+// CreateAPIToken mints a token for the signed-in admin. The secret is shown once on the dashboard
+// and cannot be retrieved later.
+func (m *AppServices) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/dashboard", http.StatusSeeOther)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Could not parse form")
+		return
+	}
+
+	name := strings.TrimSpace(r.Form.Get("name"))
+	if name == "" {
+		m.renderDashboard(w, r, map[string]interface{}{"Error": "Give the API token a name, e.g. the app that will use it."})
+		return
+	}
+
+	token, secret, err := m.DB.CreateAPIToken(name, currentUser(r))
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "CreateAPIToken - saving token")
+		return
+	}
+
+	log.Printf("API token %q created by %s", token.Prefix, token.CreatedBy)
+
+	m.renderDashboard(w, r, map[string]interface{}{"NewAPIToken": token, "NewAPITokenSecret": secret})
+}
+
+// RevokeAPIToken revokes an API token so it can no longer be used
+func (m *AppServices) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/api-tokens/revoke/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/dashboard", http.StatusSeeOther)
+		return
+	}
+
+	err = m.DB.RevokeAPIToken(id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		m.adminError(w, r, err, http.StatusInternalServerError, "RevokeAPIToken - revoking token")
+		return
+	}
+
+	log.Printf("API token %d revoked by %s", id, currentUser(r))
+
+	http.Redirect(w, r, "/admin/dashboard", http.StatusSeeOther)
+}
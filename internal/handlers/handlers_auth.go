@@ -1,26 +1,40 @@
 package handlers
 
 import (
-	"crypto/rand"
-	"encoding/base64"
-	"fmt"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/AlexTLDR/pizzeria/internal/auth"
 	"github.com/AlexTLDR/pizzeria/internal/middleware"
 )
 
-// ShowLoginPage displays the login page
+// loginCookieName is the cookie holding the secrets of a sign-in in progress
+const loginCookieName = "oauth_state"
+
+// ShowLoginPage displays the login page with a button for each configured provider, and one to
+// sign in with a passkey when passkeys are enabled
 func (m *AppServices) ShowLoginPage(w http.ResponseWriter, r *http.Request) {
 	// Check if there's an error message
 	errorMsg := r.URL.Query().Get("error")
 
+	var providers []*auth.Provider
+	if m.OAuthConfig != nil {
+		providers = m.OAuthConfig.Providers
+	}
+
 	// Render the login page
 	err := m.TemplateCache["login.html"].Execute(w, map[string]interface{}{
-		"Title": "Admin Login",
-		"Error": errorMsg,
-		"Year":  time.Now().Year(),
+		"Title":     "Admin Login",
+		"Error":     errorMsg,
+		"Providers": providers,
+		"Passkeys":  m.Passkeys != nil,
+		"Year":      time.Now().Year(),
 	})
 
 	if err != nil {
@@ -30,69 +44,95 @@ func (m *AppServices) ShowLoginPage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// HandleGoogleLogin initiates the Google OAuth login flow
-func (m *AppServices) HandleGoogleLogin(w http.ResponseWriter, r *http.Request) {
-	// Generate a random state token to protect against CSRF
-	state, err := generateStateToken()
+// HandleLogin starts signing in with the provider named in the URL, e.g. /auth/google/login
+func (m *AppServices) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	provider := m.OAuthConfig.Provider(r.PathValue("provider"))
+	if provider == nil {
+		m.clientError(w, http.StatusNotFound, "Unknown sign-in provider")
+		return
+	}
+
+	// Generate the state, nonce and PKCE verifier protecting this sign-in
+	attempt, err := auth.NewLoginAttempt()
 	if err != nil {
-		m.serverError(w, err, "HandleGoogleLogin - generating state token")
+		m.serverError(w, err, "HandleLogin - generating login secrets")
 		return
 	}
 
-	// Store the state token in a cookie
-	stateCookie := http.Cookie{
-		Name:     "oauth_state",
-		Value:    state,
-		Path:     "/",
+	authURL, err := provider.AuthCodeURL(r.Context(), attempt)
+	if err != nil {
+		log.Printf("ERROR: Starting sign-in with %s failed: %v", provider.ID, err)
+		http.Redirect(w, r, "/login?error="+url.QueryEscape(provider.Name+" is not available, please try again later"), http.StatusSeeOther)
+
+		return
+	}
+
+	// Keep the secrets in a cookie only sent to the callback of this provider
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginCookieName,
+		Value:    strings.Join([]string{attempt.State, attempt.Nonce, attempt.Verifier}, "."),
+		Path:     "/auth/" + provider.ID + "/",
 		HttpOnly: true,
 		Secure:   true,
 		SameSite: http.SameSiteLaxMode,
 		MaxAge:   60 * 5, // 5 minutes
-	}
-	http.SetCookie(w, &stateCookie)
+	})
 
-	// Redirect to Google's OAuth consent page
-	url := m.OAuthConfig.GetGoogleLoginURL(state)
-	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+	// Redirect to the provider's sign-in page
+	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
 }
 
-// HandleGoogleCallback processes the callback from Google OAuth
-func (m *AppServices) HandleGoogleCallback(w http.ResponseWriter, r *http.Request) {
-	// Get the state from the URL
-	state := r.URL.Query().Get("state")
-	if state == "" {
-		log.Println("No state token in callback")
-		http.Redirect(w, r, "/login?error=Invalid+authentication+attempt", http.StatusSeeOther)
-
+// HandleCallback processes the redirect back from the provider named in the URL, e.g.
+// /auth/google/callback, and starts a session for admin users
+func (m *AppServices) HandleCallback(w http.ResponseWriter, r *http.Request) {
+	provider := m.OAuthConfig.Provider(r.PathValue("provider"))
+	if provider == nil {
+		m.clientError(w, http.StatusNotFound, "Unknown sign-in provider")
 		return
 	}
 
-	// Get the state from the cookie
-	stateCookie, err := r.Cookie("oauth_state")
-	if err != nil {
-		log.Printf("State cookie not found: %v", err)
-		http.Redirect(w, r, "/login?error=Invalid+authentication+attempt", http.StatusSeeOther)
+	// The provider reports cancelled and refused sign-ins as an error
+	if providerError := r.URL.Query().Get("error"); providerError != "" {
+		log.Printf("Sign-in with %s failed: %s", provider.ID, providerError)
+		http.Redirect(w, r, "/login?error=Authentication+failed", http.StatusSeeOther)
 
 		return
 	}
 
-	// Verify the state matches
-	if state != stateCookie.Value {
-		log.Printf("State mismatch: %s vs %s", state, stateCookie.Value)
+	// Get the secrets of the sign-in from the cookie
+	loginCookie, err := r.Cookie(loginCookieName)
+	if err != nil {
+		log.Printf("Login cookie not found: %v", err)
 		http.Redirect(w, r, "/login?error=Invalid+authentication+attempt", http.StatusSeeOther)
 
 		return
 	}
 
-	// Clear the state cookie
+	// Clear the login cookie; each sign-in can only be completed once
 	http.SetCookie(w, &http.Cookie{
-		Name:     "oauth_state",
+		Name:     loginCookieName,
 		Value:    "",
-		Path:     "/",
+		Path:     "/auth/" + provider.ID + "/",
 		HttpOnly: true,
 		MaxAge:   -1,
 	})
 
+	var attempt auth.LoginAttempt
+
+	secrets := strings.Split(loginCookie.Value, ".")
+	if len(secrets) == 3 {
+		attempt = auth.LoginAttempt{State: secrets[0], Nonce: secrets[1], Verifier: secrets[2]}
+	}
+
+	// Verify the state matches
+	state := r.URL.Query().Get("state")
+	if state == "" || attempt.State == "" || subtle.ConstantTimeCompare([]byte(state), []byte(attempt.State)) != 1 {
+		log.Printf("State mismatch in %s callback", provider.ID)
+		http.Redirect(w, r, "/login?error=Invalid+authentication+attempt", http.StatusSeeOther)
+
+		return
+	}
+
 	// Get the authorization code from the URL
 	code := r.URL.Query().Get("code")
 	if code == "" {
@@ -102,38 +142,44 @@ func (m *AppServices) HandleGoogleCallback(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Exchange the authorization code for a token
-	token, err := m.OAuthConfig.ExchangeCodeForToken(code)
+	// Redeem the code and verify the ID token that comes with it
+	identity, err := provider.Exchange(r.Context(), code, attempt)
 	if err != nil {
-		m.serverError(w, err, "HandleGoogleCallback - exchanging OAuth code")
-		return
-	}
+		log.Printf("ERROR: Sign-in with %s failed: %v", provider.ID, err)
+		http.Redirect(w, r, "/login?error=Authentication+failed", http.StatusSeeOther)
 
-	// Get the user info from Google
-	googleUserInfo, err := m.OAuthConfig.GetUserInfo(token)
-	if err != nil {
-		m.serverError(w, err, "HandleGoogleCallback - getting user info")
 		return
 	}
 
 	// Check if the email is verified
-	if !googleUserInfo.VerifiedEmail {
-		log.Printf("Email not verified: %s", googleUserInfo.Email)
+	if !identity.EmailVerified && !provider.TrustEmail {
+		log.Printf("Email not verified by %s: %s", provider.ID, identity.Email)
 		http.Redirect(w, r, "/login?error=Email+not+verified", http.StatusSeeOther)
 
 		return
 	}
 
-	// Check if the email is in the allowed list
-	if !m.OAuthConfig.IsAllowedEmail(googleUserInfo.Email) {
-		log.Printf("Unauthorized email: %s", googleUserInfo.Email)
+	// Check if the email belongs to an admin user
+	user, err := m.DB.GetAdminUserByEmail(identity.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Unauthorized email from %s: %s", provider.ID, identity.Email)
 		http.Redirect(w, r, "/login?error=Unauthorized+email", http.StatusSeeOther)
 
 		return
 	}
 
-	// Set the session cookie with the email
-	middleware.SetSessionCookie(w, googleUserInfo.Email)
+	if err != nil {
+		m.serverError(w, err, "HandleCallback - fetching admin user")
+		return
+	}
+
+	// Start a server-side session and set its cookie
+	if err := m.startSession(w, r, user.Email); err != nil {
+		m.serverError(w, err, "HandleCallback - starting session")
+		return
+	}
+
+	log.Printf("%s signed in with %s", user.Email, provider.Name)
 
 	// Redirect to the admin dashboard
 	http.Redirect(w, r, "/admin", http.StatusSeeOther)
@@ -141,23 +187,16 @@ func (m *AppServices) HandleGoogleCallback(w http.ResponseWriter, r *http.Reques
 
 // HandleLogout logs the user out
 func (m *AppServices) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	// Revoke the session, so its cookie stops working even if it was copied
+	if id := middleware.GetSessionID(r); id != 0 {
+		if err := m.DB.RevokeSession(id); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("ERROR: Revoking session %d on logout failed: %v", id, err)
+		}
+	}
+
 	// Clear the session cookie
 	middleware.ClearSessionCookie(w)
 
 	// Redirect to the login page
 	http.Redirect(w, r, "/login", http.StatusSeeOther)
 }
-
-// generateStateToken generates a random state token for CSRF protection
-func generateStateToken() (string, error) {
-	// Generate 32 bytes of random data
-	b := make([]byte, 32)
-
-	_, err := rand.Read(b)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate random bytes: %w", err)
-	}
-
-	// Encode to base64
-	return base64.URLEncoding.EncodeToString(b), nil
-}
@@ -1,28 +1,30 @@
 package handlers
 
 import (
+	"bytes"
 	"log"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/AlexTLDR/pizzeria/internal/models"
+	"github.com/AlexTLDR/pizzeria/internal/pdf"
 )
 
-// categorySimplifier maps full bilingual categories to simplified Italian-only versions
-var categorySimplifier = map[string]string{
-	"Antipasti / Vorspeisen":                   "Antipasti",
-	"Insalate / Salate":                        "Insalate",
-	"Carne / Fleisch":                          "Carne",
-	"Pesce Fritto / Fisch fritiert":            "Pesce Fritto",
-	"Pasta al Forno / Nudelgerichte überbacken": "Pasta al Forno",
+// Home handles the home page
+func (m *AppServices) Home(w http.ResponseWriter, r *http.Request) {
+	m.renderMenu(w, r, time.Now(), false)
 }
 
-// Home handles the home page
-func (m *AppServices) Home(w http.ResponseWriter, _ *http.Request) {
-	// Use the model's method to get menu items - this properly handles NULL small_price values
-	log.Println("Fetching menu items using model's GetAllMenuItems method")
+// renderMenu renders the public menu as guests see it at the given time. Previews of a future
+// date are marked as such in the page.
+func (m *AppServices) renderMenu(w http.ResponseWriter, r *http.Request, at time.Time, preview bool) {
+	// Use the model's method to get menu items together with their variants and tags
+	log.Println("Fetching menu items using model's GetAllMenuItemsAt method")
 
-	menuItems, err := m.DB.GetAllMenuItems()
+	menuItems, err := m.DB.GetAllMenuItemsAt(at)
 	if err != nil {
 		m.serverError(w, err, "Home - fetching menu items")
 		return
@@ -30,6 +32,20 @@ func (m *AppServices) Home(w http.ResponseWriter, _ *http.Request) {
 
 	log.Printf("Retrieved %d menu items directly via SQL", len(menuItems))
 
+	// Only visible categories are rendered, in their configured order
+	categories, err := m.DB.GetVisibleCategories()
+	if err != nil {
+		m.serverError(w, err, "Home - fetching categories")
+		return
+	}
+
+	// The legend lists every code, so guests can look up any superscript on the menu
+	allergens, additives, err := m.declarationLists()
+	if err != nil {
+		m.serverError(w, err, "Home - fetching allergens and additives")
+		return
+	}
+
 	// Get active flash messages
 	flashMessages, err := m.DB.GetActiveFlashMessages()
 	if err != nil {
@@ -37,53 +53,92 @@ func (m *AppServices) Home(w http.ResponseWriter, _ *http.Request) {
 		log.Printf("NOTICE: Error fetching flash messages in Home handler: %v", err)
 	}
 
-	// Group menu items by category
-	menuByCategory := make(map[string][]models.MenuItem)
+	tags, err := m.DB.GetAllTags()
+	if err != nil {
+		m.serverError(w, err, "Home - fetching tags")
+		return
+	}
 
-	var categories []string
+	extras, err := m.DB.GetAllExtras()
+	if err != nil {
+		m.serverError(w, err, "Home - fetching extras")
+		return
+	}
 
-	categorySet := make(map[string]bool)
+	// The opening status, the footer and the closure banner are rendered from the opening hours in the database
+	schedule, err := m.openingSchedule(at)
+	if err != nil {
+		m.serverError(w, err, "Home - fetching opening hours")
+		return
+	}
 
-	// Create a new slice for simplified menu items with pre-allocation
-	simplifiedMenuItems := make([]models.MenuItem, 0, len(menuItems))
+	// Names, descriptions and announcements are shown in the visitor's language where translated
+	lang := m.language(w, r)
 
-	// First, collect all unique categories and map full category names to simple ones for the template
-	for _, item := range menuItems {
-		// Clone the item for modification
-		simplifiedItem := item
-
-		// Get simplified category using the map with fallback to original
-		simpleCategory, exists := categorySimplifier[item.Category]
-		if !exists {
-			// For categories that don't need renaming (Pizza, Spaghetti, Penne, Rigatoni)
-			simpleCategory = item.Category
+	err = m.localizeMenu(lang, menuItems, categories, flashMessages)
+	if err != nil {
+		m.serverError(w, err, "Home - fetching translations")
+		return
+	}
+
+	// Extras offered for a whole category are listed under its heading
+	extrasByCategory := make(map[string][]models.Extra)
+
+	for _, c := range categories {
+		for _, e := range extras {
+			if e.AppliesToCategory(c.ID) {
+				extrasByCategory[c.Slug] = append(extrasByCategory[c.Slug], e)
+			}
 		}
+	}
 
-		// Update the simplified item's category
-		simplifiedItem.Category = simpleCategory
+	// Filter the menu by the tags and category given in the URL, e.g. ?diet=vegan&not=spicy
+	filter := menuFilterFromQuery(r.URL.Query(), tags, categories)
 
-		// Add to the simplified menu items slice
-		simplifiedMenuItems = append(simplifiedMenuItems, simplifiedItem)
+	// Group the matching menu items by category slug; hidden items are left out entirely
+	menuByCategory := make(map[string][]models.MenuItem)
+	matching := 0
 
-		if !categorySet[item.Category] {
-			categories = append(categories, item.Category)
-			categorySet[item.Category] = true
+	for _, item := range menuItems {
+		if item.IsHidden() || !filter.Matches(item) {
+			continue
 		}
 
-		// Use the simplified category for the menu by category map
-		menuByCategory[item.Category] = append(menuByCategory[item.Category], simplifiedItem)
+		menuByCategory[item.CategorySlug] = append(menuByCategory[item.CategorySlug], item)
+		matching++
 	}
 
-	log.Printf("Categories found: %v", categories)
+	log.Printf("Rendering %d categories", len(categories))
+
+	tmpl, err := m.localizedTemplate("index.html", lang)
+	if err != nil {
+		m.serverError(w, err, "Home - preparing template")
+		return
+	}
 
 	// Render template with categories and menu items
-	err = m.TemplateCache["index.html"].Execute(w, map[string]interface{}{
-		"Title":          "La Piccola Sardegna",
-		"Categories":     categories,
-		"MenuByCategory": menuByCategory,
-		"Menu":           simplifiedMenuItems,
-		"FlashMessages":  flashMessages,
-		"Year":           time.Now().Year(),
+	err = tmpl.Execute(w, map[string]interface{}{
+		"Title":             "La Piccola Sardegna",
+		"Categories":        categories,
+		"MenuByCategory":    menuByCategory,
+		"ExtrasByCategory":  extrasByCategory,
+		"Menu":              menuItems,
+		"Allergens":         allergens,
+		"Additives":         additives,
+		"Filter":            filter,
+		"FilterChips":       filterChips(filter, tags),
+		"AllCategoriesURL":  menuFilterURL(models.MenuFilter{Require: filter.Require, Exclude: filter.Exclude}),
+		"MatchingCount":     matching,
+		"FlashMessages":     flashMessages,
+		"OpeningHours":      models.WeeklySchedule(schedule.Slots),
+		"OpeningExceptions": schedule.ExceptionsBetween(at, at.AddDate(0, 0, upcomingExceptionDays)),
+		"OpeningStatus":     schedule.Status(at),
+		"Closure":           schedule.Closure(at),
+		"Now":               at,
+		"Preview":           preview,
+		"Year":              time.Now().Year(),
+		"Lang":              lang,
+		"LanguageLinks":     languageLinks(r, lang),
 	})
 
 	if err != nil {
@@ -94,3 +149,183 @@ func (m *AppServices) Home(w http.ResponseWriter, _ *http.Request) {
 
 	log.Printf("Template rendered with %d menu items", len(menuItems))
 }
+
+// MenuPDF serves the printable menu at /menu.pdf, on A4 pages or as a folded A5 booklet with ?format=a5
+func (m *AppServices) MenuPDF(w http.ResponseWriter, r *http.Request) {
+	format := pdf.Format(r.URL.Query().Get("format"))
+	if format == "" {
+		format = pdf.A4
+	}
+
+	if !format.IsValid() {
+		m.clientError(w, http.StatusBadRequest, "Unknown menu format")
+		return
+	}
+
+	now := time.Now()
+
+	menuItems, err := m.DB.GetAllMenuItemsAt(now)
+	if err != nil {
+		m.serverError(w, err, "MenuPDF - fetching menu items")
+		return
+	}
+
+	categories, err := m.DB.GetVisibleCategories()
+	if err != nil {
+		m.serverError(w, err, "MenuPDF - fetching categories")
+		return
+	}
+
+	allergens, additives, err := m.declarationLists()
+	if err != nil {
+		m.serverError(w, err, "MenuPDF - fetching allergens and additives")
+		return
+	}
+
+	extras, err := m.DB.GetAllExtras()
+	if err != nil {
+		m.serverError(w, err, "MenuPDF - fetching extras")
+		return
+	}
+
+	menu := pdf.Menu{
+		Title:     "La Piccola Sardegna",
+		Date:      now,
+		Allergens: allergens,
+		Additives: additives,
+	}
+
+	// Same sections as the website: visible categories in order, without hidden items
+	for _, c := range categories {
+		section := pdf.Section{Category: c}
+
+		for _, item := range menuItems {
+			if item.CategoryID == c.ID && !item.IsHidden() {
+				section.Items = append(section.Items, item)
+			}
+		}
+
+		for _, e := range extras {
+			if e.AppliesToCategory(c.ID) {
+				section.Extras = append(section.Extras, e)
+			}
+		}
+
+		menu.Sections = append(menu.Sections, section)
+	}
+
+	// Render to a buffer first so a failed render does not leave a half written download
+	var buf bytes.Buffer
+
+	err = menu.Render(&buf, format)
+	if err != nil {
+		m.serverError(w, err, "MenuPDF - rendering menu")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="menu-`+string(format)+`.pdf"`)
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("ERROR: Writing menu PDF failed: %v", err)
+	}
+}
+
+// filterChip is a toggle for one tag in the public menu filter bar
+type filterChip struct {
+	Tag   models.Tag
+	State string // "require", "exclude" or empty when the tag is not filtered on
+	URL   string // Link that moves the chip to its next state
+}
+
+// menuFilterFromQuery builds the public menu filter from the diet, not and category query parameters.
+// Values may be repeated or comma separated; unknown tags and categories are ignored.
+func menuFilterFromQuery(query url.Values, tags []models.Tag, categories []models.Category) models.MenuFilter {
+	known := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		known[t.Slug] = true
+	}
+
+	slugs := func(key string) []string {
+		var result []string
+
+		for _, value := range query[key] {
+			for _, slug := range strings.Split(value, ",") {
+				slug = strings.ToLower(strings.TrimSpace(slug))
+				if known[slug] && !slices.Contains(result, slug) {
+					result = append(result, slug)
+				}
+			}
+		}
+
+		return result
+	}
+
+	filter := models.MenuFilter{
+		Require: slugs("diet"),
+		Exclude: slugs("not"),
+	}
+
+	category := strings.ToLower(strings.TrimSpace(query.Get("category")))
+	for _, c := range categories {
+		if c.Slug == category {
+			filter.Category = category
+			break
+		}
+	}
+
+	return filter
+}
+
+// menuFilterURL returns the shareable home page link for a filter
+func menuFilterURL(filter models.MenuFilter) string {
+	query := url.Values{}
+
+	if filter.Category != "" {
+		query.Set("category", filter.Category)
+	}
+
+	if len(filter.Require) > 0 {
+		query.Set("diet", strings.Join(filter.Require, ","))
+	}
+
+	if len(filter.Exclude) > 0 {
+		query.Set("not", strings.Join(filter.Exclude, ","))
+	}
+
+	if len(query) == 0 {
+		return "/"
+	}
+
+	return "/?" + query.Encode()
+}
+
+// filterChips builds the filter bar. Each chip cycles through "only", "without" and off.
+func filterChips(filter models.MenuFilter, tags []models.Tag) []filterChip {
+	chips := make([]filterChip, 0, len(tags))
+
+	for _, t := range tags {
+		next := models.MenuFilter{
+			Category: filter.Category,
+			Require:  slices.DeleteFunc(slices.Clone(filter.Require), func(s string) bool { return s == t.Slug }),
+			Exclude:  slices.DeleteFunc(slices.Clone(filter.Exclude), func(s string) bool { return s == t.Slug }),
+		}
+
+		chip := filterChip{Tag: t}
+
+		switch {
+		case slices.Contains(filter.Require, t.Slug):
+			chip.State = "require"
+			next.Exclude = append(next.Exclude, t.Slug)
+		case slices.Contains(filter.Exclude, t.Slug):
+			chip.State = "exclude"
+		default:
+			next.Require = append(next.Require, t.Slug)
+		}
+
+		chip.URL = menuFilterURL(next)
+		chips = append(chips, chip)
+	}
+
+	return chips
+}
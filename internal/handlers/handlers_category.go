@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/i18n"
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+// AdminCategories displays the category management page
+func (m *AppServices) AdminCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := m.DB.GetAllCategories()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "AdminCategories - fetching categories")
+		return
+	}
+
+	// Translations by language and category ID
+	translations := make(map[string]map[int]models.Translation)
+
+	for _, lang := range i18n.Translated() {
+		translations[lang], err = m.DB.GetTranslations(models.TranslationCategory, lang)
+		if err != nil {
+			m.adminError(w, r, err, http.StatusInternalServerError, "AdminCategories - fetching translations")
+			return
+		}
+	}
+
+	// Render the categories template
+	err = m.TemplateCache["admin-categories.html"].Execute(w, map[string]interface{}{
+		"Title":        "Categories",
+		"Categories":   categories,
+		"Languages":    translationLanguages(),
+		"Translations": translations,
+		"Error":        r.URL.Query().Get("error"),
+		"Year":         time.Now().Year(),
+	})
+
+	if err != nil {
+		// Just log the error since template.Execute likely already wrote to the response
+		log.Printf("ERROR: Template rendering failed in AdminCategories: %v", err)
+		return
+	}
+}
+
+// categoryFromForm reads and validates the category fields of a submitted form
+func categoryFromForm(r *http.Request) (models.Category, error) {
+	category := models.Category{
+		NameIT:      strings.TrimSpace(r.FormValue("name_it")),
+		NameDE:      strings.TrimSpace(r.FormValue("name_de")),
+		Description: strings.TrimSpace(r.FormValue("description")),
+		Slug:        models.Slugify(r.FormValue("slug")),
+		Visible:     r.FormValue("visible") != "",
+	}
+
+	if category.NameIT == "" {
+		return category, errors.New("the Italian name is required")
+	}
+
+	// Derive the slug from the Italian name when none was given
+	if category.Slug == "" {
+		category.Slug = models.Slugify(category.NameIT)
+	}
+
+	if category.Slug == "" {
+		return category, errors.New("could not derive a slug from the name")
+	}
+
+	return category, nil
+}
+
+// redirectToCategories redirects back to the category page, optionally with an error message
+func redirectToCategories(w http.ResponseWriter, r *http.Request, errorMsg string) {
+	target := "/admin/categories"
+	if errorMsg != "" {
+		target += "?error=" + url.QueryEscape(errorMsg)
+	}
+
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// CreateCategory handles the create category form submission
+func (m *AppServices) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/categories", http.StatusSeeOther)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Could not parse form")
+		return
+	}
+
+	category, err := categoryFromForm(r)
+	if err != nil {
+		redirectToCategories(w, r, err.Error())
+		return
+	}
+
+	category.Translations = translationsFromForm(r, models.TranslationCategory)
+
+	_, err = m.DB.InsertCategory(category)
+	if errors.Is(err, models.ErrCategorySlugTaken) {
+		redirectToCategories(w, r, "A category with this slug already exists. Choose another name or slug.")
+		return
+	}
+
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "CreateCategory - saving category")
+		return
+	}
+
+	redirectToCategories(w, r, "")
+}
+
+// UpdateCategory handles the edit category form submission
+func (m *AppServices) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from URL
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/categories/update/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/categories", http.StatusSeeOther)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Could not parse form")
+		return
+	}
+
+	category, err := categoryFromForm(r)
+	if err != nil {
+		redirectToCategories(w, r, err.Error())
+		return
+	}
+
+	category.ID = id
+	category.Translations = translationsFromForm(r, models.TranslationCategory)
+
+	err = m.DB.UpdateCategory(category)
+	if errors.Is(err, models.ErrCategorySlugTaken) {
+		redirectToCategories(w, r, "A category with this slug already exists. Choose another name or slug.")
+		return
+	}
+
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "UpdateCategory - updating category")
+		return
+	}
+
+	redirectToCategories(w, r, "")
+}
+
+// ToggleCategoryVisibility shows or hides a category on the public menu
+func (m *AppServices) ToggleCategoryVisibility(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from URL
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/categories/toggle/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/categories", http.StatusSeeOther)
+		return
+	}
+
+	category, err := m.DB.GetCategoryByID(id)
+	if err != nil {
+		m.clientError(w, http.StatusNotFound, "Category not found")
+		return
+	}
+
+	err = m.DB.SetCategoryVisibility(id, !category.Visible)
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "ToggleCategoryVisibility - updating category")
+		return
+	}
+
+	redirectToCategories(w, r, "")
+}
+
+// MoveCategory moves a category one step up or down in the menu order
+func (m *AppServices) MoveCategory(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from URL
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/categories/move/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/categories", http.StatusSeeOther)
+		return
+	}
+
+	categories, err := m.DB.GetAllCategories()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "MoveCategory - fetching categories")
+		return
+	}
+
+	ids := make([]int, 0, len(categories))
+	index := -1
+
+	for i, c := range categories {
+		ids = append(ids, c.ID)
+
+		if c.ID == id {
+			index = i
+		}
+	}
+
+	if index == -1 {
+		m.clientError(w, http.StatusNotFound, "Category not found")
+		return
+	}
+
+	// Swap with the neighbour in the requested direction
+	switch r.FormValue("direction") {
+	case "up":
+		if index > 0 {
+			ids[index-1], ids[index] = ids[index], ids[index-1]
+		}
+	case "down":
+		if index < len(ids)-1 {
+			ids[index+1], ids[index] = ids[index], ids[index+1]
+		}
+	default:
+		m.clientError(w, http.StatusBadRequest, "Invalid direction")
+		return
+	}
+
+	err = m.DB.ReorderCategories(ids)
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "MoveCategory - reordering categories")
+		return
+	}
+
+	redirectToCategories(w, r, "")
+}
+
+// DeleteCategory handles the deletion of an empty category
+func (m *AppServices) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from URL
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/categories/delete/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/categories", http.StatusSeeOther)
+		return
+	}
+
+	err = m.DB.DeleteCategory(id)
+	if errors.Is(err, models.ErrCategoryInUse) {
+		redirectToCategories(w, r, "This category still has menu items. Move or delete them first and empty them from the trash, or hide the category instead.")
+		return
+	}
+
+	if errors.Is(err, models.ErrCategoryInTrash) {
+		redirectToCategories(w, r, "This category still has menu items in the trash. Purge them from the trash first, or restore and move them to another category.")
+		return
+	}
+
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "DeleteCategory - deleting category")
+		return
+	}
+
+	redirectToCategories(w, r, "")
+}
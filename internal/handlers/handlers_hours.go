@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/hours"
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+// upcomingExceptionDays is how far ahead the footer lists holidays and special hours
+const upcomingExceptionDays = 14
+
+// AdminOpeningHours displays the weekly opening hours and the holidays, vacations and special hours
+func (m *AppServices) AdminOpeningHours(w http.ResponseWriter, r *http.Request) {
+	slots, err := m.DB.GetOpeningHours()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "AdminOpeningHours - fetching opening hours")
+		return
+	}
+
+	exceptions, err := m.DB.GetOpeningExceptions()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "AdminOpeningHours - fetching exceptions")
+		return
+	}
+
+	// Render the opening hours template
+	err = m.TemplateCache["admin-hours.html"].Execute(w, map[string]interface{}{
+		"Title":      "Opening Hours",
+		"Slots":      slots,
+		"Exceptions": exceptions,
+		"Weekdays":   weekdayOptions(),
+		"Today":      time.Now().Format("2006-01-02"),
+		"Error":      r.URL.Query().Get("error"),
+		"Year":       time.Now().Year(),
+	})
+
+	if err != nil {
+		// Just log the error since template.Execute likely already wrote to the response
+		log.Printf("ERROR: Template rendering failed in AdminOpeningHours: %v", err)
+		return
+	}
+}
+
+// weekdayOptions lists the weekdays for the slot form, starting on Monday
+func weekdayOptions() []time.Weekday {
+	return []time.Weekday{
+		time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday,
+	}
+}
+
+// slotFromForm reads and validates the fields of a new opening slot
+func slotFromForm(r *http.Request) (models.OpeningSlot, error) {
+	var slot models.OpeningSlot
+
+	weekday, err := strconv.Atoi(r.FormValue("weekday"))
+	if err != nil || weekday < 0 || weekday > 6 {
+		return slot, errors.New("please choose a weekday")
+	}
+
+	slot.Weekday = time.Weekday(weekday)
+
+	slot.Opens, slot.Closes, err = hoursFromForm(r)
+
+	return slot, err
+}
+
+// hoursFromForm reads and validates the opening and closing times of a form. A closing time
+// at or before the opening time means the restaurant stays open past midnight.
+func hoursFromForm(r *http.Request) (string, string, error) {
+	opens, err := time.Parse("15:04", strings.TrimSpace(r.FormValue("opens")))
+	if err != nil {
+		return "", "", errors.New("opening time must look like 17:00")
+	}
+
+	closes, err := time.Parse("15:04", strings.TrimSpace(r.FormValue("closes")))
+	if err != nil {
+		return "", "", errors.New("closing time must look like 22:00")
+	}
+
+	if opens.Equal(closes) {
+		return "", "", errors.New("opening and closing time must differ")
+	}
+
+	return opens.Format("15:04"), closes.Format("15:04"), nil
+}
+
+// exceptionFromForm reads and validates the fields of a new holiday, vacation or special hours
+func exceptionFromForm(r *http.Request) (models.OpeningException, error) {
+	exception := models.OpeningException{
+		Closed: r.FormValue("kind") != "special",
+		Note:   strings.TrimSpace(r.FormValue("note")),
+	}
+
+	var err error
+
+	exception.StartDate, err = time.Parse("2006-01-02", r.FormValue("start_date"))
+	if err != nil {
+		return exception, errors.New("please enter a valid start date")
+	}
+
+	// A single day only needs a start date
+	exception.EndDate = exception.StartDate
+
+	if end := r.FormValue("end_date"); end != "" {
+		exception.EndDate, err = time.Parse("2006-01-02", end)
+		if err != nil {
+			return exception, errors.New("please enter a valid end date")
+		}
+	}
+
+	if exception.EndDate.Before(exception.StartDate) {
+		return exception, errors.New("the end date must not be before the start date")
+	}
+
+	if !exception.Closed {
+		exception.Opens, exception.Closes, err = hoursFromForm(r)
+		if err != nil {
+			return exception, err
+		}
+	}
+
+	return exception, nil
+}
+
+// redirectToHours redirects back to the opening hours page, optionally with an error message
+func redirectToHours(w http.ResponseWriter, r *http.Request, errorMsg string) {
+	target := "/admin/hours"
+	if errorMsg != "" {
+		target += "?error=" + url.QueryEscape(errorMsg)
+	}
+
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// CreateOpeningSlot handles the new opening slot form submission
+func (m *AppServices) CreateOpeningSlot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/hours", http.StatusSeeOther)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Could not parse form")
+		return
+	}
+
+	slot, err := slotFromForm(r)
+	if err != nil {
+		redirectToHours(w, r, err.Error())
+		return
+	}
+
+	_, err = m.DB.InsertOpeningSlot(slot)
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "CreateOpeningSlot - saving slot")
+		return
+	}
+
+	redirectToHours(w, r, "")
+}
+
+// DeleteOpeningSlot handles the deletion of an opening slot
+func (m *AppServices) DeleteOpeningSlot(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from URL
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/hours/slots/delete/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/hours", http.StatusSeeOther)
+		return
+	}
+
+	err = m.DB.DeleteOpeningSlot(id)
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "DeleteOpeningSlot - deleting slot")
+		return
+	}
+
+	redirectToHours(w, r, "")
+}
+
+// CreateOpeningException handles the new holiday, vacation or special hours form submission
+func (m *AppServices) CreateOpeningException(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/hours", http.StatusSeeOther)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Could not parse form")
+		return
+	}
+
+	exception, err := exceptionFromForm(r)
+	if err != nil {
+		redirectToHours(w, r, err.Error())
+		return
+	}
+
+	_, err = m.DB.InsertOpeningException(exception)
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "CreateOpeningException - saving exception")
+		return
+	}
+
+	redirectToHours(w, r, "")
+}
+
+// DeleteOpeningException handles the deletion of a holiday, vacation or special hours
+func (m *AppServices) DeleteOpeningException(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from URL
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/hours/exceptions/delete/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/hours", http.StatusSeeOther)
+		return
+	}
+
+	err = m.DB.DeleteOpeningException(id)
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "DeleteOpeningException - deleting exception")
+		return
+	}
+
+	redirectToHours(w, r, "")
+}
+
+// openingSchedule loads the opening hours together with the exceptions that matter for the
+// status and the footer around at
+func (m *AppServices) openingSchedule(at time.Time) (hours.Schedule, error) {
+	slots, err := m.DB.GetOpeningHours()
+	if err != nil {
+		return hours.Schedule{}, err
+	}
+
+	exceptions, err := m.DB.GetUpcomingOpeningExceptions(at.AddDate(0, 0, -1), at.AddDate(0, 0, hours.LookaheadDays))
+	if err != nil {
+		return hours.Schedule{}, err
+	}
+
+	return hours.Schedule{Slots: slots, Exceptions: exceptions}, nil
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/menuio"
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+// ExportMenu downloads the whole menu as /admin/menu/export.csv or /admin/menu/export.json
+func (m *AppServices) ExportMenu(w http.ResponseWriter, r *http.Request) {
+	format := strings.TrimPrefix(filepath.Ext(r.URL.Path), ".")
+	if format != "csv" && format != "json" {
+		m.clientError(w, http.StatusNotFound, "Unknown export format")
+		return
+	}
+
+	items, err := m.DB.GetAllMenuItems()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "ExportMenu - fetching menu items")
+		return
+	}
+
+	// Write to a buffer first so a failed export does not leave a half written download
+	var buf bytes.Buffer
+
+	if format == "csv" {
+		err = menuio.WriteCSV(&buf, menuio.FromMenuItems(items))
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		err = menuio.WriteJSON(&buf, menuio.FromMenuItems(items))
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	if err != nil {
+		m.serverError(w, err, "ExportMenu - encoding menu")
+		return
+	}
+
+	filename := fmt.Sprintf("menu-%s.%s", time.Now().Format(time.DateOnly), format)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("ERROR: Writing menu export failed: %v", err)
+	}
+}
+
+// menuCatalog loads the categories, tags, declarations and extras imported items refer to
+func (m *AppServices) menuCatalog() (menuio.Catalog, error) {
+	var catalog menuio.Catalog
+
+	var err error
+
+	catalog.Categories, err = m.DB.GetAllCategories()
+	if err != nil {
+		return catalog, err
+	}
+
+	catalog.Tags, err = m.DB.GetAllTags()
+	if err != nil {
+		return catalog, err
+	}
+
+	catalog.Allergens, catalog.Additives, err = m.declarationLists()
+	if err != nil {
+		return catalog, err
+	}
+
+	catalog.Extras, err = m.DB.GetAllExtras()
+
+	return catalog, err
+}
+
+// decodeMenuImport parses an import file and checks every item against the catalog.
+// Problems with the file are returned as messages for the admin; err is only set on database errors.
+func (m *AppServices) decodeMenuImport(format string, data []byte) ([]models.MenuItem, []string, error) {
+	var items []menuio.Item
+
+	var err error
+
+	if format == "json" {
+		items, err = menuio.ReadJSON(bytes.NewReader(data))
+	} else {
+		items, err = menuio.ReadCSV(bytes.NewReader(data))
+	}
+
+	if err != nil {
+		return nil, []string{err.Error()}, nil
+	}
+
+	catalog, err := m.menuCatalog()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	menuItems, errs := catalog.Resolve(items)
+
+	problems := make([]string, 0, len(errs))
+	for _, e := range errs {
+		problems = append(problems, e.Error())
+	}
+
+	return menuItems, problems, nil
+}
+
+// ImportMenu shows the import form. An uploaded file is checked and compared with the current menu
+// without saving anything; posting the checked file again with confirm set applies it in one go.
+func (m *AppServices) ImportMenu(w http.ResponseWriter, r *http.Request) {
+	data := map[string]interface{}{
+		"Title": "Import Menu",
+		"Year":  time.Now().Year(),
+	}
+
+	if r.Method == http.MethodPost {
+		err := r.ParseMultipartForm(10 << 20) // 10 MB max
+		if err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			m.clientError(w, http.StatusBadRequest, "Could not parse form")
+			return
+		}
+
+		format, content, err := importContent(r)
+		if err != nil {
+			data["Problems"] = []string{err.Error()}
+			m.renderImport(w, data)
+
+			return
+		}
+
+		items, problems, err := m.decodeMenuImport(format, content)
+		if err != nil {
+			m.adminError(w, r, err, http.StatusInternalServerError, "ImportMenu - checking import")
+			return
+		}
+
+		if len(problems) == 0 && r.FormValue("confirm") != "" {
+			plan, err := m.DB.ApplyMenuImport(items, currentUser(r))
+
+			switch {
+			case errors.Is(err, models.ErrImportMismatch):
+				// The menu changed since the preview
+				problems = append(problems, err.Error())
+			case err != nil:
+				m.adminError(w, r, err, http.StatusInternalServerError, "ImportMenu - applying import")
+				return
+			default:
+				log.Printf("Menu import: %d new, %d changed, %d removed", len(plan.New), len(plan.Changed), len(plan.Removed))
+				http.Redirect(w, r, "/admin/dashboard", http.StatusSeeOther)
+
+				return
+			}
+		}
+
+		if len(problems) == 0 {
+			plan, err := m.DB.PlanMenuImport(items)
+
+			switch {
+			case errors.Is(err, models.ErrImportMismatch):
+				problems = append(problems, err.Error())
+			case err != nil:
+				m.adminError(w, r, err, http.StatusInternalServerError, "ImportMenu - comparing menu")
+				return
+			default:
+				data["Plan"] = plan
+			}
+		}
+
+		data["Problems"] = problems
+		data["Format"] = format
+		data["Content"] = string(content)
+	}
+
+	m.renderImport(w, data)
+}
+
+// importContent returns the format and content of an import, either from an uploaded file or from
+// the content field of the confirm form
+func importContent(r *http.Request) (string, []byte, error) {
+	if content := r.FormValue("content"); content != "" {
+		format := r.FormValue("format")
+		if format != "csv" && format != "json" {
+			return "", nil, errors.New("unknown import format")
+		}
+
+		return format, []byte(content), nil
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return "", nil, errors.New("choose a CSV or JSON file")
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return "", nil, errors.New("could not read the file")
+	}
+
+	switch strings.ToLower(filepath.Ext(header.Filename)) {
+	case ".csv":
+		return "csv", content, nil
+	case ".json":
+		return "json", content, nil
+	default:
+		return "", nil, errors.New("only .csv and .json files can be imported")
+	}
+}
+
+// renderImport renders the import template
+func (m *AppServices) renderImport(w http.ResponseWriter, data map[string]interface{}) {
+	err := m.TemplateCache["admin-import.html"].Execute(w, data)
+	if err != nil {
+		// Just log the error since template.Execute likely already wrote to the response
+		log.Printf("ERROR: Template rendering failed in ImportMenu: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/i18n"
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+// languageCookieName is the cookie remembering a language chosen with ?lang=
+const languageCookieName = "lang"
+
+// languageLink is an entry of the language switcher on the menu
+type languageLink struct {
+	Code    string
+	Name    string
+	URL     string
+	Current bool
+}
+
+// language returns the language to show the page in. A ?lang= parameter wins and is remembered
+// in a cookie for later visits; otherwise the cookie and then the Accept-Language header decide.
+func (m *AppServices) language(w http.ResponseWriter, r *http.Request) string {
+	w.Header().Add("Vary", "Accept-Language, Cookie")
+
+	lang := r.URL.Query().Get("lang")
+
+	switch {
+	case i18n.IsSupported(lang):
+		http.SetCookie(w, &http.Cookie{
+			Name:     languageCookieName,
+			Value:    lang,
+			Path:     "/",
+			MaxAge:   int((365 * 24 * time.Hour).Seconds()),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+	default:
+		cookie, err := r.Cookie(languageCookieName)
+		if err == nil && i18n.IsSupported(cookie.Value) {
+			lang = cookie.Value
+		} else {
+			lang = i18n.Negotiate(r.Header.Get("Accept-Language"))
+		}
+	}
+
+	w.Header().Set("Content-Language", lang)
+
+	return lang
+}
+
+// localizedTemplate returns a copy of a cached template whose t, tn and tlabel functions
+// translate into lang. The cached template itself is never executed, so it can be cloned.
+func (m *AppServices) localizedTemplate(name, lang string) (*template.Template, error) {
+	tmpl, ok := m.TemplateCache[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s not found", name)
+	}
+
+	clone, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	return clone.Funcs(i18n.FuncMap(lang)), nil
+}
+
+// languageLinks builds the language switcher for the current page, keeping its other query parameters
+func languageLinks(r *http.Request, current string) []languageLink {
+	links := make([]languageLink, 0, len(i18n.Languages))
+
+	for _, lang := range i18n.Languages {
+		query := r.URL.Query()
+		query.Set("lang", lang)
+
+		links = append(links, languageLink{
+			Code:    lang,
+			Name:    i18n.Name(lang),
+			URL:     (&url.URL{Path: r.URL.Path, RawQuery: query.Encode()}).String(),
+			Current: lang == current,
+		})
+	}
+
+	return links
+}
+
+// localizeMenu translates the menu items, categories and flash messages in place. Fields without
+// a translation keep the default language. In Italian, categories are headed by their Italian
+// name alone unless a translation says otherwise.
+func (m *AppServices) localizeMenu(lang string, items []models.MenuItem, categories []models.Category, messages []models.FlashMessage) error {
+	if lang == i18n.Default {
+		return nil
+	}
+
+	itemTranslations, err := m.DB.GetTranslations(models.TranslationMenuItem, lang)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		items[i] = items[i].Localize(itemTranslations[items[i].ID])
+	}
+
+	categoryTranslations, err := m.DB.GetTranslations(models.TranslationCategory, lang)
+	if err != nil {
+		return err
+	}
+
+	for i := range categories {
+		if lang == i18n.Italian {
+			categories[i].NameDE = ""
+		}
+
+		categories[i] = categories[i].Localize(categoryTranslations[categories[i].ID])
+	}
+
+	messageTranslations, err := m.DB.GetTranslations(models.TranslationFlashMessage, lang)
+	if err != nil {
+		return err
+	}
+
+	for i := range messages {
+		messages[i] = messages[i].Localize(messageTranslations[messages[i].ID])
+	}
+
+	return nil
+}
+
+// translationsFromForm reads the translated fields of a form, named like "description.en".
+// Fields the form does not have are left out, so their translations stay as they are.
+func translationsFromForm(r *http.Request, entityType string) map[string]models.Translation {
+	translations := make(map[string]models.Translation)
+
+	for _, lang := range i18n.Translated() {
+		translations[lang] = make(models.Translation)
+
+		for _, field := range models.TranslatableFields[entityType] {
+			if values, ok := r.Form[field+"."+lang]; ok {
+				translations[lang][field] = values[0]
+			}
+		}
+	}
+
+	return translations
+}
+
+// translationLanguages describes the languages that need translations, for the admin forms
+func translationLanguages() []languageLink {
+	var langs []languageLink
+
+	for _, lang := range i18n.Translated() {
+		langs = append(langs, languageLink{Code: lang, Name: i18n.Name(lang)})
+	}
+
+	return langs
+}
@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"mime/multipart"
 	"net/http"
@@ -52,12 +56,43 @@ func (m *AppServices) deleteImageFile(imageURL string) {
 }
 
 // ShowCreateMenuItem displays the create menu item form
-func (m *AppServices) ShowCreateMenuItem(w http.ResponseWriter, _ *http.Request) {
+func (m *AppServices) ShowCreateMenuItem(w http.ResponseWriter, r *http.Request) {
+	categories, err := m.DB.GetAllCategories()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "ShowCreateMenuItem - fetching categories")
+		return
+	}
+
+	allergens, additives, err := m.declarationLists()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "ShowCreateMenuItem - fetching allergens and additives")
+		return
+	}
+
+	tags, err := m.DB.GetAllTags()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "ShowCreateMenuItem - fetching tags")
+		return
+	}
+
+	extras, err := m.DB.GetAllExtras()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "ShowCreateMenuItem - fetching extras")
+		return
+	}
+
 	// Render the menu form template
-	err := m.TemplateCache["menu-form.html"].Execute(w, map[string]interface{}{
-		"Title":    "Create Menu Item",
-		"FormType": "create",
-		"Year":     time.Now().Year(),
+	err = m.TemplateCache["menu-form.html"].Execute(w, map[string]interface{}{
+		"Title":        "Create Menu Item",
+		"FormType":     "create",
+		"Categories":   categories,
+		"Allergens":    allergens,
+		"Additives":    additives,
+		"Tags":         tags,
+		"Extras":       extras,
+		"Languages":    translationLanguages(),
+		"Translations": map[string]models.Translation{},
+		"Year":         time.Now().Year(),
 	})
 
 	if err != nil {
@@ -85,12 +120,49 @@ func (m *AppServices) ShowEditMenuItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	categories, err := m.DB.GetAllCategories()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "ShowEditMenuItem - fetching categories")
+		return
+	}
+
+	allergens, additives, err := m.declarationLists()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "ShowEditMenuItem - fetching allergens and additives")
+		return
+	}
+
+	tags, err := m.DB.GetAllTags()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "ShowEditMenuItem - fetching tags")
+		return
+	}
+
+	extras, err := m.DB.GetAllExtras()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "ShowEditMenuItem - fetching extras")
+		return
+	}
+
+	translations, err := m.DB.GetRecordTranslations(models.TranslationMenuItem, idInt)
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "ShowEditMenuItem - fetching translations")
+		return
+	}
+
 	// Render the menu form template
 	err = m.TemplateCache["menu-form.html"].Execute(w, map[string]interface{}{
-		"Title":    "Edit Menu Item",
-		"FormType": "edit",
-		"Item":     item,
-		"Year":     time.Now().Year(),
+		"Title":        "Edit Menu Item",
+		"FormType":     "edit",
+		"Item":         item,
+		"Categories":   categories,
+		"Allergens":    allergens,
+		"Additives":    additives,
+		"Tags":         tags,
+		"Extras":       extras,
+		"Languages":    translationLanguages(),
+		"Translations": translations,
+		"Year":         time.Now().Year(),
 	})
 
 	if err != nil {
@@ -100,6 +172,288 @@ func (m *AppServices) ShowEditMenuItem(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// parseCategoryID parses a category ID from a form value and checks that the category exists
+func (m *AppServices) parseCategoryID(value string) (int, error) {
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := m.DB.GetCategoryByID(id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// checkedIDs parses the values of a checkbox group into a set of IDs
+func checkedIDs(r *http.Request, field string) (map[int]bool, error) {
+	ids := make(map[int]bool)
+
+	for _, value := range r.Form[field] {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q", field, value)
+		}
+
+		ids[id] = true
+	}
+
+	return ids, nil
+}
+
+// variantsFromForm reads the variant rows of a submitted menu item form.
+// Rows are sent as parallel variant_key, variant_id, variant_label and variant_price fields;
+// variant_default holds the key of the default row. Completely empty rows are skipped.
+func variantsFromForm(r *http.Request) ([]models.Variant, error) {
+	keys := r.Form["variant_key"]
+	ids := r.Form["variant_id"]
+	labels := r.Form["variant_label"]
+	prices := r.Form["variant_price"]
+
+	if len(ids) != len(keys) || len(labels) != len(keys) || len(prices) != len(keys) {
+		return nil, errors.New("invalid price list")
+	}
+
+	defaultKey := r.FormValue("variant_default")
+
+	var variants []models.Variant
+
+	for i, key := range keys {
+		label := strings.TrimSpace(labels[i])
+		priceStr := strings.TrimSpace(prices[i])
+
+		if label == "" && priceStr == "" {
+			continue
+		}
+
+		price, err := models.ParseMoney(priceStr)
+		if err != nil || price.Cents < 0 {
+			return nil, fmt.Errorf("invalid price %q", priceStr)
+		}
+
+		// New rows have no ID yet
+		var id int
+
+		if ids[i] != "" {
+			id, err = strconv.Atoi(ids[i])
+			if err != nil {
+				return nil, errors.New("invalid price list")
+			}
+		}
+
+		variants = append(variants, models.Variant{
+			ID:        id,
+			Label:     label,
+			Price:     price,
+			IsDefault: key == defaultKey,
+		})
+	}
+
+	if len(variants) == 0 {
+		return nil, errors.New("at least one price is required")
+	}
+
+	// Several sizes need labels so guests can tell them apart
+	if len(variants) > 1 {
+		for _, v := range variants {
+			if v.Label == "" {
+				return nil, errors.New("every size needs a label when there is more than one price")
+			}
+		}
+	}
+
+	return variants, nil
+}
+
+// availabilityFromForm reads the availability and the optional sold out date of a submitted menu item form
+func availabilityFromForm(r *http.Request) (models.Availability, *time.Time, error) {
+	availability := models.Availability(r.FormValue("availability"))
+	if availability == "" {
+		availability = models.AvailabilityAvailable
+	}
+
+	if !availability.IsValid() {
+		return "", nil, fmt.Errorf("unknown availability %q", availability)
+	}
+
+	untilStr := strings.TrimSpace(r.FormValue("sold_out_until"))
+	if availability != models.AvailabilitySoldOut || untilStr == "" {
+		return availability, nil, nil
+	}
+
+	until, err := time.Parse("2006-01-02", untilStr)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid date %q", untilStr)
+	}
+
+	return availability, &until, nil
+}
+
+// windowsFromForm reads the availability windows of a submitted menu item form.
+// Rows are sent as parallel window_weekday, window_start and window_end fields; rows without times are skipped.
+func windowsFromForm(r *http.Request) ([]models.AvailabilityWindow, error) {
+	weekdays := r.Form["window_weekday"]
+	starts := r.Form["window_start"]
+	ends := r.Form["window_end"]
+
+	if len(starts) != len(weekdays) || len(ends) != len(weekdays) {
+		return nil, errors.New("invalid time list")
+	}
+
+	var windows []models.AvailabilityWindow
+
+	for i, weekdayStr := range weekdays {
+		startStr := strings.TrimSpace(starts[i])
+		endStr := strings.TrimSpace(ends[i])
+
+		if startStr == "" && endStr == "" {
+			continue
+		}
+
+		weekday, err := strconv.Atoi(weekdayStr)
+		if err != nil || weekday < 0 || weekday > 6 {
+			return nil, fmt.Errorf("invalid weekday %q", weekdayStr)
+		}
+
+		start, err := time.Parse("15:04", startStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q", startStr)
+		}
+
+		end, err := time.Parse("15:04", endStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q", endStr)
+		}
+
+		if !end.After(start) {
+			return nil, fmt.Errorf("%s must be after %s", endStr, startStr)
+		}
+
+		windows = append(windows, models.AvailabilityWindow{
+			Weekday: time.Weekday(weekday),
+			Start:   start.Format("15:04"),
+			End:     end.Format("15:04"),
+		})
+	}
+
+	return windows, nil
+}
+
+// tagsFromForm reads the checked tags of a submitted menu item form
+func (m *AppServices) tagsFromForm(r *http.Request) ([]models.Tag, error) {
+	tags, err := m.DB.GetAllTags()
+	if err != nil {
+		return nil, err
+	}
+
+	tagIDs, err := checkedIDs(r, "tag_ids")
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []models.Tag
+
+	for _, t := range tags {
+		if tagIDs[t.ID] {
+			selected = append(selected, t)
+			delete(tagIDs, t.ID)
+		}
+	}
+
+	if len(tagIDs) > 0 {
+		return nil, errors.New("unknown tag")
+	}
+
+	return selected, nil
+}
+
+// extrasFromForm reads the extras checked for a single menu item
+func (m *AppServices) extrasFromForm(r *http.Request) ([]models.Extra, error) {
+	extras, err := m.DB.GetAllExtras()
+	if err != nil {
+		return nil, err
+	}
+
+	extraIDs, err := checkedIDs(r, "extra_ids")
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []models.Extra
+
+	for _, e := range extras {
+		if extraIDs[e.ID] {
+			selected = append(selected, e)
+			delete(extraIDs, e.ID)
+		}
+	}
+
+	if len(extraIDs) > 0 {
+		return nil, errors.New("unknown extra")
+	}
+
+	return selected, nil
+}
+
+// declarationLists fetches the allergen and additive reference lists
+func (m *AppServices) declarationLists() ([]models.Allergen, []models.Additive, error) {
+	allergens, err := m.DB.GetAllAllergens()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	additives, err := m.DB.GetAllAdditives()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return allergens, additives, nil
+}
+
+// declarationsFromForm reads the checked allergens and additives of a submitted menu item form
+func (m *AppServices) declarationsFromForm(r *http.Request) ([]models.Allergen, []models.Additive, error) {
+	allergens, additives, err := m.declarationLists()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allergenIDs, err := checkedIDs(r, "allergen_ids")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	additiveIDs, err := checkedIDs(r, "additive_ids")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var selectedAllergens []models.Allergen
+
+	for _, a := range allergens {
+		if allergenIDs[a.ID] {
+			selectedAllergens = append(selectedAllergens, a)
+			delete(allergenIDs, a.ID)
+		}
+	}
+
+	var selectedAdditives []models.Additive
+
+	for _, a := range additives {
+		if additiveIDs[a.ID] {
+			selectedAdditives = append(selectedAdditives, a)
+			delete(additiveIDs, a.ID)
+		}
+	}
+
+	// Anything left over does not exist in the reference tables
+	if len(allergenIDs) > 0 || len(additiveIDs) > 0 {
+		return nil, nil, errors.New("unknown allergen or additive")
+	}
+
+	return selectedAllergens, selectedAdditives, nil
+}
+
 // isValidImageExtension checks if the file has an allowed image extension
 func (m *AppServices) isValidImageExtension(filename string) bool {
 	extension := strings.ToLower(filepath.Ext(filename))
@@ -118,6 +472,33 @@ func (m *AppServices) isValidImageExtension(filename string) bool {
 	return allowedExtensions[extension]
 }
 
+// saveMenuImage stores an uploaded image under a random name in the menu images directory
+// and returns its URL
+func saveMenuImage(file io.Reader, filename string) (string, error) {
+	// Create a completely random filename with timestamp prefix
+	timestamp := time.Now().Unix()
+	extension := filepath.Ext(filename) // Get the file extension
+	randomName := fmt.Sprintf("%d_%s%s", timestamp, uuid.New().String(), extension)
+
+	// Save the file
+	filePath := filepath.Join("static", "images", "menu", randomName)
+
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	defer dst.Close()
+
+	// Copy the file content
+	_, err = dst.ReadFrom(file)
+	if err != nil {
+		return "", err
+	}
+
+	return "/" + filePath, nil // Add leading slash for web URLs
+}
+
 // CreateMenuItem handles the create menu item form submission
 func (m *AppServices) CreateMenuItem(w http.ResponseWriter, r *http.Request) {
 	// Check if this is a GET request - if so, show the form instead of processing it
@@ -136,40 +517,57 @@ func (m *AppServices) CreateMenuItem(w http.ResponseWriter, r *http.Request) {
 	// Get form values
 	name := r.FormValue("name")
 	description := r.FormValue("description")
-	categoryRaw := r.FormValue("category")
-	priceStr := r.FormValue("price")
-	smallPriceStr := r.FormValue("small_price")
+	categoryIDStr := r.FormValue("category_id")
 
 	// Basic validation
-	if name == "" || description == "" || categoryRaw == "" || priceStr == "" {
+	if name == "" || description == "" || categoryIDStr == "" {
 		m.clientError(w, http.StatusBadRequest, "All fields are required")
 		return
 	}
 
-	// Format category (capitalize first letter, lowercase rest)
-	category := strings.ToLower(categoryRaw)
-	if len(category) > 0 {
-		category = strings.ToUpper(category[:1]) + category[1:]
+	// Make sure the category exists
+	categoryID, err := m.parseCategoryID(categoryIDStr)
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid category")
+		return
 	}
 
-	// Parse price
-	price, err := strconv.ParseFloat(priceStr, 64)
+	// Parse the sizes and prices
+	variants, err := variantsFromForm(r)
 	if err != nil {
-		m.clientError(w, http.StatusBadRequest, "Invalid price")
+		m.clientError(w, http.StatusBadRequest, "Invalid prices: "+err.Error())
 		return
 	}
 
-	// Parse small price if provided
-	var smallPrice *float64
+	// Allergens and additives are optional, but must exist when given
+	allergens, additives, err := m.declarationsFromForm(r)
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid allergens or additives")
+		return
+	}
 
-	if smallPriceStr != "" {
-		smallPriceValue, err := strconv.ParseFloat(smallPriceStr, 64)
-		if err != nil {
-			m.clientError(w, http.StatusBadRequest, "Invalid small price")
-			return
-		}
+	tags, err := m.tagsFromForm(r)
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid tags")
+		return
+	}
 
-		smallPrice = &smallPriceValue
+	extras, err := m.extrasFromForm(r)
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid extras")
+		return
+	}
+
+	availability, soldOutUntil, err := availabilityFromForm(r)
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid availability: "+err.Error())
+		return
+	}
+
+	windows, err := windowsFromForm(r)
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid serving times: "+err.Error())
+		return
 	}
 
 	// Handle the uploaded image
@@ -185,45 +583,32 @@ func (m *AppServices) CreateMenuItem(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// Create a completely random filename with timestamp prefix
-		timestamp := time.Now().Unix()
-		extension := filepath.Ext(header.Filename) // Get the file extension
-		randomName := fmt.Sprintf("%d_%s%s", timestamp, uuid.New().String(), extension)
-
-		// Save the file
-		filePath := filepath.Join("static", "images", "menu", randomName)
-
-		dst, err := os.Create(filePath)
-		if err != nil {
-			m.adminError(w, r, err, http.StatusInternalServerError, "CreateMenuItem - saving image")
-			return
-		}
-
-		defer dst.Close()
-
-		// Copy the file content
-		_, err = dst.ReadFrom(file)
+		imageURL, err = saveMenuImage(file, header.Filename)
 		if err != nil {
 			m.adminError(w, r, err, http.StatusInternalServerError, "CreateMenuItem - saving image")
 			return
 		}
-
-		// Set the image URL
-		imageURL = "/" + filePath // Add leading slash for web URLs
 	}
 
 	// Create menu item
 	item := models.MenuItem{
-		Name:        name,
-		Description: description,
-		Category:    category,
-		Price:       price,
-		SmallPrice:  smallPrice,
-		ImageURL:    imageURL,
+		Name:         name,
+		Description:  description,
+		CategoryID:   categoryID,
+		Variants:     variants,
+		ImageURL:     imageURL,
+		Allergens:    allergens,
+		Additives:    additives,
+		Tags:         tags,
+		Extras:       extras,
+		Availability: availability,
+		SoldOutUntil: soldOutUntil,
+		Windows:      windows,
+		Translations: translationsFromForm(r, models.TranslationMenuItem),
 	}
 
 	// Save to database
-	_, err = m.DB.InsertMenuItem(item)
+	_, err = m.DB.InsertMenuItem(item, currentUser(r))
 	if err != nil {
 		m.adminError(w, r, err, http.StatusInternalServerError, "CreateMenuItem - saving menu item")
 		return
@@ -267,41 +652,58 @@ func (m *AppServices) UpdateMenuItem(w http.ResponseWriter, r *http.Request) {
 	// Get form values
 	name := r.FormValue("name")
 	description := r.FormValue("description")
-	categoryRaw := r.FormValue("category")
-	priceStr := r.FormValue("price")
-	smallPriceStr := r.FormValue("small_price")
+	categoryIDStr := r.FormValue("category_id")
 	removeImage := r.FormValue("remove_image")
 
 	// Basic validation
-	if name == "" || description == "" || categoryRaw == "" || priceStr == "" {
+	if name == "" || description == "" || categoryIDStr == "" {
 		m.clientError(w, http.StatusBadRequest, "All fields are required")
 		return
 	}
 
-	// Format category
-	category := strings.ToLower(categoryRaw)
-	if len(category) > 0 {
-		category = strings.ToUpper(category[:1]) + category[1:]
+	// Make sure the category exists
+	categoryID, err := m.parseCategoryID(categoryIDStr)
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid category")
+		return
 	}
 
-	// Parse price
-	price, err := strconv.ParseFloat(priceStr, 64)
+	// Parse the sizes and prices
+	variants, err := variantsFromForm(r)
 	if err != nil {
-		m.clientError(w, http.StatusBadRequest, "Invalid price")
+		m.clientError(w, http.StatusBadRequest, "Invalid prices: "+err.Error())
 		return
 	}
 
-	// Parse small price if provided
-	var smallPrice *float64
+	// Allergens and additives are optional, but must exist when given
+	allergens, additives, err := m.declarationsFromForm(r)
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid allergens or additives")
+		return
+	}
 
-	if smallPriceStr != "" {
-		smallPriceValue, err := strconv.ParseFloat(smallPriceStr, 64)
-		if err != nil {
-			m.clientError(w, http.StatusBadRequest, "Invalid small price")
-			return
-		}
+	tags, err := m.tagsFromForm(r)
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid tags")
+		return
+	}
+
+	extras, err := m.extrasFromForm(r)
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid extras")
+		return
+	}
+
+	availability, soldOutUntil, err := availabilityFromForm(r)
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid availability: "+err.Error())
+		return
+	}
 
-		smallPrice = &smallPriceValue
+	windows, err := windowsFromForm(r)
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid serving times: "+err.Error())
+		return
 	}
 
 	// Handle the uploaded image
@@ -335,47 +737,34 @@ func (m *AppServices) UpdateMenuItem(w http.ResponseWriter, r *http.Request) {
 				m.deleteImageFile(existingItem.ImageURL)
 			}
 
-			// Create a completely random filename with timestamp prefix
-			timestamp := time.Now().Unix()
-			extension := filepath.Ext(header.Filename) // Get the file extension
-			randomName := fmt.Sprintf("%d_%s%s", timestamp, uuid.New().String(), extension)
-
-			// Save the file
-			filePath := filepath.Join("static", "images", "menu", randomName)
-
-			dst, err := os.Create(filePath)
-			if err != nil {
-				m.adminError(w, r, err, http.StatusInternalServerError, "UpdateMenuItem - saving image")
-				return
-			}
-
-			defer dst.Close()
-
-			// Copy the file content
-			_, err = dst.ReadFrom(file)
+			imageURL, err = saveMenuImage(file, header.Filename)
 			if err != nil {
 				m.adminError(w, r, err, http.StatusInternalServerError, "UpdateMenuItem - saving image")
 				return
 			}
-
-			// Set the new image URL
-			imageURL = "/" + filePath // Add leading slash for web URLs
 		}
 	}
 
 	// Create menu item
 	item := models.MenuItem{
-		ID:          idInt,
-		Name:        name,
-		Description: description,
-		Category:    category,
-		Price:       price,
-		SmallPrice:  smallPrice,
-		ImageURL:    imageURL,
+		ID:           idInt,
+		Name:         name,
+		Description:  description,
+		CategoryID:   categoryID,
+		Variants:     variants,
+		ImageURL:     imageURL,
+		Allergens:    allergens,
+		Additives:    additives,
+		Tags:         tags,
+		Extras:       extras,
+		Availability: availability,
+		SoldOutUntil: soldOutUntil,
+		Windows:      windows,
+		Translations: translationsFromForm(r, models.TranslationMenuItem),
 	}
 
 	// Update in database
-	err = m.DB.UpdateMenuItem(item)
+	err = m.DB.UpdateMenuItem(item, currentUser(r))
 	if err != nil {
 		m.adminError(w, r, err, http.StatusInternalServerError, "UpdateMenuItem - updating menu item")
 		return
@@ -385,7 +774,7 @@ func (m *AppServices) UpdateMenuItem(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/admin/dashboard", http.StatusSeeOther)
 }
 
-// DeleteMenuItem handles the deletion of a menu item
+// DeleteMenuItem moves a menu item to the trash. Its image is kept until the item is purged.
 func (m *AppServices) DeleteMenuItem(w http.ResponseWriter, r *http.Request) {
 	// Extract ID from URL
 	id := r.URL.Path[len("/admin/menu/delete/"):]
@@ -396,14 +785,12 @@ func (m *AppServices) DeleteMenuItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get the menu item to check if it has an image
-	item, err := m.DB.GetMenuItemByID(idInt)
-	if err == nil && item.ImageURL != "" {
-		m.deleteImageFile(item.ImageURL)
+	err = m.DB.DeleteMenuItem(idInt, currentUser(r))
+	if errors.Is(err, sql.ErrNoRows) {
+		m.clientError(w, http.StatusNotFound, "Menu item not found")
+		return
 	}
 
-	// Delete from database
-	err = m.DB.DeleteMenuItem(idInt)
 	if err != nil {
 		m.adminError(w, r, err, http.StatusInternalServerError, "DeleteMenuItem - deleting menu item")
 		return
@@ -412,3 +799,77 @@ func (m *AppServices) DeleteMenuItem(w http.ResponseWriter, r *http.Request) {
 	// Redirect to admin dashboard
 	http.Redirect(w, r, "/admin/dashboard", http.StatusSeeOther)
 }
+
+// ToggleMenuItemAvailability marks an item as sold out for the rest of the day, or makes a sold out
+// or hidden item available again
+func (m *AppServices) ToggleMenuItemAvailability(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		m.clientError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Extract ID from URL
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/menu/availability/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	item, err := m.DB.GetMenuItemByID(id)
+	if err != nil {
+		m.clientError(w, http.StatusNotFound, "Menu item not found")
+		return
+	}
+
+	now := time.Now()
+
+	if item.IsHidden() || item.IsSoldOutAt(now) {
+		err = m.DB.SetMenuItemAvailability(id, models.AvailabilityAvailable, nil, currentUser(r))
+	} else {
+		err = m.DB.SetMenuItemAvailability(id, models.AvailabilitySoldOut, &now, currentUser(r))
+	}
+
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "ToggleMenuItemAvailability - updating menu item")
+		return
+	}
+
+	http.Redirect(w, r, "/admin/dashboard", http.StatusSeeOther)
+}
+
+// reorderRequest is the JSON body accepted by ReorderMenuItems
+type reorderRequest struct {
+	CategoryID int   `json:"category_id"`
+	ItemIDs    []int `json:"item_ids"`
+}
+
+// ReorderMenuItems stores a new item order for one category, sent as JSON by the dashboard
+func (m *AppServices) ReorderMenuItems(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	var req reorderRequest
+
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+
+	err = m.DB.ReorderMenuItems(req.CategoryID, req.ItemIDs)
+	if errors.Is(err, models.ErrInvalidOrdering) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		log.Printf("ERROR (ReorderMenuItems): %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not save the new order"})
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
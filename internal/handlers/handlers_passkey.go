@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/auth"
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+// maxPasskeyNameLength limits the name an admin gives a passkey
+const maxPasskeyNameLength = 64
+
+// AdminPasskeys displays the passkeys of the signed-in admin, which let them sign in when their
+// OpenID Connect provider is unavailable
+func (m *AppServices) AdminPasskeys(w http.ResponseWriter, r *http.Request) {
+	passkeys, err := m.DB.GetPasskeysByEmail(currentUser(r))
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "AdminPasskeys - fetching passkeys")
+		return
+	}
+
+	// Render the passkeys template
+	err = m.TemplateCache["admin-passkeys.html"].Execute(w, map[string]interface{}{
+		"Title":       "Passkeys",
+		"Passkeys":    passkeys,
+		"Enabled":     m.Passkeys != nil,
+		"CurrentUser": currentUser(r),
+		"Error":       r.URL.Query().Get("error"),
+		"Year":        time.Now().Year(),
+	})
+
+	if err != nil {
+		// Just log the error since template.Execute likely already wrote to the response
+		log.Printf("ERROR: Template rendering failed in AdminPasskeys: %v", err)
+		return
+	}
+}
+
+// userHandle returns the user handle stored with the passkeys of an admin user: their ID. It
+// identifies them to authenticators without revealing their email.
+func userHandle(user models.AdminUser) []byte {
+	return []byte(strconv.Itoa(user.ID))
+}
+
+// PasskeyCreationOptions starts registering a passkey for the signed-in admin and returns the
+// options for navigator.credentials.create() as JSON
+func (m *AppServices) PasskeyCreationOptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	if m.Passkeys == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "passkeys are not enabled"})
+		return
+	}
+
+	user, err := m.DB.GetAdminUserByEmail(currentUser(r))
+	if err != nil {
+		log.Printf("ERROR (PasskeyCreationOptions): %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not start the registration"})
+
+		return
+	}
+
+	passkeys, err := m.DB.GetPasskeysByEmail(user.Email)
+	if err != nil {
+		log.Printf("ERROR (PasskeyCreationOptions): %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not start the registration"})
+
+		return
+	}
+
+	// The challenge is bound to the admin, so only they can complete the registration
+	challenge, err := m.DB.CreatePasskeyChallenge(user.Email, clientIP(r))
+	if errors.Is(err, models.ErrTooManyChallenges) {
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "too many registrations started, try again in a few minutes"})
+		return
+	}
+
+	if err != nil {
+		log.Printf("ERROR (PasskeyCreationOptions): %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not start the registration"})
+
+		return
+	}
+
+	var exclude [][]byte
+	for _, p := range passkeys {
+		exclude = append(exclude, p.CredentialID)
+	}
+
+	writeJSON(w, http.StatusOK, m.Passkeys.CreationOptions(challenge, userHandle(user), user.Email, exclude))
+}
+
+// registerPasskeyRequest is the JSON body accepted by RegisterPasskey
+type registerPasskeyRequest struct {
+	Name       string                    `json:"name"`
+	Credential auth.RegistrationResponse `json:"credential"`
+}
+
+// RegisterPasskey stores the passkey created with the options of PasskeyCreationOptions
+func (m *AppServices) RegisterPasskey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	if m.Passkeys == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "passkeys are not enabled"})
+		return
+	}
+
+	var req registerPasskeyRequest
+
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxPasskeyNameLength {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "please name the passkey, e.g. after the device holding it"})
+		return
+	}
+
+	challenge, err := auth.ClientDataChallenge(req.Credential.ClientDataJSON)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	email, err := m.DB.ConsumePasskeyChallenge(challenge)
+	if errors.Is(err, models.ErrInvalidChallenge) || (err == nil && email != currentUser(r)) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "the registration has expired, please try again"})
+		return
+	}
+
+	if err != nil {
+		log.Printf("ERROR (RegisterPasskey): %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not save the passkey"})
+
+		return
+	}
+
+	credential, err := m.Passkeys.VerifyRegistration(req.Credential, challenge)
+	if err != nil {
+		log.Printf("Passkey registration by %s rejected: %v", email, err)
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "the passkey could not be verified"})
+
+		return
+	}
+
+	_, err = m.DB.InsertPasskey(models.Passkey{
+		Email:        email,
+		Name:         name,
+		CredentialID: credential.ID,
+		PublicKey:    credential.PublicKey,
+		SignCount:    credential.SignCount,
+	})
+	if err != nil {
+		log.Printf("ERROR (RegisterPasskey): %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not save the passkey"})
+
+		return
+	}
+
+	log.Printf("Passkey %q registered by %s", name, email)
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// DeletePasskey removes a passkey of the signed-in admin
+func (m *AppServices) DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from URL
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/passkeys/delete/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/passkeys", http.StatusSeeOther)
+		return
+	}
+
+	// Admins can only remove their own passkeys; others are not found
+	err = m.DB.DeletePasskey(id, currentUser(r))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		m.adminError(w, r, err, http.StatusInternalServerError, "DeletePasskey - deleting passkey")
+		return
+	}
+
+	if err == nil {
+		log.Printf("Passkey %d removed by %s", id, currentUser(r))
+	}
+
+	http.Redirect(w, r, "/admin/passkeys", http.StatusSeeOther)
+}
+
+// PasskeyLoginOptions starts signing in with a passkey and returns the options for
+// navigator.credentials.get() as JSON
+func (m *AppServices) PasskeyLoginOptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	if m.Passkeys == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "passkeys are not enabled"})
+		return
+	}
+
+	// The passkey tells who signs in, so the challenge is not bound to an admin,
+	// and each client may only hold a few open at a time
+	challenge, err := m.DB.CreatePasskeyChallenge("", clientIP(r))
+	if errors.Is(err, models.ErrTooManyChallenges) {
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "too many sign-ins started, try again in a few minutes"})
+		return
+	}
+
+	if err != nil {
+		log.Printf("ERROR (PasskeyLoginOptions): %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not start the sign-in"})
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, m.Passkeys.RequestOptions(challenge))
+}
+
+// PasskeyLogin checks a passkey sign-in and starts a session for its admin user. The JSON answer
+// names the page to continue on.
+func (m *AppServices) PasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	if m.Passkeys == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "passkeys are not enabled"})
+		return
+	}
+
+	var response auth.AssertionResponse
+
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&response)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+
+	// Every failure gets the same answer, so it does not tell which passkeys exist
+	reject := func(reason string, args ...any) {
+		log.Printf("Passkey sign-in rejected: "+reason, args...)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "the passkey sign-in failed, please try again"})
+	}
+
+	challenge, err := auth.ClientDataChallenge(response.ClientDataJSON)
+	if err != nil {
+		reject("%v", err)
+		return
+	}
+
+	// Each challenge can be used once, so a recorded sign-in cannot be replayed
+	email, err := m.DB.ConsumePasskeyChallenge(challenge)
+	if errors.Is(err, models.ErrInvalidChallenge) || (err == nil && email != "") {
+		reject("unknown or expired challenge")
+		return
+	}
+
+	if err != nil {
+		log.Printf("ERROR (PasskeyLogin): %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not check the passkey"})
+
+		return
+	}
+
+	passkey, err := m.DB.GetPasskeyByCredentialID(response.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		reject("unknown passkey")
+		return
+	}
+
+	if err != nil {
+		log.Printf("ERROR (PasskeyLogin): %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not check the passkey"})
+
+		return
+	}
+
+	// Passkeys of removed admin users are deleted with them; check again to be sure
+	user, err := m.DB.GetAdminUserByEmail(passkey.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		reject("%s is no admin user", passkey.Email)
+		return
+	}
+
+	if err != nil {
+		log.Printf("ERROR (PasskeyLogin): %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not check the passkey"})
+
+		return
+	}
+
+	signCount, err := m.Passkeys.VerifyAssertion(response, challenge, passkey.PublicKey, userHandle(user), passkey.SignCount)
+	if err != nil {
+		reject("passkey %d of %s: %v", passkey.ID, passkey.Email, err)
+		return
+	}
+
+	if err := m.DB.RecordPasskeyUse(passkey.ID, signCount); err != nil {
+		log.Printf("ERROR (PasskeyLogin): %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not check the passkey"})
+
+		return
+	}
+
+	// Start a server-side session and set its cookie
+	if err := m.startSession(w, r, user.Email); err != nil {
+		log.Printf("ERROR (PasskeyLogin): %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not start a session"})
+
+		return
+	}
+
+	log.Printf("%s signed in with passkey %q", user.Email, passkey.Name)
+
+	writeJSON(w, http.StatusOK, map[string]string{"redirect": "/admin"})
+}
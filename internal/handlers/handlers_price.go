@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+// AdminPrices displays the current prices with a form to schedule new ones and the changes already scheduled
+func (m *AppServices) AdminPrices(w http.ResponseWriter, r *http.Request) {
+	items, err := m.DB.GetAllMenuItems()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "AdminPrices - fetching menu items")
+		return
+	}
+
+	schedules, err := m.DB.GetUpcomingPriceSchedules()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "AdminPrices - fetching scheduled prices")
+		return
+	}
+
+	// New prices usually start with a new month
+	now := time.Now().In(models.Location)
+	nextMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, models.Location)
+
+	// Render the prices template
+	err = m.TemplateCache["admin-prices.html"].Execute(w, map[string]interface{}{
+		"Title":        "Prices",
+		"Items":        items,
+		"Schedules":    schedules,
+		"DefaultDate":  nextMonth.Format(time.DateOnly),
+		"Tomorrow":     now.AddDate(0, 0, 1).Format(time.DateOnly),
+		"Error":        r.URL.Query().Get("error"),
+		"ScheduledFor": r.URL.Query().Get("scheduled"),
+		"Year":         now.Year(),
+	})
+
+	if err != nil {
+		// Just log the error since template.Execute likely already wrote to the response
+		log.Printf("ERROR: Template rendering failed in AdminPrices: %v", err)
+		return
+	}
+}
+
+// redirectToPrices redirects back to the prices page, optionally with an error message
+func redirectToPrices(w http.ResponseWriter, r *http.Request, errorMsg string) {
+	target := "/admin/prices"
+	if errorMsg != "" {
+		target += "?error=" + url.QueryEscape(errorMsg)
+	}
+
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// schedulesFromForm reads the scheduled prices of a submitted form. The variant_id and price
+// fields are parallel lists; variants left without a new price are skipped.
+func schedulesFromForm(r *http.Request) ([]models.PriceSchedule, error) {
+	effectiveFrom, err := time.ParseInLocation(time.DateOnly, r.FormValue("effective_from"), models.Location)
+	if err != nil {
+		return nil, errors.New("invalid date")
+	}
+
+	ids := r.Form["variant_id"]
+	prices := r.Form["price"]
+
+	if len(ids) != len(prices) {
+		return nil, errors.New("incomplete price list")
+	}
+
+	var schedules []models.PriceSchedule
+
+	for i, priceStr := range prices {
+		priceStr = strings.TrimSpace(priceStr)
+		if priceStr == "" {
+			continue
+		}
+
+		variantID, err := strconv.Atoi(ids[i])
+		if err != nil {
+			return nil, errors.New("invalid variant")
+		}
+
+		price, err := models.ParseMoney(priceStr)
+		if err != nil || price.Cents < 0 {
+			return nil, fmt.Errorf("invalid price %q", priceStr)
+		}
+
+		schedules = append(schedules, models.PriceSchedule{
+			VariantID:     variantID,
+			Price:         price,
+			EffectiveFrom: effectiveFrom,
+		})
+	}
+
+	if len(schedules) == 0 {
+		return nil, errors.New("enter at least one new price")
+	}
+
+	return schedules, nil
+}
+
+// SchedulePrices handles the form that schedules a batch of new prices for a date
+func (m *AppServices) SchedulePrices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/prices", http.StatusSeeOther)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Could not parse form")
+		return
+	}
+
+	schedules, err := schedulesFromForm(r)
+	if err != nil {
+		redirectToPrices(w, r, err.Error())
+		return
+	}
+
+	err = m.DB.SchedulePrices(schedules)
+	if errors.Is(err, models.ErrScheduleInPast) {
+		redirectToPrices(w, r, err.Error())
+		return
+	}
+
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "SchedulePrices - saving scheduled prices")
+		return
+	}
+
+	http.Redirect(w, r, "/admin/prices?scheduled="+schedules[0].EffectiveFrom.Format(time.DateOnly), http.StatusSeeOther)
+}
+
+// DeletePriceSchedule removes a scheduled price before it takes effect
+func (m *AppServices) DeletePriceSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/prices", http.StatusSeeOther)
+		return
+	}
+
+	// Extract ID from URL
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/prices/delete/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	err = m.DB.DeletePriceSchedule(id)
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "DeletePriceSchedule - deleting scheduled price")
+		return
+	}
+
+	redirectToPrices(w, r, "")
+}
+
+// PreviewMenu renders the public menu as it will look on the date given in the URL,
+// e.g. /admin/prices/preview?date=2026-01-01, at the current time of day
+func (m *AppServices) PreviewMenu(w http.ResponseWriter, r *http.Request) {
+	date, err := time.ParseInLocation(time.DateOnly, r.URL.Query().Get("date"), models.Location)
+	if err != nil {
+		redirectToPrices(w, r, "invalid preview date")
+		return
+	}
+
+	now := time.Now().In(models.Location)
+	at := time.Date(date.Year(), date.Month(), date.Day(), now.Hour(), now.Minute(), 0, 0, models.Location)
+
+	m.renderMenu(w, r, at, true)
+}
+
+// priceAdjustmentFromForm reads the selection and adjustment of the bulk price form. The same
+// fields are used for the preview (query string) and for applying (POST body).
+func priceAdjustmentFromForm(form url.Values) (models.PriceSelection, models.PriceAdjustment, error) {
+	var selection models.PriceSelection
+
+	var adjustment models.PriceAdjustment
+
+	switch form.Get("scope") {
+	case "category":
+		id, err := strconv.Atoi(form.Get("category_id"))
+		if err != nil {
+			return selection, adjustment, errors.New("choose a category")
+		}
+
+		selection.CategoryID = id
+	case "tag":
+		id, err := strconv.Atoi(form.Get("tag_id"))
+		if err != nil {
+			return selection, adjustment, errors.New("choose a tag")
+		}
+
+		selection.TagID = id
+	case "items":
+		for _, idStr := range form["item_ids"] {
+			id, err := strconv.Atoi(idStr)
+			if err != nil {
+				return selection, adjustment, errors.New("invalid menu item")
+			}
+
+			selection.ItemIDs = append(selection.ItemIDs, id)
+		}
+
+		if len(selection.ItemIDs) == 0 {
+			return selection, adjustment, errors.New("choose at least one menu item")
+		}
+	default:
+		return selection, adjustment, errors.New("choose which items to change")
+	}
+
+	value := strings.TrimSpace(form.Get("value"))
+
+	switch form.Get("mode") {
+	case "percent":
+		// Percentages are entered like prices, e.g. "5" or "-2,5"
+		percent, err := models.ParseMoney(strings.TrimSuffix(value, "%"))
+		if err != nil {
+			return selection, adjustment, fmt.Errorf("invalid percentage %q", value)
+		}
+
+		adjustment.Percent = int(percent.Cents)
+	case "amount":
+		amount, err := models.ParseMoney(value)
+		if err != nil {
+			return selection, adjustment, fmt.Errorf("invalid amount %q", value)
+		}
+
+		adjustment.Amount = amount
+	default:
+		return selection, adjustment, errors.New("choose a percentage or a fixed amount")
+	}
+
+	adjustment.Rounding = models.PriceRounding(form.Get("rounding"))
+	if !adjustment.Rounding.IsValid() {
+		return selection, adjustment, errors.New("invalid rounding")
+	}
+
+	return selection, adjustment, nil
+}
+
+// AdjustPrices shows the bulk price form. Once submitted it previews the old and new prices,
+// which are saved by posting the same form.
+func (m *AppServices) AdjustPrices(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Could not parse form")
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		m.applyPriceAdjustment(w, r)
+		return
+	}
+
+	items, err := m.DB.GetAllMenuItems()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "AdjustPrices - fetching menu items")
+		return
+	}
+
+	categories, err := m.DB.GetAllCategories()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "AdjustPrices - fetching categories")
+		return
+	}
+
+	tags, err := m.DB.GetAllTags()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "AdjustPrices - fetching tags")
+		return
+	}
+
+	errorMsg := r.Form.Get("error")
+
+	var changes []models.PriceChange
+
+	// Only preview once the form was submitted
+	if r.Form.Has("scope") && errorMsg == "" {
+		selection, adjustment, err := priceAdjustmentFromForm(r.Form)
+		if err != nil {
+			errorMsg = err.Error()
+		} else {
+			changes, err = m.DB.PreviewPriceAdjustment(selection, adjustment)
+			if err != nil {
+				m.adminError(w, r, err, http.StatusInternalServerError, "AdjustPrices - previewing prices")
+				return
+			}
+
+			if len(changes) == 0 {
+				errorMsg = models.ErrNoPriceChanges.Error()
+			}
+		}
+	}
+
+	selectedItems := make(map[int]bool)
+
+	for _, idStr := range r.Form["item_ids"] {
+		if id, err := strconv.Atoi(idStr); err == nil {
+			selectedItems[id] = true
+		}
+	}
+
+	// Render the bulk price template
+	err = m.TemplateCache["admin-price-adjust.html"].Execute(w, map[string]interface{}{
+		"Title":         "Adjust Prices",
+		"Items":         items,
+		"Categories":    categories,
+		"Tags":          tags,
+		"Form":          r.Form,
+		"SelectedItems": selectedItems,
+		"Changes":       changes,
+		"Error":         errorMsg,
+		"Year":          time.Now().Year(),
+	})
+
+	if err != nil {
+		// Just log the error since template.Execute likely already wrote to the response
+		log.Printf("ERROR: Template rendering failed in AdjustPrices: %v", err)
+		return
+	}
+}
+
+// applyPriceAdjustment saves a previewed bulk price change
+func (m *AppServices) applyPriceAdjustment(w http.ResponseWriter, r *http.Request) {
+	selection, adjustment, err := priceAdjustmentFromForm(r.PostForm)
+	if err != nil {
+		http.Redirect(w, r, "/admin/prices/adjust?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
+	changes, err := m.DB.ApplyPriceAdjustment(selection, adjustment, currentUser(r))
+	if errors.Is(err, models.ErrNoPriceChanges) {
+		http.Redirect(w, r, "/admin/prices/adjust?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "AdjustPrices - saving prices")
+		return
+	}
+
+	log.Printf("Adjusted %d prices", len(changes))
+
+	http.Redirect(w, r, "/admin/prices", http.StatusSeeOther)
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+// MenuItemHistory displays the revision history of a menu item with the changes of each revision
+func (m *AppServices) MenuItemHistory(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from URL
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/menu/history/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	item, err := m.DB.GetMenuItemByID(id)
+	if err != nil {
+		m.clientError(w, http.StatusNotFound, "Menu item not found")
+		return
+	}
+
+	revisions, err := m.DB.GetMenuItemRevisions(id)
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "MenuItemHistory - fetching revisions")
+		return
+	}
+
+	// Render the history template
+	err = m.TemplateCache["admin-history.html"].Execute(w, map[string]interface{}{
+		"Title":     "History",
+		"Item":      item,
+		"Revisions": revisions,
+		"Error":     r.URL.Query().Get("error"),
+		"Year":      time.Now().Year(),
+	})
+
+	if err != nil {
+		// Just log the error since template.Execute likely already wrote to the response
+		log.Printf("ERROR: Template rendering failed in MenuItemHistory: %v", err)
+		return
+	}
+}
+
+// redirectToHistory redirects back to the history of a menu item, optionally with an error message
+func redirectToHistory(w http.ResponseWriter, r *http.Request, menuItemID int, errorMsg string) {
+	target := "/admin/menu/history/" + strconv.Itoa(menuItemID)
+	if errorMsg != "" {
+		target += "?error=" + url.QueryEscape(errorMsg)
+	}
+
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// keepExisting drops the entries of a stored revision that no longer exist, e.g. a tag deleted since
+func keepExisting[T any](stored, existing []T, id func(T) int) []T {
+	known := make(map[int]bool, len(existing))
+	for _, e := range existing {
+		known[id(e)] = true
+	}
+
+	var kept []T
+
+	for _, s := range stored {
+		if known[id(s)] {
+			kept = append(kept, s)
+		}
+	}
+
+	return kept
+}
+
+// RevertMenuItem restores a menu item to the state stored in one of its revisions
+func (m *AppServices) RevertMenuItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		m.clientError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Extract the revision ID from URL
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/menu/revert/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	revision, err := m.DB.GetRevisionByID(id)
+	if err != nil {
+		m.clientError(w, http.StatusNotFound, "Revision not found")
+		return
+	}
+
+	current, err := m.DB.GetMenuItemByID(revision.MenuItemID)
+	if err != nil {
+		m.clientError(w, http.StatusNotFound, "Menu item not found. Restore it from the trash first.")
+		return
+	}
+
+	item := revision.Item
+	item.ID = current.ID
+
+	var notes []string
+
+	// The revision may point at things that were removed since it was made
+	if _, err := m.DB.GetCategoryByID(item.CategoryID); err != nil {
+		item.CategoryID = current.CategoryID
+
+		notes = append(notes, "its category no longer exists, so the item stays in "+current.Category)
+	}
+
+	if item.ImageURL != "" && item.ImageURL != current.ImageURL {
+		if _, err := os.Stat(strings.TrimPrefix(item.ImageURL, "/")); err != nil {
+			item.ImageURL = current.ImageURL
+
+			notes = append(notes, "its image file was deleted, so the current image was kept")
+		}
+	}
+
+	allergens, additives, err := m.declarationLists()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "RevertMenuItem - fetching allergens and additives")
+		return
+	}
+
+	tags, err := m.DB.GetAllTags()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "RevertMenuItem - fetching tags")
+		return
+	}
+
+	extras, err := m.DB.GetAllExtras()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "RevertMenuItem - fetching extras")
+		return
+	}
+
+	item.Allergens = keepExisting(item.Allergens, allergens, func(a models.Allergen) int { return a.ID })
+	item.Additives = keepExisting(item.Additives, additives, func(a models.Additive) int { return a.ID })
+	item.Tags = keepExisting(item.Tags, tags, func(t models.Tag) int { return t.ID })
+	item.Extras = keepExisting(item.Extras, extras, func(e models.Extra) int { return e.ID })
+
+	err = m.DB.RevertMenuItem(item, currentUser(r))
+	if errors.Is(err, sql.ErrNoRows) {
+		m.clientError(w, http.StatusNotFound, "Menu item not found")
+		return
+	}
+
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "RevertMenuItem - restoring revision")
+		return
+	}
+
+	if len(notes) > 0 {
+		redirectToHistory(w, r, item.ID, "The version was restored, but "+strings.Join(notes, " and ")+".")
+		return
+	}
+
+	redirectToHistory(w, r, item.ID, "")
+}
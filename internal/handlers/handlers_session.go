@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/middleware"
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+// maxUserAgentLength limits the user agent stored with a session
+const maxUserAgentLength = 256
+
+// SessionAuth wraps an admin handler. Requests need a session cookie for a session that has not
+// expired or been revoked and whose user is still an admin user. Every request extends the
+// session; the user's email, role and session ID are put in the request context.
+func (m *AppServices) SessionAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, valid := middleware.VerifySecureSessionCookie(r)
+		if !valid {
+			log.Println("Invalid or expired session cookie, redirecting to login")
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+
+			return
+		}
+
+		session, err := m.DB.AuthenticateSession(token)
+		if errors.Is(err, models.ErrInvalidSession) {
+			log.Println("Session expired or revoked, redirecting to login")
+			middleware.ClearSecureSessionCookie(w)
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+
+			return
+		}
+
+		if err != nil {
+			m.serverError(w, err, "SessionAuth - checking session")
+			return
+		}
+
+		// Sessions end when their user is removed from the admin users
+		user, err := m.DB.GetAdminUserByEmail(session.Email)
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Unauthorized access attempt by: %s", session.Email)
+
+			if err := m.DB.RevokeSession(session.ID); err != nil {
+				log.Printf("ERROR: Revoking session %d of %s failed: %v", session.ID, session.Email, err)
+			}
+
+			middleware.ClearSecureSessionCookie(w)
+			http.Error(w, "Unauthorized: Access denied", http.StatusForbidden)
+
+			return
+		}
+
+		if err != nil {
+			m.serverError(w, err, "SessionAuth - fetching admin user")
+			return
+		}
+
+		// Role changes take effect with the next request
+		r = middleware.WithSession(r, session.Email, session.ID)
+		next(w, middleware.WithUserRole(r, user.Role))
+	}
+}
+
+// startSession creates a session for a user who just signed in and sets its cookie
+func (m *AppServices) startSession(w http.ResponseWriter, r *http.Request, email string) error {
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	session, token, err := m.DB.CreateSession(email, clientIP(r), userAgent)
+	if err != nil {
+		return err
+	}
+
+	// Ended sessions are kept for a while, so a stolen cookie can still be traced
+	if err := m.DB.DeleteEndedSessions(time.Now().Add(-models.SessionLifetime)); err != nil {
+		log.Printf("ERROR: Deleting ended sessions failed: %v", err)
+	}
+
+	middleware.SetSessionCookie(w, token)
+	log.Printf("Session %d started for %s from %s", session.ID, email, session.IP)
+
+	return nil
+}
+
+// clientIP returns the address the request came from. Forwarding headers are ignored, as they
+// can be set by anyone when the server is reached directly.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// sessionsVisibleTo returns the sessions the user of the request may see and revoke. Owners see
+// the sessions of all admins, everyone else only their own.
+func sessionsVisibleTo(r *http.Request, sessions []models.Session) []models.Session {
+	if middleware.GetUserRole(r).AtLeast(models.RoleOwner) {
+		return sessions
+	}
+
+	var own []models.Session
+
+	for _, s := range sessions {
+		if s.Email == currentUser(r) {
+			own = append(own, s)
+		}
+	}
+
+	return own
+}
+
+// AdminSessions lists the active sessions the current user may see
+func (m *AppServices) AdminSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := m.DB.GetActiveSessions()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "AdminSessions - fetching sessions")
+		return
+	}
+
+	sessions = sessionsVisibleTo(r, sessions)
+
+	// Render the sessions template
+	err = m.TemplateCache["admin-sessions.html"].Execute(w, map[string]interface{}{
+		"Title":          "Active Sessions",
+		"Sessions":       sessions,
+		"CurrentSession": middleware.GetSessionID(r),
+		"CurrentUser":    currentUser(r),
+		"Year":           time.Now().Year(),
+	})
+
+	if err != nil {
+		// Just log the error since template.Execute likely already wrote to the response
+		log.Printf("ERROR: Template rendering failed in AdminSessions: %v", err)
+		return
+	}
+}
+
+// RevokeSession signs a session out; the next request made with its cookie goes to the login page
+func (m *AppServices) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/sessions/revoke/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/sessions", http.StatusSeeOther)
+		return
+	}
+
+	sessions, err := m.DB.GetActiveSessions()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "RevokeSession - fetching sessions")
+		return
+	}
+
+	// Sessions of other admins are only revocable by owners; to everyone else they do not exist
+	visible := slices.ContainsFunc(sessionsVisibleTo(r, sessions), func(s models.Session) bool { return s.ID == id })
+	if !visible {
+		m.clientError(w, http.StatusNotFound, "Session not found or already ended")
+		return
+	}
+
+	err = m.DB.RevokeSession(id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		m.adminError(w, r, err, http.StatusInternalServerError, "RevokeSession - revoking session")
+		return
+	}
+
+	log.Printf("Session %d revoked by %s", id, currentUser(r))
+
+	http.Redirect(w, r, "/admin/sessions", http.StatusSeeOther)
+}
+
+// RevokeAllSessions signs the current user out everywhere, including this browser
+func (m *AppServices) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/sessions", http.StatusSeeOther)
+		return
+	}
+
+	email := currentUser(r)
+
+	revoked, err := m.DB.RevokeSessionsByEmail(email)
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "RevokeAllSessions - revoking sessions")
+		return
+	}
+
+	log.Printf("%s logged out everywhere, %d session(s) revoked", email, revoked)
+
+	middleware.ClearSessionCookie(w)
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
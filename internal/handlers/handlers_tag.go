@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+// AdminTags displays the dietary tag management page
+func (m *AppServices) AdminTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := m.DB.GetAllTags()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "AdminTags - fetching tags")
+		return
+	}
+
+	// Render the tags template
+	err = m.TemplateCache["admin-tags.html"].Execute(w, map[string]interface{}{
+		"Title": "Tags",
+		"Tags":  tags,
+		"Error": r.URL.Query().Get("error"),
+		"Year":  time.Now().Year(),
+	})
+
+	if err != nil {
+		// Just log the error since template.Execute likely already wrote to the response
+		log.Printf("ERROR: Template rendering failed in AdminTags: %v", err)
+		return
+	}
+}
+
+// tagFromForm reads and validates the tag fields of a submitted form
+func tagFromForm(r *http.Request) (models.Tag, error) {
+	tag := models.Tag{
+		Name: strings.TrimSpace(r.FormValue("name")),
+		Slug: models.Slugify(r.FormValue("slug")),
+	}
+
+	if tag.Name == "" {
+		return tag, errors.New("the name is required")
+	}
+
+	// Derive the slug from the name when none was given
+	if tag.Slug == "" {
+		tag.Slug = models.Slugify(tag.Name)
+	}
+
+	if tag.Slug == "" {
+		return tag, errors.New("could not derive a slug from the name")
+	}
+
+	return tag, nil
+}
+
+// redirectToTags redirects back to the tag page, optionally with an error message
+func redirectToTags(w http.ResponseWriter, r *http.Request, errorMsg string) {
+	target := "/admin/tags"
+	if errorMsg != "" {
+		target += "?error=" + url.QueryEscape(errorMsg)
+	}
+
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// CreateTag handles the create tag form submission
+func (m *AppServices) CreateTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/tags", http.StatusSeeOther)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Could not parse form")
+		return
+	}
+
+	tag, err := tagFromForm(r)
+	if err != nil {
+		redirectToTags(w, r, err.Error())
+		return
+	}
+
+	_, err = m.DB.InsertTag(tag)
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "CreateTag - saving tag")
+		return
+	}
+
+	redirectToTags(w, r, "")
+}
+
+// UpdateTag handles the edit tag form submission
+func (m *AppServices) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from URL
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/tags/update/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/tags", http.StatusSeeOther)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Could not parse form")
+		return
+	}
+
+	tag, err := tagFromForm(r)
+	if err != nil {
+		redirectToTags(w, r, err.Error())
+		return
+	}
+
+	tag.ID = id
+
+	err = m.DB.UpdateTag(tag)
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "UpdateTag - updating tag")
+		return
+	}
+
+	redirectToTags(w, r, "")
+}
+
+// DeleteTag handles the deletion of a tag
+func (m *AppServices) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from URL
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/tags/delete/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	err = m.DB.DeleteTag(id)
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "DeleteTag - deleting tag")
+		return
+	}
+
+	redirectToTags(w, r, "")
+}
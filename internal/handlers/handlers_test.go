@@ -1,11 +1,25 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/AlexTLDR/pizzeria/internal/auth"
+	"github.com/AlexTLDR/pizzeria/internal/auth/oidctest"
+	"github.com/AlexTLDR/pizzeria/internal/auth/passkeytest"
+	"github.com/AlexTLDR/pizzeria/internal/i18n"
+	"github.com/AlexTLDR/pizzeria/internal/middleware"
+	"github.com/AlexTLDR/pizzeria/internal/models"
 )
 
 func TestAppServices_Home(t *testing.T) {
@@ -26,3 +40,2012 @@ func TestAppServices_Home(t *testing.T) {
 		t.Errorf("handler response doesn't contain expected mock template content")
 	}
 }
+
+func TestAppServices_HomeFilter(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	pizzaID, err := services.DB.InsertCategory(models.Category{Slug: "pizza", NameIT: "Pizza", Visible: true})
+	if err != nil {
+		t.Fatalf("failed to insert category: %v", err)
+	}
+
+	pastaID, err := services.DB.InsertCategory(models.Category{Slug: "penne", NameIT: "Penne", Visible: true})
+	if err != nil {
+		t.Fatalf("failed to insert category: %v", err)
+	}
+
+	tags := make(map[string]models.Tag)
+
+	for _, slug := range []string{"vegetarian", "spicy", "gluten-free"} {
+		id, err := services.DB.InsertTag(models.Tag{Slug: slug, Name: slug})
+		if err != nil {
+			t.Fatalf("failed to insert tag: %v", err)
+		}
+
+		tags[slug] = models.Tag{ID: id, Slug: slug}
+	}
+
+	items := []models.MenuItem{
+		{Name: "Margherita", CategoryID: pizzaID, Variants: []models.Variant{{Price: models.EUR(800)}}, Tags: []models.Tag{tags["vegetarian"]}},
+		{Name: "Diavola", CategoryID: pizzaID, Variants: []models.Variant{{Price: models.EUR(1000)}}, Tags: []models.Tag{tags["spicy"]}},
+		{Name: "Ortolana", CategoryID: pizzaID, Variants: []models.Variant{{Price: models.EUR(1000)}}, Tags: []models.Tag{tags["vegetarian"], tags["gluten-free"]}},
+		{Name: "Arrabbiata", CategoryID: pastaID, Variants: []models.Variant{{Price: models.EUR(900)}}, Tags: []models.Tag{tags["vegetarian"], tags["spicy"], tags["gluten-free"]}},
+	}
+
+	for _, item := range items {
+		if _, err := services.DB.InsertMenuItem(item, ""); err != nil {
+			t.Fatalf("failed to insert menu item: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"No filter", "/", []string{"Margherita", "Diavola", "Ortolana", "Arrabbiata"}},
+		{"Diet", "/?diet=vegetarian", []string{"Margherita", "Ortolana", "Arrabbiata"}},
+		{"Diet and exclusion", "/?diet=vegetarian&not=spicy", []string{"Margherita", "Ortolana"}},
+		{"Comma separated diets in a category", "/?category=pizza&diet=vegetarian,gluten-free", []string{"Ortolana"}},
+		{"Unknown tag is ignored", "/?diet=carnivore", []string{"Margherita", "Diavola", "Ortolana", "Arrabbiata"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, rr := CreateTestRequest(t, "GET", tt.query, nil)
+
+			http.HandlerFunc(Services.Home).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+
+			var want strings.Builder
+			for _, name := range tt.want {
+				want.WriteString("<li>" + name + "</li>")
+			}
+
+			if !strings.Contains(rr.Body.String(), "<ul>"+want.String()+"</ul>") {
+				t.Errorf("unexpected menu for %s: got %s, want %v", tt.query, rr.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestAppServices_HomeLanguage(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	pastaID, err := services.DB.InsertCategory(models.Category{Slug: "pasta", NameIT: "Pasta", NameDE: "Nudeln", Visible: true})
+	if err != nil {
+		t.Fatalf("failed to insert category: %v", err)
+	}
+
+	item := models.MenuItem{
+		Name:         "Carbonara",
+		Description:  "Sahnesoße mit Speck",
+		CategoryID:   pastaID,
+		Variants:     []models.Variant{{Price: models.EUR(1100)}},
+		Translations: map[string]models.Translation{"en": {"price": "11"}},
+	}
+
+	// A translation that cannot be saved leaves no half-saved item behind
+	if _, err := services.DB.InsertMenuItem(item, ""); err == nil {
+		t.Fatal("InsertMenuItem accepted a translation of an untranslatable field")
+	}
+
+	if items, err := services.DB.GetAllMenuItems(); err != nil || len(items) != 0 {
+		t.Fatalf("menu items after a failed insert = %v, %v; want none", items, err)
+	}
+
+	item.Translations = map[string]models.Translation{"en": {"description": "Creamy sauce with pancetta"}}
+
+	if _, err := services.DB.InsertMenuItem(item, ""); err != nil {
+		t.Fatalf("failed to insert menu item: %v", err)
+	}
+
+	// The English category name is set through the category form
+	form := url.Values{"name_it": {"Pasta"}, "name_de": {"Nudeln"}, "slug": {"pasta"}, "visible": {"1"}, "name.en": {"Noodles"}}
+	req, rr := CreateTestRequest(t, "POST", fmt.Sprintf("/admin/categories/update/%d", pastaID), strings.NewReader(form.Encode()))
+	http.HandlerFunc(Services.UpdateCategory).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("UpdateCategory returned wrong status code: got %v want %v", rr.Code, http.StatusSeeOther)
+	}
+
+	tests := []struct {
+		name           string
+		url            string
+		acceptLanguage string
+		cookie         string
+		wantLang       string
+		want           string
+	}{
+		{"default", "/", "", "", "de", "<dt>Pasta / Nudeln</dt><dd>Sahnesoße mit Speck</dd>"},
+		{"accept language", "/", "en-GB,en;q=0.9,de;q=0.5", "", "en", "<dt>Pasta / Noodles</dt><dd>Creamy sauce with pancetta</dd>"},
+		{"missing translation falls back", "/", "it-IT", "", "it", "<dt>Pasta</dt><dd>Sahnesoße mit Speck</dd>"},
+		{"cookie beats header", "/", "en", "de", "de", "<dt>Pasta / Nudeln</dt>"},
+		{"query beats cookie", "/?lang=en", "de", "it", "en", "<dd>Creamy sauce with pancetta</dd>"},
+		{"unknown language", "/?lang=fr", "fr", "", "de", "<dd>Sahnesoße mit Speck</dd>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, rr := CreateTestRequest(t, "GET", tt.url, nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "lang", Value: tt.cookie})
+			}
+
+			http.HandlerFunc(Services.Home).ServeHTTP(rr, req)
+
+			body := rr.Body.String()
+			if !strings.Contains(body, `<html lang="`+tt.wantLang+`">`) || !strings.Contains(body, tt.want) {
+				t.Errorf("unexpected page in %s: got %s, want %s", tt.wantLang, body, tt.want)
+			}
+
+			// UI text from the message catalogs follows the same language
+			if title := "<h2>" + i18n.T(tt.wantLang, "menu.title") + "</h2>"; !strings.Contains(body, title) {
+				t.Errorf("page in %s does not contain %s", tt.wantLang, title)
+			}
+
+			if got := rr.Header().Get("Content-Language"); got != tt.wantLang {
+				t.Errorf("Content-Language = %q, want %q", got, tt.wantLang)
+			}
+
+			// Only an explicit choice is remembered
+			setCookie := rr.Header().Get("Set-Cookie")
+			if strings.Contains(tt.url, "lang=en") != strings.HasPrefix(setCookie, "lang=en;") {
+				t.Errorf("Set-Cookie = %q", setCookie)
+			}
+		})
+	}
+}
+
+func TestAppServices_MoveCategory(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	for _, name := range []string{"Antipasti", "Pizza", "Carne"} {
+		if _, err := services.DB.InsertCategory(models.Category{Slug: models.Slugify(name), NameIT: name, Visible: true}); err != nil {
+			t.Fatalf("failed to insert category: %v", err)
+		}
+	}
+
+	// A plain GET, e.g. a prefetched link, changes nothing
+	req, rr := CreateTestRequest(t, "GET", "/admin/categories/move/1?direction=down", nil)
+	http.HandlerFunc(Services.MoveCategory).ServeHTTP(rr, req)
+
+	req, rr = CreateTestRequest(t, "GET", "/admin/categories/toggle/1", nil)
+	http.HandlerFunc(Services.ToggleCategoryVisibility).ServeHTTP(rr, req)
+
+	req, rr = CreateTestRequest(t, "GET", "/admin/categories/delete/1", nil)
+	http.HandlerFunc(Services.DeleteCategory).ServeHTTP(rr, req)
+
+	if antipasti, err := services.DB.GetCategoryByID(1); err != nil || !antipasti.Visible {
+		t.Fatalf("GET requests changed the category: %+v (%v)", antipasti, err)
+	}
+
+	req, rr = CreateTestRequest(t, "POST", "/admin/categories/move/3", strings.NewReader("direction=up"))
+
+	http.HandlerFunc(Services.MoveCategory).ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusSeeOther {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusSeeOther)
+	}
+
+	categories, err := services.DB.GetAllCategories()
+	if err != nil {
+		t.Fatalf("failed to fetch categories: %v", err)
+	}
+
+	var got []string
+	for _, c := range categories {
+		got = append(got, c.NameIT)
+	}
+
+	if want := "Antipasti,Carne,Pizza"; strings.Join(got, ",") != want {
+		t.Errorf("categories in wrong order: got %v want %v", strings.Join(got, ","), want)
+	}
+}
+
+func TestAppServices_CategorySlugTaken(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	pizzaID, err := services.DB.InsertCategory(models.Category{Slug: "pizza", NameIT: "Pizza", Visible: true})
+	if err != nil {
+		t.Fatalf("failed to insert category: %v", err)
+	}
+
+	pastaID, err := services.DB.InsertCategory(models.Category{Slug: "pasta", NameIT: "Pasta", Visible: true})
+	if err != nil {
+		t.Fatalf("failed to insert category: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		url     string
+		form    url.Values
+		wantErr bool
+	}{
+		{"create with a taken slug", Services.CreateCategory, "/admin/categories/create", url.Values{"name_it": {"Pizza!"}}, true},
+		{"rename to a taken slug", Services.UpdateCategory, fmt.Sprintf("/admin/categories/update/%d", pastaID), url.Values{"name_it": {"Pasta"}, "slug": {"pizza"}}, true},
+		{"keep own slug", Services.UpdateCategory, fmt.Sprintf("/admin/categories/update/%d", pizzaID), url.Values{"name_it": {"Pizza"}, "slug": {"pizza"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, rr := CreateTestRequest(t, "POST", tt.url, strings.NewReader(tt.form.Encode()))
+			tt.handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusSeeOther {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusSeeOther)
+			}
+
+			location := rr.Header().Get("Location")
+			if strings.Contains(location, "error=") != tt.wantErr {
+				t.Errorf("redirected to %q, want an error: %v", location, tt.wantErr)
+			}
+		})
+	}
+
+	categories, err := services.DB.GetAllCategories()
+	if err != nil || len(categories) != 2 || categories[1].Slug != "pasta" {
+		t.Errorf("categories changed by rejected forms: %+v (%v)", categories, err)
+	}
+}
+
+func TestAppServices_ReorderMenuItems(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	categoryID, err := services.DB.InsertCategory(models.Category{Slug: "pizza", NameIT: "Pizza", Visible: true})
+	if err != nil {
+		t.Fatalf("failed to insert category: %v", err)
+	}
+
+	var ids []int
+
+	for _, name := range []string{"Margherita", "Funghi", "Diavola"} {
+		id, err := services.DB.InsertMenuItem(models.MenuItem{Name: name, CategoryID: categoryID, Variants: []models.Variant{{Price: models.EUR(900)}}}, "")
+		if err != nil {
+			t.Fatalf("failed to insert menu item: %v", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"Incomplete ordering", fmt.Sprintf(`{"category_id": %d, "item_ids": [%d, %d]}`, categoryID, ids[2], ids[0]), http.StatusBadRequest},
+		{"Duplicate item", fmt.Sprintf(`{"category_id": %d, "item_ids": [%d, %d, %d]}`, categoryID, ids[2], ids[2], ids[0]), http.StatusBadRequest},
+		{"Valid ordering", fmt.Sprintf(`{"category_id": %d, "item_ids": [%d, %d, %d]}`, categoryID, ids[2], ids[0], ids[1]), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, rr := CreateTestRequest(t, "POST", "/admin/menu/reorder", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			http.HandlerFunc(Services.ReorderMenuItems).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v (%s)", status, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+
+	items, err := services.DB.GetAllMenuItems()
+	if err != nil {
+		t.Fatalf("failed to fetch menu items: %v", err)
+	}
+
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Name)
+	}
+
+	if want := "Diavola,Margherita,Funghi"; strings.Join(got, ",") != want {
+		t.Errorf("menu items in wrong order: got %v want %v", strings.Join(got, ","), want)
+	}
+}
+
+func TestVariantsFromForm(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []models.Variant
+		wantErr bool
+	}{
+		{
+			name: "Single unlabelled price",
+			body: "variant_key=n0&variant_id=&variant_label=&variant_price=8.50&variant_default=n0",
+			want: []models.Variant{{Price: models.EUR(850), IsDefault: true}},
+		},
+		{
+			name: "Sizes with existing and new rows",
+			body: "variant_key=v3&variant_id=3&variant_label=klein&variant_price=6" +
+				"&variant_key=n0&variant_id=&variant_label=Familie&variant_price=19.9&variant_default=n0",
+			want: []models.Variant{{ID: 3, Label: "klein", Price: models.EUR(600)}, {Label: "Familie", Price: models.EUR(1990), IsDefault: true}},
+		},
+		{
+			name: "Empty rows are skipped",
+			body: "variant_key=n0&variant_id=&variant_label=&variant_price=&variant_key=n1&variant_id=&variant_label=&variant_price=7",
+			want: []models.Variant{{Price: models.EUR(700)}},
+		},
+		{
+			name:    "No price",
+			body:    "variant_key=n0&variant_id=&variant_label=&variant_price=",
+			wantErr: true,
+		},
+		{
+			name:    "Several sizes need labels",
+			body:    "variant_key=n0&variant_id=&variant_label=klein&variant_price=6&variant_key=n1&variant_id=&variant_label=&variant_price=9",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid price",
+			body:    "variant_key=n0&variant_id=&variant_label=&variant_price=abc",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := CreateTestRequest(t, "POST", "/admin/menu/create", strings.NewReader(tt.body))
+
+			if err := req.ParseForm(); err != nil {
+				t.Fatalf("could not parse form: %v", err)
+			}
+
+			got, err := variantsFromForm(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("variantsFromForm() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("variantsFromForm() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateMenuItem_KeepsVariantIDs(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	id, err := services.DB.InsertMenuItem(models.MenuItem{
+		Name:     "Margherita",
+		Variants: []models.Variant{{Label: "klein", Price: models.EUR(600)}, {Label: "normal", Price: models.EUR(800), IsDefault: true}},
+	}, "")
+	if err != nil {
+		t.Fatalf("failed to insert menu item: %v", err)
+	}
+
+	item, err := services.DB.GetMenuItemByID(id)
+	if err != nil {
+		t.Fatalf("failed to fetch menu item: %v", err)
+	}
+
+	normal := item.Variants[1]
+	normal.Price = models.EUR(850)
+
+	// Drop "klein", keep "normal" and add a family size
+	item.Variants = []models.Variant{normal, {Label: "Familie", Price: models.EUR(2000)}}
+
+	if err := services.DB.UpdateMenuItem(item, "admin@example.com"); err != nil {
+		t.Fatalf("failed to update menu item: %v", err)
+	}
+
+	item, err = services.DB.GetMenuItemByID(id)
+	if err != nil {
+		t.Fatalf("failed to fetch menu item: %v", err)
+	}
+
+	if len(item.Variants) != 2 {
+		t.Fatalf("got %d variants, want 2", len(item.Variants))
+	}
+
+	if item.Variants[0].ID != normal.ID || item.Variants[0].Price != models.EUR(850) || !item.Variants[0].IsDefault {
+		t.Errorf("existing variant not updated in place: %+v", item.Variants[0])
+	}
+
+	if item.Variants[1].Label != "Familie" || item.Variants[1].IsDefault {
+		t.Errorf("new variant not appended: %+v", item.Variants[1])
+	}
+}
+
+func TestGetAllMenuItems_Extras(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	pizzaID, err := services.DB.InsertCategory(models.Category{Slug: "pizza", NameIT: "Pizza", Visible: true})
+	if err != nil {
+		t.Fatalf("failed to insert category: %v", err)
+	}
+
+	cheeseID, err := services.DB.InsertExtra(models.Extra{Name: "extra Käse", Price: models.EUR(150), CategoryIDs: []int{pizzaID}})
+	if err != nil {
+		t.Fatalf("failed to insert extra: %v", err)
+	}
+
+	eggID, err := services.DB.InsertExtra(models.Extra{Name: "Ei", Price: models.EUR(100)})
+	if err != nil {
+		t.Fatalf("failed to insert extra: %v", err)
+	}
+
+	// The cheese is also assigned directly and must not show up twice
+	_, err = services.DB.InsertMenuItem(models.MenuItem{
+		Name:       "Bismarck",
+		CategoryID: pizzaID,
+		Variants:   []models.Variant{{Price: models.EUR(1100)}},
+		Extras:     []models.Extra{{ID: eggID}, {ID: cheeseID}},
+	}, "")
+	if err != nil {
+		t.Fatalf("failed to insert menu item: %v", err)
+	}
+
+	_, err = services.DB.InsertMenuItem(models.MenuItem{Name: "Margherita", CategoryID: pizzaID, Variants: []models.Variant{{Price: models.EUR(800)}}}, "")
+	if err != nil {
+		t.Fatalf("failed to insert menu item: %v", err)
+	}
+
+	items, err := services.DB.GetAllMenuItems()
+	if err != nil {
+		t.Fatalf("failed to fetch menu items: %v", err)
+	}
+
+	got := make(map[string]string)
+
+	for _, item := range items {
+		var names []string
+		for _, e := range item.Extras {
+			names = append(names, e.Name)
+		}
+
+		got[item.Name] = strings.Join(names, ",")
+	}
+
+	if want := "extra Käse,Ei"; got["Bismarck"] != want {
+		t.Errorf("Bismarck extras = %q, want %q", got["Bismarck"], want)
+	}
+
+	if want := "extra Käse"; got["Margherita"] != want {
+		t.Errorf("Margherita extras = %q, want %q", got["Margherita"], want)
+	}
+}
+
+func TestAppServices_ToggleMenuItemAvailability(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	id, err := services.DB.InsertMenuItem(models.MenuItem{
+		Name:     "Lasagne",
+		Variants: []models.Variant{{Price: models.EUR(1150)}},
+		Windows:  []models.AvailabilityWindow{{Weekday: time.Sunday, Start: "11:30", End: "14:00"}},
+	}, "")
+	if err != nil {
+		t.Fatalf("failed to insert menu item: %v", err)
+	}
+
+	path := "/admin/menu/availability/" + strconv.Itoa(id)
+
+	// The first toggle sells the item out for today, the second one brings it back
+	for _, wantSoldOut := range []bool{true, false} {
+		req, rr := CreateTestRequest(t, "POST", path, nil)
+
+		http.HandlerFunc(Services.ToggleMenuItemAvailability).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusSeeOther {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusSeeOther)
+		}
+
+		item, err := services.DB.GetMenuItemByID(id)
+		if err != nil {
+			t.Fatalf("failed to fetch menu item: %v", err)
+		}
+
+		if got := item.IsSoldOutAt(time.Now()); got != wantSoldOut {
+			t.Errorf("IsSoldOutAt() = %v, want %v", got, wantSoldOut)
+		}
+
+		if item.IsSoldOutAt(time.Now().AddDate(0, 0, 1)) {
+			t.Errorf("item still sold out tomorrow")
+		}
+
+		if len(item.Windows) != 1 || item.Windows[0].String() != "So 11:30–14:00" {
+			t.Errorf("windows not kept: %+v", item.Windows)
+		}
+	}
+
+	// Both toggles are in the history, newest first
+	revisions, err := services.DB.GetMenuItemRevisions(id)
+	if err != nil {
+		t.Fatalf("failed to fetch revisions: %v", err)
+	}
+
+	if len(revisions) != 3 || len(revisions[0].Changes) != 1 || revisions[0].Changes[0].New != "available" ||
+		len(revisions[1].Changes) != 1 || !strings.HasPrefix(revisions[1].Changes[0].New, "sold out until ") {
+		t.Errorf("expected revisions for both toggles, got %+v", revisions)
+	}
+}
+
+func TestAppServices_Trash(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	categoryID, err := services.DB.InsertCategory(models.Category{Slug: "al-forno", NameIT: "Al forno", NameDE: "Aus dem Ofen"})
+	if err != nil {
+		t.Fatalf("failed to insert category: %v", err)
+	}
+
+	tagID, err := services.DB.InsertTag(models.Tag{Slug: "hausgemacht", Name: "Hausgemacht"})
+	if err != nil {
+		t.Fatalf("failed to insert tag: %v", err)
+	}
+
+	id, err := services.DB.InsertMenuItem(models.MenuItem{
+		Name:       "Lasagne",
+		CategoryID: categoryID,
+		Variants:   []models.Variant{{Price: models.EUR(1150)}},
+		Tags:       []models.Tag{{ID: tagID}},
+	}, "")
+	if err != nil {
+		t.Fatalf("failed to insert menu item: %v", err)
+	}
+
+	post := func(handler http.HandlerFunc, path string) {
+		t.Helper()
+
+		req, rr := CreateTestRequest(t, "POST", path, nil)
+		handler.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusSeeOther {
+			t.Fatalf("%s returned wrong status code: got %v want %v", path, status, http.StatusSeeOther)
+		}
+	}
+
+	// Deleting only moves the item to the trash
+	post(Services.DeleteMenuItem, "/admin/menu/delete/"+strconv.Itoa(id))
+
+	if _, err := services.DB.GetMenuItemByID(id); err == nil {
+		t.Errorf("deleted item is still returned by GetMenuItemByID")
+	}
+
+	req, rr := CreateTestRequest(t, "GET", "/admin/trash", nil)
+	http.HandlerFunc(Services.AdminTrash).ServeHTTP(rr, req)
+
+	if !strings.Contains(rr.Body.String(), "<li>Lasagne</li>") {
+		t.Errorf("trash does not list the deleted item: %s", rr.Body.String())
+	}
+
+	post(Services.RestoreMenuItem, "/admin/trash/restore/"+strconv.Itoa(id))
+
+	item, err := services.DB.GetMenuItemByID(id)
+	if err != nil {
+		t.Fatalf("restored item not found: %v", err)
+	}
+
+	if len(item.Variants) != 1 || item.Variants[0].Price != models.EUR(1150) {
+		t.Errorf("restored item lost its variants: %+v", item.Variants)
+	}
+
+	// Purging is only possible from the trash
+	req, rr = CreateTestRequest(t, "POST", "/admin/trash/purge/"+strconv.Itoa(id), nil)
+	http.HandlerFunc(Services.PurgeMenuItem).ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("purging a live item returned %v, want %v", status, http.StatusNotFound)
+	}
+
+	post(Services.DeleteMenuItem, "/admin/menu/delete/"+strconv.Itoa(id))
+
+	// The trashed item keeps its category until it is purged
+	if err := services.DB.DeleteCategory(categoryID); !errors.Is(err, models.ErrCategoryInTrash) {
+		t.Errorf("DeleteCategory() with a trashed item error = %v, want %v", err, models.ErrCategoryInTrash)
+	}
+
+	post(Services.PurgeMenuItem, "/admin/trash/purge/"+strconv.Itoa(id))
+
+	trashed, err := services.DB.GetTrashedMenuItems()
+	if err != nil {
+		t.Fatalf("failed to fetch trash: %v", err)
+	}
+
+	if len(trashed) != 0 {
+		t.Errorf("purged item still in the trash: %+v", trashed)
+	}
+
+	var links int
+	if err := services.DB.DB.QueryRow(`SELECT COUNT(*) FROM menu_item_tags WHERE menu_item_id = ?`, id).Scan(&links); err != nil || links != 0 {
+		t.Errorf("purged item still has %d tag link(s) (%v)", links, err)
+	}
+
+	if err := services.DB.DeleteCategory(categoryID); err != nil {
+		t.Errorf("DeleteCategory() after purging error = %v", err)
+	}
+}
+
+func TestAppServices_RevertMenuItem(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	tagID, err := services.DB.InsertTag(models.Tag{Slug: "vegan", Name: "Vegan"})
+	if err != nil {
+		t.Fatalf("failed to insert tag: %v", err)
+	}
+
+	id, err := services.DB.InsertMenuItem(models.MenuItem{
+		Name:     "Marinara",
+		Variants: []models.Variant{{Price: models.EUR(700)}},
+		Tags:     []models.Tag{{ID: tagID, Name: "Vegan"}},
+	}, "anna@example.com")
+	if err != nil {
+		t.Fatalf("failed to insert menu item: %v", err)
+	}
+
+	item, err := services.DB.GetMenuItemByID(id)
+	if err != nil {
+		t.Fatalf("failed to fetch menu item: %v", err)
+	}
+
+	item.Name = "Marinara speciale"
+	item.Variants[0].Price = models.EUR(850)
+
+	if err := services.DB.UpdateMenuItem(item, "marco@example.com"); err != nil {
+		t.Fatalf("failed to update menu item: %v", err)
+	}
+
+	// The tag is deleted after the first version, so restoring it must not bring the link back
+	if err := services.DB.DeleteTag(tagID); err != nil {
+		t.Fatalf("failed to delete tag: %v", err)
+	}
+
+	req, rr := CreateTestRequest(t, "GET", "/admin/menu/history/"+strconv.Itoa(id), nil)
+	http.HandlerFunc(Services.MenuItemHistory).ServeHTTP(rr, req)
+
+	if body := rr.Body.String(); !strings.Contains(body, "<li>update by marco@example.com: Name Prices</li>") {
+		t.Errorf("history does not show the edit: %s", body)
+	}
+
+	revisions, err := services.DB.GetMenuItemRevisions(id)
+	if err != nil || len(revisions) != 2 {
+		t.Fatalf("got %d revisions (%v), want 2", len(revisions), err)
+	}
+
+	req, rr = CreateTestRequest(t, "POST", "/admin/menu/revert/"+strconv.Itoa(revisions[1].ID), nil)
+	http.HandlerFunc(Services.RevertMenuItem).ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusSeeOther {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusSeeOther)
+	}
+
+	item, err = services.DB.GetMenuItemByID(id)
+	if err != nil {
+		t.Fatalf("failed to fetch menu item: %v", err)
+	}
+
+	if item.Name != "Marinara" || item.DefaultVariant().Price != models.EUR(700) || len(item.Tags) != 0 {
+		t.Errorf("item not rolled back: %+v", item)
+	}
+
+	revisions, err = services.DB.GetMenuItemRevisions(id)
+	if err != nil || len(revisions) != 3 || revisions[0].Action != models.RevisionRevert {
+		t.Errorf("rollback not recorded as a revision: %+v (%v)", revisions, err)
+	}
+}
+
+func TestAppServices_SchedulePrices(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	id, err := services.DB.InsertMenuItem(models.MenuItem{Name: "Margherita", Variants: []models.Variant{{Price: models.EUR(800)}}}, "")
+	if err != nil {
+		t.Fatalf("failed to insert menu item: %v", err)
+	}
+
+	item, err := services.DB.GetMenuItemByID(id)
+	if err != nil {
+		t.Fatalf("failed to fetch menu item: %v", err)
+	}
+
+	variantID := item.Variants[0].ID
+	today := time.Now()
+	tomorrow := today.AddDate(0, 0, 1)
+
+	// schedule posts a new price and returns where the handler redirects to
+	schedule := func(day time.Time, price string) string {
+		t.Helper()
+
+		body := fmt.Sprintf("effective_from=%s&variant_id=%d&price=%s", day.Format(time.DateOnly), variantID, price)
+		req, rr := CreateTestRequest(t, "POST", "/admin/prices/schedule", strings.NewReader(body))
+		http.HandlerFunc(Services.SchedulePrices).ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusSeeOther {
+			t.Fatalf("SchedulePrices returned wrong status code: got %v want %v", status, http.StatusSeeOther)
+		}
+
+		return rr.Header().Get("Location")
+	}
+
+	// Prices can only be scheduled for the days to come
+	if location := schedule(today, "9,00"); !strings.Contains(location, "error=") {
+		t.Errorf("scheduling for today was accepted: %s", location)
+	}
+
+	schedule(tomorrow, "9,50")
+
+	price := func(at time.Time) models.Money {
+		t.Helper()
+
+		items, err := services.DB.GetAllMenuItemsAt(at)
+		if err != nil {
+			t.Fatalf("failed to fetch menu items: %v", err)
+		}
+
+		return items[0].Variants[0].Price
+	}
+
+	if got := price(today); got != models.EUR(800) {
+		t.Errorf("price today = %v, want %v", got, models.EUR(800))
+	}
+
+	if got := price(tomorrow); got != models.EUR(950) {
+		t.Errorf("price tomorrow = %v, want %v", got, models.EUR(950))
+	}
+
+	// Editing the item today keeps the change scheduled for tomorrow
+	item.Variants[0].Price = models.EUR(850)
+
+	err = services.DB.UpdateMenuItem(item, "")
+	if err != nil {
+		t.Fatalf("failed to update menu item: %v", err)
+	}
+
+	if got := price(today); got != models.EUR(850) {
+		t.Errorf("price today after edit = %v, want %v", got, models.EUR(850))
+	}
+
+	if got := price(tomorrow); got != models.EUR(950) {
+		t.Errorf("price tomorrow after edit = %v, want %v", got, models.EUR(950))
+	}
+
+	req, rr := CreateTestRequest(t, "GET", "/admin/prices", nil)
+	http.HandlerFunc(Services.AdminPrices).ServeHTTP(rr, req)
+
+	if !strings.Contains(rr.Body.String(), "<li>Margherita 9,50 €</li>") {
+		t.Errorf("prices page does not list the scheduled price: %s", rr.Body.String())
+	}
+}
+
+func TestAppServices_AdjustPrices(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	pizzaID, err := services.DB.InsertCategory(models.Category{Slug: "pizza", NameIT: "Pizza", Visible: true})
+	if err != nil {
+		t.Fatalf("failed to insert category: %v", err)
+	}
+
+	pizzaItemID, err := services.DB.InsertMenuItem(models.MenuItem{
+		Name:       "Margherita",
+		CategoryID: pizzaID,
+		Variants:   []models.Variant{{Label: "klein", Price: models.EUR(650)}, {Label: "normal", Price: models.EUR(800), IsDefault: true}},
+	}, "")
+	if err != nil {
+		t.Fatalf("failed to insert menu item: %v", err)
+	}
+
+	saladID, err := services.DB.InsertMenuItem(models.MenuItem{Name: "Insalata", Variants: []models.Variant{{Price: models.EUR(700)}}}, "")
+	if err != nil {
+		t.Fatalf("failed to insert menu item: %v", err)
+	}
+
+	form := fmt.Sprintf("scope=category&category_id=%d&mode=percent&value=5&rounding=.50", pizzaID)
+
+	// The preview shows the new prices without saving them
+	req, rr := CreateTestRequest(t, "GET", "/admin/prices/adjust?"+form, nil)
+	http.HandlerFunc(Services.AdjustPrices).ServeHTTP(rr, req)
+
+	if body := rr.Body.String(); !strings.Contains(body, "<li>Margherita 6,50 € 7,00 €</li><li>Margherita 8,00 € 8,50 €</li>") {
+		t.Errorf("preview does not list the price changes: %s", body)
+	}
+
+	prices := func(id int) []models.Money {
+		t.Helper()
+
+		item, err := services.DB.GetMenuItemByID(id)
+		if err != nil {
+			t.Fatalf("failed to fetch menu item: %v", err)
+		}
+
+		var prices []models.Money
+		for _, v := range item.Variants {
+			prices = append(prices, v.Price)
+		}
+
+		return prices
+	}
+
+	if got := prices(pizzaItemID); got[0] != models.EUR(650) || got[1] != models.EUR(800) {
+		t.Errorf("preview changed the prices: %v", got)
+	}
+
+	req, rr = CreateTestRequest(t, "POST", "/admin/prices/adjust", strings.NewReader(form))
+	http.HandlerFunc(Services.AdjustPrices).ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusSeeOther {
+		t.Fatalf("AdjustPrices returned wrong status code: got %v want %v", status, http.StatusSeeOther)
+	}
+
+	if got := prices(pizzaItemID); got[0] != models.EUR(700) || got[1] != models.EUR(850) {
+		t.Errorf("adjusted prices = %v, want [7,00 € 8,50 €]", got)
+	}
+
+	// Items outside the selection keep their price
+	if got := prices(saladID); got[0] != models.EUR(700) {
+		t.Errorf("unselected item price = %v, want 7,00 €", got[0])
+	}
+
+	revisions, err := services.DB.GetMenuItemRevisions(pizzaItemID)
+	if err != nil {
+		t.Fatalf("failed to fetch revisions: %v", err)
+	}
+
+	if len(revisions) != 2 || len(revisions[0].Changes) != 1 || revisions[0].Changes[0].Field != "Prices" {
+		t.Errorf("adjustment did not record a price revision: %+v", revisions)
+	}
+}
+
+func TestAppServices_ImportMenu(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	pizzaID, err := services.DB.InsertCategory(models.Category{Slug: "pizza", NameIT: "Pizza", Visible: true})
+	if err != nil {
+		t.Fatalf("failed to insert category: %v", err)
+	}
+
+	margheritaID, err := services.DB.InsertMenuItem(models.MenuItem{
+		Name:       "Margherita",
+		CategoryID: pizzaID,
+		Variants:   []models.Variant{{Label: "klein", Price: models.EUR(650)}, {Label: "normal", Price: models.EUR(800), IsDefault: true}},
+	}, "")
+	if err != nil {
+		t.Fatalf("failed to insert menu item: %v", err)
+	}
+
+	salamiID, err := services.DB.InsertMenuItem(models.MenuItem{Name: "Salami", CategoryID: pizzaID, Variants: []models.Variant{{Price: models.EUR(900)}}}, "")
+	if err != nil {
+		t.Fatalf("failed to insert menu item: %v", err)
+	}
+
+	before, err := services.DB.GetMenuItemByID(margheritaID)
+	if err != nil {
+		t.Fatalf("failed to fetch menu item: %v", err)
+	}
+
+	// Margherita is matched by name and gets a new price, Salami is missing and Funghi is new
+	content := "name,category,prices\n" +
+		"margherita,pizza,\"klein 6,50; normal 8,50*\"\n" +
+		"Funghi,pizza,\"9,00\"\n"
+
+	form := url.Values{"format": {"csv"}, "content": {content}}
+
+	req, rr := CreateTestRequest(t, "POST", "/admin/menu/import", strings.NewReader(form.Encode()))
+	http.HandlerFunc(Services.ImportMenu).ServeHTTP(rr, req)
+
+	if body := rr.Body.String(); !strings.Contains(body, "<p>1 new, 1 changed, 1 removed</p>") {
+		t.Errorf("dry run does not show the changes: %s", body)
+	}
+
+	if items, _ := services.DB.GetAllMenuItems(); len(items) != 2 {
+		t.Fatalf("dry run changed the menu: %d items", len(items))
+	}
+
+	form.Set("confirm", "1")
+
+	req, rr = CreateTestRequest(t, "POST", "/admin/menu/import", strings.NewReader(form.Encode()))
+	http.HandlerFunc(Services.ImportMenu).ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusSeeOther {
+		t.Fatalf("ImportMenu returned wrong status code: got %v want %v: %s", status, http.StatusSeeOther, rr.Body.String())
+	}
+
+	after, err := services.DB.GetMenuItemByID(margheritaID)
+	if err != nil {
+		t.Fatalf("imported item not found: %v", err)
+	}
+
+	// Sizes with the same label keep their variant IDs
+	if after.Variants[0].ID != before.Variants[0].ID || after.Variants[1].Price != models.EUR(850) {
+		t.Errorf("variants after import = %+v, before %+v", after.Variants, before.Variants)
+	}
+
+	if _, err := services.DB.GetMenuItemByID(salamiID); err == nil {
+		t.Errorf("item missing from the import is still on the menu")
+	}
+
+	items, err := services.DB.GetAllMenuItems()
+	if err != nil {
+		t.Fatalf("failed to fetch menu items: %v", err)
+	}
+
+	if len(items) != 2 || items[1].Name != "Funghi" {
+		t.Errorf("menu after import = %+v", items)
+	}
+}
+
+func TestAppServices_MenuPDF(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	pizzaID, err := services.DB.InsertCategory(models.Category{Slug: "pizza", NameIT: "Pizza", Visible: true})
+	if err != nil {
+		t.Fatalf("failed to insert category: %v", err)
+	}
+
+	_, err = services.DB.InsertMenuItem(models.MenuItem{Name: "Margherita", CategoryID: pizzaID, Variants: []models.Variant{{Price: models.EUR(800)}}}, "")
+	if err != nil {
+		t.Fatalf("failed to insert menu item: %v", err)
+	}
+
+	tests := []struct {
+		url        string
+		wantStatus int
+	}{
+		{url: "/menu.pdf", wantStatus: http.StatusOK},
+		{url: "/menu.pdf?format=a5", wantStatus: http.StatusOK},
+		{url: "/menu.pdf?format=a3", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			req, rr := CreateTestRequest(t, "GET", tt.url, nil)
+			http.HandlerFunc(Services.MenuPDF).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.wantStatus {
+				t.Fatalf("MenuPDF returned wrong status code: got %v want %v", status, tt.wantStatus)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			if ct := rr.Header().Get("Content-Type"); ct != "application/pdf" {
+				t.Errorf("Content-Type = %q, want application/pdf", ct)
+			}
+
+			if !strings.HasPrefix(rr.Body.String(), "%PDF-") {
+				t.Errorf("response is not a PDF document")
+			}
+		})
+	}
+}
+
+func TestAppServices_API(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	pizzaID, err := services.DB.InsertCategory(models.Category{Slug: "pizza", NameIT: "Pizza", Visible: true})
+	if err != nil {
+		t.Fatalf("failed to insert category: %v", err)
+	}
+
+	margheritaID, err := services.DB.InsertMenuItem(models.MenuItem{
+		Name:       "Margherita",
+		CategoryID: pizzaID,
+		Variants:   []models.Variant{{Label: "klein", Price: models.EUR(650)}, {Label: "normal", Price: models.EUR(800), IsDefault: true}},
+	}, "")
+	if err != nil {
+		t.Fatalf("failed to insert menu item: %v", err)
+	}
+
+	hiddenID, err := services.DB.InsertMenuItem(models.MenuItem{
+		Name:         "Secret",
+		CategoryID:   pizzaID,
+		Variants:     []models.Variant{{Price: models.EUR(900)}},
+		Availability: models.AvailabilityHidden,
+	}, "")
+	if err != nil {
+		t.Fatalf("failed to insert menu item: %v", err)
+	}
+
+	get := func(url, etag string) *httptest.ResponseRecorder {
+		req, rr := CreateTestRequest(t, "GET", url, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+
+		switch {
+		case url == "/api/v1/menu":
+			Services.APIMenu(rr, req)
+		case strings.HasPrefix(url, "/api/v1/menu/"):
+			Services.APIMenuItem(rr, req)
+		default:
+			Services.APICategories(rr, req)
+		}
+
+		return rr
+	}
+
+	rr := get("/api/v1/menu", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("APIMenu returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	body := rr.Body.String()
+
+	// Hidden items are left out; field names are the documented snake_case names
+	want := fmt.Sprintf(`"id":%d,"name":"Margherita"`, margheritaID)
+	if !strings.Contains(body, want) || strings.Contains(body, "Secret") {
+		t.Errorf("APIMenu body = %s", body)
+	}
+
+	if !strings.Contains(body, `"label":"normal","price":{"amount":800,"currency":"EUR","formatted":"8,00 €"},"default":true`) {
+		t.Errorf("APIMenu prices not in the documented format: %s", body)
+	}
+
+	// The ETag of the response answers a conditional request with 304
+	etag := rr.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("APIMenu sent no ETag")
+	}
+
+	if rr = get("/api/v1/menu", `"other", W/`+etag); rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("conditional request returned %v with %d bytes, want 304 without body", rr.Code, rr.Body.Len())
+	}
+
+	if rr = get(fmt.Sprintf("/api/v1/menu/%d", margheritaID), ""); rr.Code != http.StatusOK {
+		t.Errorf("APIMenuItem returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	for _, url := range []string{fmt.Sprintf("/api/v1/menu/%d", hiddenID), "/api/v1/menu/x"} {
+		if rr = get(url, ""); rr.Code != http.StatusNotFound || !strings.Contains(rr.Body.String(), `"error":"menu item not found"`) {
+			t.Errorf("GET %s returned %v: %s", url, rr.Code, rr.Body.String())
+		}
+	}
+
+	if rr = get("/api/v1/categories", ""); !strings.Contains(rr.Body.String(), `{"categories":[{"id":`) {
+		t.Errorf("APICategories body = %s", rr.Body.String())
+	}
+}
+
+func TestAppServices_AdminAPI(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	_, err := services.DB.InsertCategory(models.Category{Slug: "pizza", NameIT: "Pizza", Visible: true})
+	if err != nil {
+		t.Fatalf("failed to insert category: %v", err)
+	}
+
+	token, secret, err := services.DB.CreateAPIToken("Delivery app", "admin@example.com")
+	if err != nil {
+		t.Fatalf("failed to create API token: %v", err)
+	}
+
+	call := func(method, url, secret, body string) *httptest.ResponseRecorder {
+		req, rr := CreateTestRequest(t, method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		if secret != "" {
+			req.Header.Set("Authorization", "Bearer "+secret)
+		}
+
+		Services.APITokenAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case url == "/api/v1/admin/menu-items":
+				Services.AdminAPIMenuItems(w, r)
+			case strings.HasPrefix(url, "/api/v1/admin/menu-items/"):
+				Services.AdminAPIMenuItem(w, r)
+			default:
+				Services.AdminAPIFlashMessages(w, r)
+			}
+		})(rr, req)
+
+		return rr
+	}
+
+	if rr := call("GET", "/api/v1/admin/menu-items", "", ""); rr.Code != http.StatusUnauthorized || rr.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("request without token returned %v, want %v", rr.Code, http.StatusUnauthorized)
+	}
+
+	if rr := call("GET", "/api/v1/admin/menu-items", "pzt_wrong", ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("request with unknown token returned %v, want %v", rr.Code, http.StatusUnauthorized)
+	}
+
+	// Invalid items are rejected with every problem listed
+	rr := call("POST", "/api/v1/admin/menu-items", secret, `{"name":"Diavola","category":"pasta","prices":[]}`)
+	if rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), `"details":[`) {
+		t.Errorf("invalid item returned %v: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = call("POST", "/api/v1/admin/menu-items", secret,
+		`{"name":"Diavola","category":"pizza","prices":[{"label":"","price":{"amount":950,"currency":"EUR"}}]}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("AdminAPIMenuItems returned wrong status code: got %v want %v: %s", rr.Code, http.StatusCreated, rr.Body.String())
+	}
+
+	items, err := services.DB.GetAllMenuItems()
+	if err != nil || len(items) != 1 || items[0].Variants[0].Price.Cents != 950 {
+		t.Fatalf("menu item not created: %v %+v", err, items)
+	}
+
+	id := items[0].ID
+	if rr.Header().Get("Location") != fmt.Sprintf("/api/v1/admin/menu-items/%d", id) {
+		t.Errorf("Location = %q", rr.Header().Get("Location"))
+	}
+
+	url := fmt.Sprintf("/api/v1/admin/menu-items/%d", id)
+
+	rr = call("PUT", url, secret, `{"name":"Diavola piccante","category":"pizza","prices":[{"label":"","price":{"amount":1050}}]}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"name":"Diavola piccante"`) {
+		t.Errorf("AdminAPIMenuItem PUT returned %v: %s", rr.Code, rr.Body.String())
+	}
+
+	// Changes made with a token are recorded in the name of its owner
+	revisions, err := services.DB.GetMenuItemRevisions(id)
+	if err != nil || len(revisions) != 2 {
+		t.Fatalf("expected 2 revisions, got %d (%v)", len(revisions), err)
+	}
+
+	for _, rev := range revisions {
+		if rev.ChangedBy != "admin@example.com" {
+			t.Errorf("revision %s changed by %q, want the token owner", rev.Action, rev.ChangedBy)
+		}
+	}
+
+	if rr = call("DELETE", url, secret, ""); rr.Code != http.StatusNoContent {
+		t.Errorf("AdminAPIMenuItem DELETE returned %v, want %v", rr.Code, http.StatusNoContent)
+	}
+
+	if rr = call("GET", url, secret, ""); rr.Code != http.StatusNotFound {
+		t.Errorf("deleted item returned %v, want %v", rr.Code, http.StatusNotFound)
+	}
+
+	rr = call("POST", "/api/v1/admin/flash-messages", secret, `{"message":"Closed on Monday","start_date":"2025-06-01","end_date":"2025-06-02"}`)
+	if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"type":"info"`) {
+		t.Errorf("AdminAPIFlashMessages POST returned %v: %s", rr.Code, rr.Body.String())
+	}
+
+	// Revoked tokens stop working
+	err = services.DB.RevokeAPIToken(token.ID)
+	if err != nil {
+		t.Fatalf("failed to revoke API token: %v", err)
+	}
+
+	if rr = call("GET", "/api/v1/admin/flash-messages", secret, ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("request with revoked token returned %v, want %v", rr.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAppServices_OpeningHours(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	tests := []struct {
+		name      string
+		path      string
+		form      url.Values
+		wantError string
+	}{
+		{name: "Tuesday", path: "/admin/hours/slots/create", form: url.Values{"weekday": {"2"}, "opens": {"17:00"}, "closes": {"22:00"}}},
+		{name: "Wednesday", path: "/admin/hours/slots/create", form: url.Values{"weekday": {"3"}, "opens": {"17:00"}, "closes": {"22:00"}}},
+		{name: "Sunday evening", path: "/admin/hours/slots/create", form: url.Values{"weekday": {"0"}, "opens": {"17:00"}, "closes": {"21:30"}}},
+		{name: "Sunday lunch", path: "/admin/hours/slots/create", form: url.Values{"weekday": {"0"}, "opens": {"11:30"}, "closes": {"14:00"}}},
+		{name: "Invalid time", path: "/admin/hours/slots/create", form: url.Values{"weekday": {"4"}, "opens": {"5pm"}, "closes": {"22:00"}}, wantError: "opening time"},
+		{name: "Invalid weekday", path: "/admin/hours/slots/create", form: url.Values{"weekday": {"7"}, "opens": {"17:00"}, "closes": {"22:00"}}, wantError: "weekday"},
+		{name: "End before start", path: "/admin/hours/exceptions/create", form: url.Values{"start_date": {"2025-08-10"}, "end_date": {"2025-08-01"}}, wantError: "end date"},
+		{name: "Special hours without times", path: "/admin/hours/exceptions/create", form: url.Values{"start_date": {"2025-12-31"}, "kind": {"special"}}, wantError: "opening time"},
+	}
+
+	handlers := map[string]http.HandlerFunc{
+		"/admin/hours/slots/create":      Services.CreateOpeningSlot,
+		"/admin/hours/exceptions/create": Services.CreateOpeningException,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, rr := CreateTestRequest(t, "POST", tt.path, strings.NewReader(tt.form.Encode()))
+			handlers[tt.path].ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusSeeOther {
+				t.Fatalf("wrong status code: got %v want %v", status, http.StatusSeeOther)
+			}
+
+			location := rr.Header().Get("Location")
+			if tt.wantError == "" && location != "/admin/hours" {
+				t.Errorf("unexpected redirect to %s", location)
+			}
+
+			if tt.wantError != "" && !strings.Contains(location, url.QueryEscape(tt.wantError)) {
+				t.Errorf("redirect %s does not report %q", location, tt.wantError)
+			}
+		})
+	}
+
+	// A vacation around today is announced above the menu
+	today := time.Now()
+	form := url.Values{
+		"start_date": {today.AddDate(0, 0, -2).Format("2006-01-02")},
+		"end_date":   {today.AddDate(0, 0, 5).Format("2006-01-02")},
+		"kind":       {"closed"},
+		"note":       {"Betriebsurlaub"},
+	}
+
+	req, rr := CreateTestRequest(t, "POST", "/admin/hours/exceptions/create", strings.NewReader(form.Encode()))
+	http.HandlerFunc(Services.CreateOpeningException).ServeHTTP(rr, req)
+
+	if location := rr.Header().Get("Location"); location != "/admin/hours" {
+		t.Fatalf("creating the vacation redirected to %s", location)
+	}
+
+	req, rr = CreateTestRequest(t, "GET", "/", nil)
+	http.HandlerFunc(Services.Home).ServeHTTP(rr, req)
+
+	body := rr.Body.String()
+
+	wantClosure := "Wir haben Betriebsurlaub vom " + today.AddDate(0, 0, -2).Format("02.01.") + " bis " + today.AddDate(0, 0, 5).Format("02.01.2006") + "."
+	if !strings.Contains(body, wantClosure) {
+		t.Errorf("closure banner missing, want %q in %s", wantClosure, body)
+	}
+
+	for _, want := range []string{
+		"Montag: Ruhetag",
+		"Dienstag - Mittwoch: 17:00 - 22:00 Uhr",
+		"Donnerstag - Samstag: Ruhetag",
+		"Sonntag: 11:30 - 14:00 Uhr, 17:00 - 21:30 Uhr",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("opening hours do not contain %q: %s", want, body)
+		}
+	}
+
+	// The status API knows the restaurant is closed and reopens after the vacation
+	req, rr = CreateTestRequest(t, "GET", "/api/v1/status", nil)
+	http.HandlerFunc(Services.APIStatus).ServeHTTP(rr, req)
+
+	var status struct {
+		Open     bool       `json:"open"`
+		OpensAt  *time.Time `json:"opens_at"`
+		Timezone string     `json:"timezone"`
+	}
+
+	if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil {
+		t.Fatalf("APIStatus returned invalid JSON: %v: %s", err, rr.Body.String())
+	}
+
+	if status.Open || status.OpensAt == nil || status.Timezone != "Europe/Berlin" {
+		t.Fatalf("APIStatus body = %s", rr.Body.String())
+	}
+
+	if opens := status.OpensAt.Format("2006-01-02"); opens <= today.AddDate(0, 0, 5).Format("2006-01-02") {
+		t.Errorf("APIStatus opens on %s, during the vacation", opens)
+	}
+
+	exceptions, err := services.DB.GetOpeningExceptions()
+	if err != nil || len(exceptions) != 1 {
+		t.Fatalf("GetOpeningExceptions() = %v, %v", exceptions, err)
+	}
+
+	req, rr = CreateTestRequest(t, "POST", "/admin/hours/exceptions/delete/"+strconv.Itoa(exceptions[0].ID), nil)
+	http.HandlerFunc(Services.DeleteOpeningException).ServeHTTP(rr, req)
+
+	req, rr = CreateTestRequest(t, "GET", "/", nil)
+	http.HandlerFunc(Services.Home).ServeHTTP(rr, req)
+
+	if strings.Contains(rr.Body.String(), "Betriebsurlaub") {
+		t.Errorf("closure banner still shown after deleting the vacation")
+	}
+}
+
+func TestAppServices_Sessions(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	key, err := middleware.GenerateCookieKey()
+	if err != nil {
+		t.Fatalf("failed to generate cookie key: %v", err)
+	}
+
+	middleware.SetCookieKeyring(middleware.NewKeyring(key))
+
+	// signIn starts a session the way the Google callback does and returns its cookie
+	signIn := func(email string) *http.Cookie {
+		req := httptest.NewRequest("GET", "/auth/google/callback", nil)
+		req.Header.Set("User-Agent", "Test Browser")
+		rr := httptest.NewRecorder()
+
+		if err := services.startSession(rr, req, email); err != nil {
+			t.Fatalf("failed to start session for %s: %v", email, err)
+		}
+
+		for _, cookie := range rr.Result().Cookies() {
+			if cookie.Name == middleware.SessionCookieName {
+				return cookie
+			}
+		}
+
+		t.Fatalf("no session cookie set for %s", email)
+
+		return nil
+	}
+
+	call := func(method, url string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req, rr := CreateTestRequest(t, method, url, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+
+		Services.SessionAuth(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case url == "/admin/sessions":
+				Services.AdminSessions(w, r)
+			case url == "/admin/sessions/revoke-all":
+				Services.RevokeAllSessions(w, r)
+			case strings.HasPrefix(url, "/admin/sessions/revoke/"):
+				Services.RevokeSession(w, r)
+			default:
+				Services.HandleLogout(w, r)
+			}
+		})(rr, req)
+
+		return rr
+	}
+
+	signedIn := func(cookie *http.Cookie) bool {
+		return call("GET", "/admin/sessions", cookie).Code == http.StatusOK
+	}
+
+	if rr := call("GET", "/admin/sessions", nil); rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/login" {
+		t.Errorf("request without session returned %v to %q, want a redirect to /login", rr.Code, rr.Header().Get("Location"))
+	}
+
+	laptop, phone, other := signIn("admin@example.com"), signIn("admin@example.com"), signIn("test@example.com")
+
+	// The cookie only carries an opaque token, not the email
+	if value, _ := url.QueryUnescape(laptop.Value); strings.Contains(value, "admin@example.com") {
+		t.Errorf("session cookie contains the email: %s", laptop.Value)
+	}
+
+	rr := call("GET", "/admin/sessions", laptop)
+	if rr.Code != http.StatusOK || strings.Count(rr.Body.String(), "admin@example.com 192.0.2.1") != 2 ||
+		strings.Count(rr.Body.String(), "(current)") != 1 {
+		t.Fatalf("AdminSessions returned %v: %s", rr.Code, rr.Body.String())
+	}
+
+	// Every request extends the session
+	sessions, err := services.DB.GetActiveSessions()
+	if err != nil || len(sessions) != 3 {
+		t.Fatalf("expected 3 active sessions, got %d (%v)", len(sessions), err)
+	}
+
+	current := sessions[0]
+	if !current.LastSeenAt.After(current.CreatedAt) || !current.ExpiresAt.After(current.LastSeenAt.Add(models.SessionIdleTimeout-time.Minute)) {
+		t.Errorf("session not extended: created %v, last seen %v, expires %v", current.CreatedAt, current.LastSeenAt, current.ExpiresAt)
+	}
+
+	// A revoked session is signed out, even though its cookie is still validly signed
+	var phoneID int
+
+	for _, s := range sessions {
+		if s.Email == "admin@example.com" && s.ID != current.ID {
+			phoneID = s.ID
+		}
+	}
+
+	if rr = call("POST", fmt.Sprintf("/admin/sessions/revoke/%d", phoneID), laptop); rr.Code != http.StatusSeeOther {
+		t.Errorf("RevokeSession returned %v, want %v", rr.Code, http.StatusSeeOther)
+	}
+
+	if signedIn(phone) {
+		t.Errorf("revoked session still accepted")
+	}
+
+	if !signedIn(laptop) {
+		t.Errorf("revoking another session signed out the current one")
+	}
+
+	// Logging out revokes the session, so a copy of the cookie no longer works
+	call("GET", "/admin/logout", laptop)
+
+	if signedIn(laptop) {
+		t.Errorf("session still accepted after logout")
+	}
+
+	// Logging out everywhere ends all sessions of the current admin only
+	first, second := signIn("admin@example.com"), signIn("admin@example.com")
+
+	if rr = call("POST", "/admin/sessions/revoke-all", first); rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/login" {
+		t.Errorf("RevokeAllSessions returned %v to %q", rr.Code, rr.Header().Get("Location"))
+	}
+
+	if signedIn(first) || signedIn(second) {
+		t.Errorf("sessions still accepted after logging out everywhere")
+	}
+
+	if !signedIn(other) {
+		t.Errorf("logging out everywhere ended another admin's session")
+	}
+
+	// Removing an admin from the allowed emails ends their sessions
+	removed := signIn("former@example.com")
+
+	if rr = call("GET", "/admin/sessions", removed); rr.Code != http.StatusForbidden {
+		t.Errorf("session of a removed admin returned %v, want %v", rr.Code, http.StatusForbidden)
+	}
+
+	sessions, err = services.DB.GetActiveSessions()
+	if err != nil || len(sessions) != 1 || sessions[0].Email != "test@example.com" {
+		t.Errorf("expected only the session of test@example.com to remain, got %+v (%v)", sessions, err)
+	}
+}
+
+func TestAppServices_AdminUsers(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	// post submits a form as the given user with the given role
+	post := func(handler http.HandlerFunc, target, email string, role models.Role, form url.Values) *httptest.ResponseRecorder {
+		req, rr := CreateTestRequest(t, "POST", target, strings.NewReader(form.Encode()))
+		req = middleware.WithUserRole(middleware.WithUserEmail(req, email), role)
+
+		handler(rr, req)
+
+		return rr
+	}
+
+	userID := func(email string) int {
+		user, err := services.DB.GetAdminUserByEmail(email)
+		if err != nil {
+			t.Fatalf("admin user %s not found: %v", email, err)
+		}
+
+		return user.ID
+	}
+
+	// The allowed emails were seeded as owners
+	users, err := services.DB.GetAdminUsers()
+	if err != nil || len(users) != 2 || users[0].Role != models.RoleOwner || users[1].Role != models.RoleOwner {
+		t.Fatalf("expected 2 seeded owners, got %+v (%v)", users, err)
+	}
+
+	if added, err := services.DB.BootstrapAdminUsers([]string{"late@example.com"}); err != nil || added != 0 {
+		t.Errorf("BootstrapAdminUsers added %d users to a filled table (%v)", added, err)
+	}
+
+	rr := post(Services.CreateAdminUser, "/admin/users/create", "admin@example.com", models.RoleOwner,
+		url.Values{"email": {" Waiter@Example.com "}, "role": {"staff"}})
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/admin/users" {
+		t.Fatalf("CreateAdminUser returned %v to %q", rr.Code, rr.Header().Get("Location"))
+	}
+
+	waiter, err := services.DB.GetAdminUserByEmail("waiter@example.com")
+	if err != nil || waiter.Role != models.RoleStaff || waiter.CreatedBy != "admin@example.com" {
+		t.Fatalf("waiter not added as staff: %+v (%v)", waiter, err)
+	}
+
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		url       string
+		form      url.Values
+		wantError string
+	}{
+		{
+			name:      "Duplicate email",
+			handler:   Services.CreateAdminUser,
+			url:       "/admin/users/create",
+			form:      url.Values{"email": {"WAITER@example.com"}, "role": {"manager"}},
+			wantError: "already an admin user",
+		},
+		{
+			name:      "Unknown role",
+			handler:   Services.CreateAdminUser,
+			url:       "/admin/users/create",
+			form:      url.Values{"email": {"cook@example.com"}, "role": {"chef"}},
+			wantError: "choose a role",
+		},
+		{
+			name:    "Remove an owner while another is left",
+			handler: Services.DeleteAdminUser,
+			url:     fmt.Sprintf("/admin/users/delete/%d", userID("test@example.com")),
+		},
+		{
+			name:      "Demote the last owner",
+			handler:   Services.UpdateAdminUser,
+			url:       fmt.Sprintf("/admin/users/update/%d", userID("admin@example.com")),
+			form:      url.Values{"role": {"manager"}},
+			wantError: models.ErrLastOwner.Error(),
+		},
+		{
+			name:      "Remove the last owner",
+			handler:   Services.DeleteAdminUser,
+			url:       fmt.Sprintf("/admin/users/delete/%d", userID("admin@example.com")),
+			wantError: models.ErrLastOwner.Error(),
+		},
+		{
+			name:    "Promote staff to manager",
+			handler: Services.UpdateAdminUser,
+			url:     fmt.Sprintf("/admin/users/update/%d", waiter.ID),
+			form:    url.Values{"role": {"manager"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := post(tt.handler, tt.url, "admin@example.com", models.RoleOwner, tt.form)
+
+			location, _ := url.QueryUnescape(rr.Header().Get("Location"))
+			if rr.Code != http.StatusSeeOther || !strings.Contains(location, tt.wantError) ||
+				(tt.wantError == "" && location != "/admin/users") {
+				t.Errorf("returned %v to %q, want an error containing %q", rr.Code, location, tt.wantError)
+			}
+		})
+	}
+
+	if waiter, _ = services.DB.GetAdminUserByEmail("waiter@example.com"); waiter.Role != models.RoleManager {
+		t.Errorf("waiter has role %s, want %s", waiter.Role, models.RoleManager)
+	}
+
+	// API tokens can only do what their owner may do
+	_, secret, err := services.DB.CreateAPIToken("Till", "waiter@example.com")
+	if err != nil {
+		t.Fatalf("failed to create API token: %v", err)
+	}
+
+	callAPI := func() int {
+		req, rr := CreateTestRequest(t, "GET", "/api/v1/admin/menu-items", nil)
+		req.Header.Set("Authorization", "Bearer "+secret)
+		Services.APITokenAuth(Services.AdminAPIMenuItems)(rr, req)
+
+		return rr.Code
+	}
+
+	if code := callAPI(); code != http.StatusOK {
+		t.Errorf("token of a manager returned %v, want %v", code, http.StatusOK)
+	}
+
+	if err := services.DB.UpdateAdminUserRole(waiter.ID, models.RoleStaff); err != nil {
+		t.Fatalf("failed to demote waiter: %v", err)
+	}
+
+	if code := callAPI(); code != http.StatusForbidden {
+		t.Errorf("token of a staff member returned %v, want %v", code, http.StatusForbidden)
+	}
+
+	// Staff only see and revoke their own sessions
+	ownerSession, _, err := services.DB.CreateSession("admin@example.com", "", "")
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	waiterSession, _, err := services.DB.CreateSession("waiter@example.com", "", "")
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	req, rr := CreateTestRequest(t, "GET", "/admin/sessions", nil)
+	Services.AdminSessions(rr, middleware.WithUserRole(middleware.WithSession(req, "waiter@example.com", waiterSession.ID), models.RoleStaff))
+
+	if body := rr.Body.String(); !strings.Contains(body, "waiter@example.com") || strings.Contains(body, "admin@example.com") {
+		t.Errorf("staff member sees other sessions: %s", body)
+	}
+
+	if rr = post(Services.RevokeSession, fmt.Sprintf("/admin/sessions/revoke/%d", ownerSession.ID), "waiter@example.com", models.RoleStaff, nil); rr.Code != http.StatusNotFound {
+		t.Errorf("staff member revoking an owner's session returned %v, want %v", rr.Code, http.StatusNotFound)
+	}
+
+	// Removing a user ends their sessions
+	if rr = post(Services.DeleteAdminUser, fmt.Sprintf("/admin/users/delete/%d", waiter.ID), "admin@example.com", models.RoleOwner, nil); rr.Code != http.StatusSeeOther {
+		t.Errorf("DeleteAdminUser returned %v, want %v", rr.Code, http.StatusSeeOther)
+	}
+
+	sessions, err := services.DB.GetActiveSessions()
+	if err != nil || len(sessions) != 1 || sessions[0].ID != ownerSession.ID {
+		t.Errorf("expected only the owner's session to remain, got %+v (%v)", sessions, err)
+	}
+}
+
+func TestAppServices_OIDCLogin(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	key, err := middleware.GenerateCookieKey()
+	if err != nil {
+		t.Fatalf("failed to generate cookie key: %v", err)
+	}
+
+	middleware.SetCookieKeyring(middleware.NewKeyring(key))
+
+	server := oidctest.NewServer(t)
+	services.OAuthConfig.Providers = []*auth.Provider{auth.NewProvider(auth.ProviderConfig{
+		ID:           "fake",
+		Name:         "Fake SSO",
+		Issuer:       server.URL,
+		ClientID:     oidctest.ClientID,
+		ClientSecret: oidctest.ClientSecret,
+		RedirectURL:  "http://localhost:8080/auth/fake/callback",
+	}, server.Client())}
+
+	req, rr := CreateTestRequest(t, "GET", "/login", nil)
+	Services.ShowLoginPage(rr, req)
+
+	if !strings.Contains(rr.Body.String(), `<a href="/auth/fake/login">Fake SSO</a>`) {
+		t.Errorf("login page does not list the provider: %s", rr.Body.String())
+	}
+
+	// route calls a handler with the provider named in the URL, like the router does
+	route := func(handler http.HandlerFunc, target string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req, rr := CreateTestRequest(t, "GET", target, nil)
+		req.SetPathValue("provider", strings.Split(req.URL.Path, "/")[2])
+
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+
+		handler(rr, req)
+
+		return rr
+	}
+
+	cookieNamed := func(rr *httptest.ResponseRecorder, name string) *http.Cookie {
+		for _, cookie := range rr.Result().Cookies() {
+			if cookie.Name == name && cookie.MaxAge >= 0 {
+				return cookie
+			}
+		}
+
+		return nil
+	}
+
+	client := server.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	// signIn goes through the provider's sign-in page and returns the login cookie and callback URL
+	signIn := func() (*http.Cookie, *url.URL) {
+		rr := route(Services.HandleLogin, "/auth/fake/login")
+		if rr.Code != http.StatusTemporaryRedirect {
+			t.Fatalf("HandleLogin returned %v, want %v", rr.Code, http.StatusTemporaryRedirect)
+		}
+
+		loginCookie := cookieNamed(rr, loginCookieName)
+		if loginCookie == nil || loginCookie.Path != "/auth/fake/" || !loginCookie.HttpOnly {
+			t.Fatalf("login cookie = %+v, want an HTTP-only cookie for /auth/fake/", loginCookie)
+		}
+
+		resp, err := client.Get(rr.Header().Get("Location"))
+		if err != nil {
+			t.Fatalf("failed to sign in at the provider: %v", err)
+		}
+		resp.Body.Close()
+
+		callback, err := url.Parse(resp.Header.Get("Location"))
+		if err != nil || resp.StatusCode != http.StatusFound {
+			t.Fatalf("provider returned %v to %q, want a redirect to the callback", resp.StatusCode, resp.Header.Get("Location"))
+		}
+
+		return loginCookie, callback
+	}
+
+	// An admin user is signed in
+	loginCookie, callback := signIn()
+
+	rr = route(Services.HandleCallback, callback.RequestURI(), loginCookie)
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/admin" {
+		t.Fatalf("HandleCallback returned %v to %q, want a redirect to /admin", rr.Code, rr.Header().Get("Location"))
+	}
+
+	if cookieNamed(rr, middleware.SessionCookieName) == nil {
+		t.Error("HandleCallback did not set a session cookie")
+	}
+
+	sessions, err := services.DB.GetActiveSessions()
+	if err != nil || len(sessions) != 1 || sessions[0].Email != "admin@example.com" {
+		t.Errorf("expected a session for admin@example.com, got %+v (%v)", sessions, err)
+	}
+
+	// The login cookie and its code cannot be used again
+	if rr = route(Services.HandleCallback, callback.RequestURI(), loginCookie); rr.Header().Get("Location") != "/login?error=Authentication+failed" {
+		t.Errorf("replayed callback redirected to %q, want a failed sign-in", rr.Header().Get("Location"))
+	}
+
+	tests := []struct {
+		name          string
+		email         string
+		emailVerified bool
+		callback      func(callback *url.URL) string
+		noCookie      bool
+		wantLocation  string
+	}{
+		{
+			name:          "Not an admin user",
+			email:         "stranger@example.com",
+			emailVerified: true,
+			wantLocation:  "/login?error=Unauthorized+email",
+		},
+		{
+			name:         "Email not verified",
+			email:        "admin@example.com",
+			wantLocation: "/login?error=Email+not+verified",
+		},
+		{
+			name:          "Forged state",
+			email:         "admin@example.com",
+			emailVerified: true,
+			callback: func(callback *url.URL) string {
+				q := callback.Query()
+				q.Set("state", "forged")
+
+				return callback.Path + "?" + q.Encode()
+			},
+			wantLocation: "/login?error=Invalid+authentication+attempt",
+		},
+		{
+			name:          "No login cookie",
+			email:         "admin@example.com",
+			emailVerified: true,
+			noCookie:      true,
+			wantLocation:  "/login?error=Invalid+authentication+attempt",
+		},
+		{
+			name:          "Cancelled at the provider",
+			email:         "admin@example.com",
+			emailVerified: true,
+			callback:      func(*url.URL) string { return "/auth/fake/callback?error=access_denied" },
+			wantLocation:  "/login?error=Authentication+failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.Email, server.EmailVerified = tt.email, tt.emailVerified
+
+			loginCookie, callback := signIn()
+
+			target := callback.RequestURI()
+			if tt.callback != nil {
+				target = tt.callback(callback)
+			}
+
+			var cookies []*http.Cookie
+			if !tt.noCookie {
+				cookies = append(cookies, loginCookie)
+			}
+
+			rr := route(Services.HandleCallback, target, cookies...)
+			if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != tt.wantLocation {
+				t.Errorf("HandleCallback returned %v to %q, want a redirect to %q", rr.Code, rr.Header().Get("Location"), tt.wantLocation)
+			}
+
+			if cookieNamed(rr, middleware.SessionCookieName) != nil {
+				t.Error("HandleCallback set a session cookie")
+			}
+		})
+	}
+
+	if rr = route(Services.HandleLogin, "/auth/unknown/login"); rr.Code != http.StatusNotFound {
+		t.Errorf("HandleLogin for an unknown provider returned %v, want %v", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestAppServices_PasskeyLogin(t *testing.T) {
+	services := NewTestAppServices(t)
+	defer CleanTestDB(services)
+
+	NewHandlers(services)
+
+	key, err := middleware.GenerateCookieKey()
+	if err != nil {
+		t.Fatalf("failed to generate cookie key: %v", err)
+	}
+
+	middleware.SetCookieKeyring(middleware.NewKeyring(key))
+
+	// Passkeys work without any OpenID Connect provider
+	services.OAuthConfig = nil
+
+	services.Passkeys, err = auth.NewRelyingParty("http://localhost:8080")
+	if err != nil {
+		t.Fatalf("NewRelyingParty() error = %v", err)
+	}
+
+	req, rr := CreateTestRequest(t, "GET", "/login", nil)
+	Services.ShowLoginPage(rr, req)
+
+	if !strings.Contains(rr.Body.String(), "Sign in with a passkey") {
+		t.Errorf("login page does not offer passkeys: %s", rr.Body.String())
+	}
+
+	// post calls a handler with a JSON body, signed in as the given admin unless email is empty
+	post := func(handler http.HandlerFunc, target, email string, body any) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("failed to encode body: %v", err)
+		}
+
+		req, rr := CreateTestRequest(t, "POST", target, strings.NewReader(string(data)))
+		req.Header.Set("Content-Type", "application/json")
+
+		if email != "" {
+			req = middleware.WithUserRole(middleware.WithSession(req, email, 1), models.RoleOwner)
+		}
+
+		handler(rr, req)
+
+		return rr
+	}
+
+	var options struct {
+		Challenge auth.Base64URL `json:"challenge"`
+		User      struct {
+			ID auth.Base64URL `json:"id"`
+		} `json:"user"`
+	}
+
+	// creationOptions starts registering a passkey for the admin
+	creationOptions := func(email string) {
+		rr := post(Services.PasskeyCreationOptions, "/admin/passkeys/options", email, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("PasskeyCreationOptions returned %v: %s", rr.Code, rr.Body.String())
+		}
+
+		if err := json.Unmarshal(rr.Body.Bytes(), &options); err != nil {
+			t.Fatalf("failed to decode creation options: %v", err)
+		}
+	}
+
+	// An admin registers a passkey
+	authenticator := passkeytest.New(t, "http://localhost:8080")
+	creationOptions("admin@example.com")
+
+	registration := map[string]any{"name": "Laptop", "credential": authenticator.Create(t, options.Challenge, options.User.ID)}
+	if rr = post(Services.RegisterPasskey, "/admin/passkeys/register", "admin@example.com", registration); rr.Code != http.StatusOK {
+		t.Fatalf("RegisterPasskey returned %v: %s", rr.Code, rr.Body.String())
+	}
+
+	passkeys, err := services.DB.GetPasskeysByEmail("admin@example.com")
+	if err != nil || len(passkeys) != 1 || passkeys[0].Name != "Laptop" {
+		t.Fatalf("expected the passkey Laptop for admin@example.com, got %+v (%v)", passkeys, err)
+	}
+
+	// The registration cannot be completed again, nor by another admin
+	if rr = post(Services.RegisterPasskey, "/admin/passkeys/register", "admin@example.com", registration); rr.Code != http.StatusBadRequest {
+		t.Errorf("replayed RegisterPasskey returned %v, want %v", rr.Code, http.StatusBadRequest)
+	}
+
+	creationOptions("admin@example.com")
+
+	other := passkeytest.New(t, "http://localhost:8080")
+	registration = map[string]any{"name": "Phone", "credential": other.Create(t, options.Challenge, options.User.ID)}
+
+	if rr = post(Services.RegisterPasskey, "/admin/passkeys/register", "test@example.com", registration); rr.Code != http.StatusBadRequest {
+		t.Errorf("RegisterPasskey with the challenge of another admin returned %v, want %v", rr.Code, http.StatusBadRequest)
+	}
+
+	// signIn answers a new sign-in challenge with the authenticator
+	signIn := func(authenticator *passkeytest.Authenticator) passkeytest.Response {
+		rr := post(Services.PasskeyLoginOptions, "/auth/passkey/options", "", nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("PasskeyLoginOptions returned %v: %s", rr.Code, rr.Body.String())
+		}
+
+		if err := json.Unmarshal(rr.Body.Bytes(), &options); err != nil {
+			t.Fatalf("failed to decode request options: %v", err)
+		}
+
+		return authenticator.Get(t, options.Challenge)
+	}
+
+	// The admin signs in with the passkey
+	response := signIn(authenticator)
+
+	rr = post(Services.PasskeyLogin, "/auth/passkey/login", "", response)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"redirect":"/admin"`) {
+		t.Fatalf("PasskeyLogin returned %v: %s", rr.Code, rr.Body.String())
+	}
+
+	sessions, err := services.DB.GetActiveSessions()
+	if err != nil || len(sessions) != 1 || sessions[0].Email != "admin@example.com" {
+		t.Errorf("expected a session for admin@example.com, got %+v (%v)", sessions, err)
+	}
+
+	passkey, err := services.DB.GetPasskeyByCredentialID(authenticator.CredentialID())
+	if err != nil || passkey.SignCount != authenticator.SignCount || passkey.LastUsedAt == nil {
+		t.Errorf("expected the use of the passkey to be recorded, got %+v (%v)", passkey, err)
+	}
+
+	// A recorded sign-in cannot be replayed
+	if rr = post(Services.PasskeyLogin, "/auth/passkey/login", "", response); rr.Code != http.StatusUnauthorized {
+		t.Errorf("replayed PasskeyLogin returned %v, want %v", rr.Code, http.StatusUnauthorized)
+	}
+
+	// Unregistered passkeys are refused
+	if rr = post(Services.PasskeyLogin, "/auth/passkey/login", "", signIn(other)); rr.Code != http.StatusUnauthorized {
+		t.Errorf("PasskeyLogin with an unregistered passkey returned %v, want %v", rr.Code, http.StatusUnauthorized)
+	}
+
+	// Admins can only remove their own passkeys
+	target := fmt.Sprintf("/admin/passkeys/delete/%d", passkey.ID)
+
+	req, rr = CreateTestRequest(t, "POST", target, nil)
+	Services.DeletePasskey(rr, middleware.WithSession(req, "test@example.com", 1))
+
+	if _, err := services.DB.GetPasskeyByCredentialID(authenticator.CredentialID()); err != nil {
+		t.Errorf("passkey removed by another admin: %v", err)
+	}
+
+	req, rr = CreateTestRequest(t, "POST", target, nil)
+	Services.DeletePasskey(rr, middleware.WithSession(req, "admin@example.com", 1))
+
+	if rr = post(Services.PasskeyLogin, "/auth/passkey/login", "", signIn(authenticator)); rr.Code != http.StatusUnauthorized {
+		t.Errorf("PasskeyLogin with a removed passkey returned %v, want %v", rr.Code, http.StatusUnauthorized)
+	}
+
+	// A client cannot pile up sign-in challenges, while other clients can still sign in
+	loginOptions := func(remoteAddr string) int {
+		req, rr := CreateTestRequest(t, "POST", "/auth/passkey/options", nil)
+		req.RemoteAddr = remoteAddr
+		Services.PasskeyLoginOptions(rr, req)
+
+		return rr.Code
+	}
+
+	for i := 0; i < models.MaxPasskeyChallengesPerClient; i++ {
+		if code := loginOptions("203.0.113.7:4711"); code != http.StatusOK {
+			t.Fatalf("PasskeyLoginOptions %d returned %v, want %v", i+1, code, http.StatusOK)
+		}
+	}
+
+	if code := loginOptions("203.0.113.7:4712"); code != http.StatusTooManyRequests {
+		t.Errorf("PasskeyLoginOptions beyond the limit returned %v, want %v", code, http.StatusTooManyRequests)
+	}
+
+	if code := loginOptions("198.51.100.1:4711"); code != http.StatusOK {
+		t.Errorf("PasskeyLoginOptions from another client returned %v, want %v", code, http.StatusOK)
+	}
+}
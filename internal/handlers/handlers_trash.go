@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AdminTrash displays the deleted menu items so they can be restored or purged
+func (m *AppServices) AdminTrash(w http.ResponseWriter, r *http.Request) {
+	items, err := m.DB.GetTrashedMenuItems()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "AdminTrash - fetching deleted menu items")
+		return
+	}
+
+	// Render the trash template
+	err = m.TemplateCache["admin-trash.html"].Execute(w, map[string]interface{}{
+		"Title": "Trash",
+		"Items": items,
+		"Year":  time.Now().Year(),
+	})
+
+	if err != nil {
+		// Just log the error since template.Execute likely already wrote to the response
+		log.Printf("ERROR: Template rendering failed in AdminTrash: %v", err)
+		return
+	}
+}
+
+// RestoreMenuItem takes a menu item out of the trash
+func (m *AppServices) RestoreMenuItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/trash", http.StatusSeeOther)
+		return
+	}
+
+	// Extract ID from URL
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/trash/restore/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	err = m.DB.RestoreMenuItem(id, currentUser(r))
+	if errors.Is(err, sql.ErrNoRows) {
+		m.clientError(w, http.StatusNotFound, "Menu item not found in the trash")
+		return
+	}
+
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "RestoreMenuItem - restoring menu item")
+		return
+	}
+
+	http.Redirect(w, r, "/admin/trash", http.StatusSeeOther)
+}
+
+// PurgeMenuItem permanently deletes a menu item from the trash, together with its image file
+func (m *AppServices) PurgeMenuItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/trash", http.StatusSeeOther)
+		return
+	}
+
+	// Extract ID from URL
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/trash/purge/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	items, err := m.DB.GetTrashedMenuItems()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "PurgeMenuItem - fetching deleted menu items")
+		return
+	}
+
+	imageURL := ""
+
+	for _, item := range items {
+		if item.ID == id {
+			imageURL = item.ImageURL
+		}
+	}
+
+	err = m.DB.PurgeMenuItem(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		m.clientError(w, http.StatusNotFound, "Menu item not found in the trash")
+		return
+	}
+
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "PurgeMenuItem - deleting menu item")
+		return
+	}
+
+	// The image is only removed once the item is gone for good
+	m.deleteImageFile(imageURL)
+
+	http.Redirect(w, r, "/admin/trash", http.StatusSeeOther)
+}
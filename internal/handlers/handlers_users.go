@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+// AdminUsers displays the admin users and their roles. Only owners can reach it.
+func (m *AppServices) AdminUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := m.DB.GetAdminUsers()
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "AdminUsers - fetching admin users")
+		return
+	}
+
+	// Render the admin users template
+	err = m.TemplateCache["admin-users.html"].Execute(w, map[string]interface{}{
+		"Title":       "Admin Users",
+		"Users":       users,
+		"Roles":       models.Roles,
+		"CurrentUser": currentUser(r),
+		"Error":       r.URL.Query().Get("error"),
+		"Year":        time.Now().Year(),
+	})
+
+	if err != nil {
+		// Just log the error since template.Execute likely already wrote to the response
+		log.Printf("ERROR: Template rendering failed in AdminUsers: %v", err)
+		return
+	}
+}
+
+// roleFromForm reads and validates the role of a submitted form
+func roleFromForm(r *http.Request) (models.Role, error) {
+	role := models.Role(r.FormValue("role"))
+	if !role.IsValid() {
+		return "", errors.New("please choose a role")
+	}
+
+	return role, nil
+}
+
+// redirectToUsers redirects back to the admin users page, optionally with an error message
+func redirectToUsers(w http.ResponseWriter, r *http.Request, errorMsg string) {
+	target := "/admin/users"
+	if errorMsg != "" {
+		target += "?error=" + url.QueryEscape(errorMsg)
+	}
+
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// CreateAdminUser handles the add user form submission. The new user can sign in right away,
+// with an account of that email at any of the configured providers.
+func (m *AppServices) CreateAdminUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/users", http.StatusSeeOther)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Could not parse form")
+		return
+	}
+
+	email := models.NormalizeEmail(r.FormValue("email"))
+	if !strings.Contains(email, "@") {
+		redirectToUsers(w, r, "please enter an email address")
+		return
+	}
+
+	role, err := roleFromForm(r)
+	if err != nil {
+		redirectToUsers(w, r, err.Error())
+		return
+	}
+
+	_, err = m.DB.GetAdminUserByEmail(email)
+
+	switch {
+	case err == nil:
+		redirectToUsers(w, r, email+" is already an admin user")
+		return
+
+	case !errors.Is(err, sql.ErrNoRows):
+		m.adminError(w, r, err, http.StatusInternalServerError, "CreateAdminUser - checking email")
+		return
+	}
+
+	_, err = m.DB.InsertAdminUser(models.AdminUser{Email: email, Role: role, CreatedBy: currentUser(r)})
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "CreateAdminUser - saving user")
+		return
+	}
+
+	log.Printf("Admin user %s added as %s by %s", email, role, currentUser(r))
+
+	redirectToUsers(w, r, "")
+}
+
+// UpdateAdminUser changes the role of an admin user; it applies from their next request
+func (m *AppServices) UpdateAdminUser(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from URL
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/users/update/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/users", http.StatusSeeOther)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Could not parse form")
+		return
+	}
+
+	role, err := roleFromForm(r)
+	if err != nil {
+		redirectToUsers(w, r, err.Error())
+		return
+	}
+
+	err = m.DB.UpdateAdminUserRole(id, role)
+
+	switch {
+	case errors.Is(err, models.ErrLastOwner):
+		redirectToUsers(w, r, err.Error())
+		return
+
+	case errors.Is(err, sql.ErrNoRows):
+		m.clientError(w, http.StatusNotFound, "Admin user not found")
+		return
+
+	case err != nil:
+		m.adminError(w, r, err, http.StatusInternalServerError, "UpdateAdminUser - updating role")
+		return
+	}
+
+	log.Printf("Admin user %d made %s by %s", id, role, currentUser(r))
+
+	redirectToUsers(w, r, "")
+}
+
+// DeleteAdminUser removes an admin user and ends all their sessions
+func (m *AppServices) DeleteAdminUser(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from URL
+	id, err := strconv.Atoi(r.URL.Path[len("/admin/users/delete/"):])
+	if err != nil {
+		m.clientError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/admin/users", http.StatusSeeOther)
+		return
+	}
+
+	user, err := m.DB.DeleteAdminUser(id)
+
+	switch {
+	case errors.Is(err, models.ErrLastOwner):
+		redirectToUsers(w, r, err.Error())
+		return
+
+	case errors.Is(err, sql.ErrNoRows):
+		redirectToUsers(w, r, "")
+		return
+
+	case err != nil:
+		m.adminError(w, r, err, http.StatusInternalServerError, "DeleteAdminUser - deleting user")
+		return
+	}
+
+	revoked, err := m.DB.RevokeSessionsByEmail(user.Email)
+	if err != nil {
+		m.adminError(w, r, err, http.StatusInternalServerError, "DeleteAdminUser - ending sessions")
+		return
+	}
+
+	log.Printf("Admin user %s removed by %s, %d session(s) ended", user.Email, currentUser(r), revoked)
+
+	redirectToUsers(w, r, "")
+}
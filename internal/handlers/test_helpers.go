@@ -12,27 +12,36 @@ import (
 	"testing"
 
 	"github.com/AlexTLDR/pizzeria/internal/auth"
+	"github.com/AlexTLDR/pizzeria/internal/i18n"
 	"github.com/AlexTLDR/pizzeria/internal/models"
 )
 
 func createMockTemplates() map[string]*template.Template {
 	funcMap := template.FuncMap{
-		"deref": func(f *float64) float64 {
-			if f != nil {
-				return *f
-			}
-			return 0
+		// money formats a price German style, e.g. "12,99 €"
+		"money": func(m models.Money) string {
+			return m.String()
 		},
 	}
 
+	for name, fn := range i18n.FuncMap(i18n.Default) {
+		funcMap[name] = fn
+	}
+
 	indexTemplate := template.New("index.html").Funcs(funcMap)
 	var err error
 	indexTemplate, err = indexTemplate.Parse(`<!DOCTYPE html>
-<html>
+<html lang="{{ .Lang }}">
 <head><title>Mock Index Template</title></head>
 <body>
   <h1>Mock Template for Testing</h1>
+  <h2>{{ t "menu.title" }}</h2>
   <div>This is mock content for testing the Home handler</div>
+  <ul>{{ range .Categories }}{{ range index $.MenuByCategory .Slug }}<li>{{ .Name }}</li>{{ end }}{{ end }}</ul>
+  <dl>{{ range .Categories }}<dt>{{ .Label }}</dt>{{ range index $.MenuByCategory .Slug }}<dd>{{ .Description }}</dd>{{ end }}{{ end }}</dl>
+  {{ range .FlashMessages }}<p class="flash">{{ .Message }}</p>{{ end }}
+  {{ with .Closure }}<p class="closure">{{ if .IsSingleDay }}{{ t "closure.day" (.StartDate.Format "02.01.2006") }}{{ else }}{{ t "closure.vacation" (.StartDate.Format "02.01.") (.EndDate.Format "02.01.2006") }}{{ end }}</p>{{ end }}
+  {{ range .OpeningHours }}<p class="hours">{{ t (printf "weekday.%d" .From) }}{{ if ne .From .To }} - {{ t (printf "weekday.%d" .To) }}{{ end }}: {{ range $i, $s := .Slots }}{{ if $i }}, {{ end }}{{ t "hours.range" $s.Opens $s.Closes }}{{ else }}{{ t "hours.closed" }}{{ end }}</p>{{ end }}
 </body>
 </html>`)
 	if err != nil {
@@ -46,7 +55,7 @@ func createMockTemplates() map[string]*template.Template {
 	}
 
 	loginTemplate := template.New("login.html").Funcs(funcMap)
-	loginTemplate, err = loginTemplate.Parse(`<html><body>Mock Login Page</body></html>`)
+	loginTemplate, err = loginTemplate.Parse(`<html><body>Mock Login Page{{range .Providers}} <a href="/auth/{{.ID}}/login">{{.Name}}</a>{{end}}{{if .Passkeys}} Sign in with a passkey{{end}}</body></html>`)
 	if err != nil {
 		panic(err)
 	}
@@ -57,11 +66,95 @@ func createMockTemplates() map[string]*template.Template {
 		panic(err)
 	}
 
+	categoriesTemplate := template.New("admin-categories.html").Funcs(funcMap)
+	categoriesTemplate, err = categoriesTemplate.Parse(`<html><body>Mock Categories Page</body></html>`)
+	if err != nil {
+		panic(err)
+	}
+
+	tagsTemplate := template.New("admin-tags.html").Funcs(funcMap)
+	tagsTemplate, err = tagsTemplate.Parse(`<html><body>Mock Tags Page</body></html>`)
+	if err != nil {
+		panic(err)
+	}
+
+	extrasTemplate := template.New("admin-extras.html").Funcs(funcMap)
+	extrasTemplate, err = extrasTemplate.Parse(`<html><body>Mock Extras Page</body></html>`)
+	if err != nil {
+		panic(err)
+	}
+
+	trashTemplate := template.New("admin-trash.html").Funcs(funcMap)
+	trashTemplate, err = trashTemplate.Parse(`<html><body>Mock Trash Page<ul>{{ range .Items }}<li>{{ .Name }}</li>{{ end }}</ul></body></html>`)
+	if err != nil {
+		panic(err)
+	}
+
+	historyTemplate := template.New("admin-history.html").Funcs(funcMap)
+	historyTemplate, err = historyTemplate.Parse(`<html><body>Mock History Page<ul>{{ range .Revisions }}<li>{{ .Action }} by {{ .ChangedBy }}:{{ range .Changes }} {{ .Field }}{{ end }}</li>{{ end }}</ul></body></html>`)
+	if err != nil {
+		panic(err)
+	}
+
+	pricesTemplate := template.New("admin-prices.html").Funcs(funcMap)
+	pricesTemplate, err = pricesTemplate.Parse(`<html><body>Mock Prices Page<ul>{{ range .Schedules }}<li>{{ .MenuItemName }} {{ money .Price }}</li>{{ end }}</ul></body></html>`)
+	if err != nil {
+		panic(err)
+	}
+
+	priceAdjustTemplate := template.New("admin-price-adjust.html").Funcs(funcMap)
+	priceAdjustTemplate, err = priceAdjustTemplate.Parse(`<html><body>Mock Adjust Prices Page{{ .Error }}<ul>{{ range .Changes }}<li>{{ .MenuItemName }} {{ money .Old }} {{ money .New }}</li>{{ end }}</ul></body></html>`)
+	if err != nil {
+		panic(err)
+	}
+
+	importTemplate := template.New("admin-import.html").Funcs(funcMap)
+	importTemplate, err = importTemplate.Parse(`<html><body>Mock Import Page<ul>{{ range .Problems }}<li>{{ . }}</li>{{ end }}</ul>{{ with .Plan }}<p>{{ len .New }} new, {{ len .Changed }} changed, {{ len .Removed }} removed</p>{{ end }}</body></html>`)
+	if err != nil {
+		panic(err)
+	}
+
+	hoursTemplate := template.New("admin-hours.html").Funcs(funcMap)
+	hoursTemplate, err = hoursTemplate.Parse(`<html><body>Mock Opening Hours Page{{ .Error }}<ul>{{ range .Slots }}<li>{{ .Weekday }} {{ .Opens }}-{{ .Closes }}</li>{{ end }}</ul><ul>{{ range .Exceptions }}<li>{{ .StartDate.Format "2006-01-02" }} {{ .Note }}</li>{{ end }}</ul></body></html>`)
+	if err != nil {
+		panic(err)
+	}
+
+	sessionsTemplate := template.New("admin-sessions.html").Funcs(funcMap)
+	sessionsTemplate, err = sessionsTemplate.Parse(`<html><body>Mock Sessions Page<ul>{{ range .Sessions }}<li>{{ .Email }} {{ .IP }}{{ if eq .ID $.CurrentSession }} (current){{ end }}</li>{{ end }}</ul></body></html>`)
+	if err != nil {
+		panic(err)
+	}
+
+	passkeysTemplate := template.New("admin-passkeys.html").Funcs(funcMap)
+	passkeysTemplate, err = passkeysTemplate.Parse(`<html><body>Mock Passkeys Page{{ if not .Enabled }} Disabled{{ end }}<ul>{{ range .Passkeys }}<li>{{ .Name }}</li>{{ end }}</ul></body></html>`)
+	if err != nil {
+		panic(err)
+	}
+
+	usersTemplate := template.New("admin-users.html").Funcs(funcMap)
+	usersTemplate, err = usersTemplate.Parse(`<html><body>Mock Admin Users Page{{ .Error }}<ul>{{ range .Users }}<li>{{ .Email }} {{ .Role }}</li>{{ end }}</ul></body></html>`)
+	if err != nil {
+		panic(err)
+	}
+
 	templateCache := map[string]*template.Template{
-		"index.html":           indexTemplate,
-		"admin-dashboard.html": adminTemplate,
-		"login.html":           loginTemplate,
-		"menu-form.html":       menuFormTemplate,
+		"index.html":              indexTemplate,
+		"admin-dashboard.html":    adminTemplate,
+		"login.html":              loginTemplate,
+		"menu-form.html":          menuFormTemplate,
+		"admin-categories.html":   categoriesTemplate,
+		"admin-tags.html":         tagsTemplate,
+		"admin-extras.html":       extrasTemplate,
+		"admin-trash.html":        trashTemplate,
+		"admin-history.html":      historyTemplate,
+		"admin-prices.html":       pricesTemplate,
+		"admin-price-adjust.html": priceAdjustTemplate,
+		"admin-import.html":       importTemplate,
+		"admin-hours.html":        hoursTemplate,
+		"admin-sessions.html":     sessionsTemplate,
+		"admin-users.html":        usersTemplate,
+		"admin-passkeys.html":     passkeysTemplate,
 	}
 
 	return templateCache
@@ -83,6 +176,11 @@ func NewTestAppServices(t *testing.T) *AppServices {
 		AllowedEmails: []string{"test@example.com", "admin@example.com"},
 	}
 
+	// Seed the allowed emails as owners, like the application does on its first start
+	if _, err := testDBModel.BootstrapAdminUsers(testOAuthConfig.AllowedEmails); err != nil {
+		t.Fatalf("failed to seed admin users: %v", err)
+	}
+
 	return &AppServices{
 		DB:            testDBModel,
 		TemplateCache: templateCache,
@@ -104,18 +202,128 @@ func createTestDB() (*sql.DB, error) {
 	}
 
 	_, err = db.Exec(`
+		CREATE TABLE categories (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			slug TEXT NOT NULL UNIQUE,
+			name_it TEXT NOT NULL,
+			name_de TEXT NOT NULL DEFAULT '',
+			description TEXT NOT NULL DEFAULT '',
+			position INTEGER NOT NULL DEFAULT 0,
+			visible BOOLEAN NOT NULL DEFAULT 1,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
 		CREATE TABLE menu_items (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			name TEXT NOT NULL,
 			description TEXT,
-			price REAL NOT NULL,
-			small_price REAL,
-			category TEXT NOT NULL,
+			category_id INTEGER REFERENCES categories(id),
 			image_url TEXT,
+			position INTEGER NOT NULL DEFAULT 0,
+			availability TEXT NOT NULL DEFAULT 'available',
+			sold_out_until DATE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP
+		);
+
+		CREATE TABLE menu_item_windows (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			menu_item_id INTEGER NOT NULL,
+			weekday INTEGER NOT NULL,
+			start_time TEXT NOT NULL,
+			end_time TEXT NOT NULL
+		);
+
+		CREATE TABLE menu_item_revisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			menu_item_id INTEGER NOT NULL,
+			action TEXT NOT NULL,
+			snapshot TEXT NOT NULL,
+			changed_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE menu_item_variants (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			menu_item_id INTEGER NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+			label TEXT NOT NULL DEFAULT '',
+			price INTEGER NOT NULL,
+			position INTEGER NOT NULL DEFAULT 0,
+			is_default BOOLEAN NOT NULL DEFAULT 0
+		);
+
+		CREATE TABLE price_schedules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			variant_id INTEGER NOT NULL,
+			price INTEGER NOT NULL,
+			effective_from DATE NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE UNIQUE INDEX idx_price_schedules_variant_date ON price_schedules(variant_id, effective_from);
+
+		CREATE TABLE allergens (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			code TEXT NOT NULL UNIQUE,
+			name TEXT NOT NULL,
+			position INTEGER NOT NULL DEFAULT 0
+		);
+
+		CREATE TABLE additives (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			code TEXT NOT NULL UNIQUE,
+			name TEXT NOT NULL,
+			position INTEGER NOT NULL DEFAULT 0
+		);
+
+		CREATE TABLE menu_item_allergens (
+			menu_item_id INTEGER NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+			allergen_id INTEGER NOT NULL REFERENCES allergens(id) ON DELETE CASCADE,
+			PRIMARY KEY (menu_item_id, allergen_id)
+		);
+
+		CREATE TABLE menu_item_additives (
+			menu_item_id INTEGER NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+			additive_id INTEGER NOT NULL REFERENCES additives(id) ON DELETE CASCADE,
+			PRIMARY KEY (menu_item_id, additive_id)
+		);
+
+		CREATE TABLE tags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			slug TEXT NOT NULL UNIQUE,
+			name TEXT NOT NULL,
+			position INTEGER NOT NULL DEFAULT 0
+		);
+
+		CREATE TABLE menu_item_tags (
+			menu_item_id INTEGER NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+			tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+			PRIMARY KEY (menu_item_id, tag_id)
+		);
+
+		CREATE TABLE extras (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			price INTEGER NOT NULL,
+			small_price INTEGER,
+			position INTEGER NOT NULL DEFAULT 0,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		);
 
+		CREATE TABLE extra_assignments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			extra_id INTEGER NOT NULL REFERENCES extras(id) ON DELETE CASCADE,
+			menu_item_id INTEGER REFERENCES menu_items(id) ON DELETE CASCADE,
+			category_id INTEGER REFERENCES categories(id) ON DELETE CASCADE,
+			CHECK ((menu_item_id IS NULL) <> (category_id IS NULL))
+		);
+
+		CREATE UNIQUE INDEX idx_extra_assignments_item ON extra_assignments(extra_id, menu_item_id) WHERE menu_item_id IS NOT NULL;
+		CREATE UNIQUE INDEX idx_extra_assignments_category ON extra_assignments(extra_id, category_id) WHERE category_id IS NOT NULL;
+
 		CREATE TABLE flash_messages (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			type TEXT NOT NULL,
@@ -126,6 +334,84 @@ func createTestDB() (*sql.DB, error) {
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		);
+
+		CREATE TABLE api_tokens (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			prefix TEXT NOT NULL,
+			token_hash TEXT NOT NULL UNIQUE,
+			created_by TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_used_at TIMESTAMP,
+			revoked_at TIMESTAMP
+		);
+
+		CREATE TABLE translations (
+			entity_type TEXT NOT NULL,
+			entity_id INTEGER NOT NULL,
+			field TEXT NOT NULL,
+			lang TEXT NOT NULL,
+			value TEXT NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (entity_type, entity_id, field, lang)
+		);
+
+		CREATE TABLE opening_hours (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			weekday INTEGER NOT NULL,
+			opens TEXT NOT NULL,
+			closes TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE opening_exceptions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			start_date DATE NOT NULL,
+			end_date DATE NOT NULL,
+			closed BOOLEAN NOT NULL DEFAULT 1,
+			opens TEXT NOT NULL DEFAULT '',
+			closes TEXT NOT NULL DEFAULT '',
+			note TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE sessions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			token_hash TEXT NOT NULL UNIQUE,
+			email TEXT NOT NULL,
+			ip TEXT NOT NULL DEFAULT '',
+			user_agent TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at TIMESTAMP NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			revoked_at TIMESTAMP
+		);
+
+		CREATE TABLE admin_users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			email TEXT NOT NULL UNIQUE,
+			role TEXT NOT NULL CHECK (role IN ('owner', 'manager', 'staff')),
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE passkeys (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			email TEXT NOT NULL,
+			name TEXT NOT NULL DEFAULT '',
+			credential_id BLOB NOT NULL UNIQUE,
+			public_key BLOB NOT NULL,
+			sign_count INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_used_at TIMESTAMP
+		);
+
+		CREATE TABLE passkey_challenges (
+			challenge_hash TEXT PRIMARY KEY,
+			email TEXT NOT NULL DEFAULT '',
+			client_ip TEXT NOT NULL DEFAULT '',
+			expires_at TIMESTAMP NOT NULL
+		);
 	`)
 
 	if err != nil {
@@ -0,0 +1,203 @@
+// Package hours works out when the restaurant is open from the weekly opening hours and the
+// holidays, vacations and special hours kept in the database.
+package hours
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+// Location is the time zone of the restaurant. Opening hours are wall clock times in it.
+var Location = models.Location
+
+// LookaheadDays is how far ahead Status searches for the next opening, long enough to get past a vacation
+const LookaheadDays = 62
+
+// Schedule holds the weekly opening slots and the exceptions that replace them on some days.
+// Exceptions only count for the days they cover, so loading those around the time of interest is enough.
+type Schedule struct {
+	Slots      []models.OpeningSlot
+	Exceptions []models.OpeningException
+}
+
+// Period is a span of time in which the restaurant is open without a break
+type Period struct {
+	Opens  time.Time
+	Closes time.Time
+}
+
+// Status tells guests whether the restaurant is open at a moment and when that changes.
+// All times are in Location; zero times mean no opening within LookaheadDays.
+type Status struct {
+	// Open reports whether the restaurant is open
+	Open bool
+	// OpensAt is the next opening: after ClosesAt when open, otherwise the next time the doors open
+	OpensAt time.Time
+	// ClosesAt is when the current opening ends, or when closed, when the next one will end
+	ClosesAt time.Time
+
+	now time.Time
+}
+
+// OpensToday reports whether a closed restaurant opens later on the same day
+func (s Status) OpensToday() bool {
+	return !s.Open && !s.OpensAt.IsZero() && sameDay(s.OpensAt, s.now)
+}
+
+// OpensTomorrow reports whether a closed restaurant opens on the next day
+func (s Status) OpensTomorrow() bool {
+	return !s.Open && !s.OpensAt.IsZero() && sameDay(s.OpensAt, s.now.AddDate(0, 0, 1))
+}
+
+// sameDay reports whether a and b fall on the same calendar day
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+
+	return ay == by && am == bm && ad == bd
+}
+
+// Status works out whether the restaurant is open at now, and when it next opens and closes
+func (s Schedule) Status(now time.Time) Status {
+	now = now.In(Location)
+	status := Status{now: now}
+
+	for _, p := range s.Periods(now, LookaheadDays) {
+		switch {
+		case !p.Opens.After(now) && now.Before(p.Closes):
+			status.Open = true
+			status.ClosesAt = p.Closes
+
+		case p.Opens.After(now):
+			status.OpensAt = p.Opens
+			if !status.Open {
+				status.ClosesAt = p.Closes
+			}
+
+			return status
+		}
+	}
+
+	return status
+}
+
+// Periods returns the openings from the day before from until days after it, in order. Slots
+// running past midnight or touching each other are joined into one period.
+func (s Schedule) Periods(from time.Time, days int) []Period {
+	from = from.In(Location)
+
+	var periods []Period
+
+	// Start a day early to catch a slot of the previous evening running past midnight
+	for offset := -1; offset <= days; offset++ {
+		day := time.Date(from.Year(), from.Month(), from.Day()+offset, 0, 0, 0, 0, Location)
+
+		for _, slot := range s.slotsOn(day) {
+			opens := clockOn(day, slot.Opens)
+			closes := clockOn(day, slot.Closes)
+
+			// A slot closing at or before its opening time ends on the next day
+			if slot.Closes <= slot.Opens {
+				closes = clockOn(day.AddDate(0, 0, 1), slot.Closes)
+			}
+
+			periods = append(periods, Period{Opens: opens, Closes: closes})
+		}
+	}
+
+	sort.SliceStable(periods, func(i, j int) bool {
+		return periods[i].Opens.Before(periods[j].Opens)
+	})
+
+	var joined []Period
+
+	for _, p := range periods {
+		if n := len(joined); n > 0 && !p.Opens.After(joined[n-1].Closes) {
+			if p.Closes.After(joined[n-1].Closes) {
+				joined[n-1].Closes = p.Closes
+			}
+
+			continue
+		}
+
+		joined = append(joined, p)
+	}
+
+	return joined
+}
+
+// slotsOn returns the opening slots of a day: none on a closed day, the special hours if there are
+// any, and the weekly slots otherwise
+func (s Schedule) slotsOn(day time.Time) []models.OpeningSlot {
+	var special []models.OpeningSlot
+
+	for _, e := range s.Exceptions {
+		if !e.Covers(day) {
+			continue
+		}
+
+		if e.Closed {
+			return nil
+		}
+
+		special = append(special, models.OpeningSlot{Weekday: day.Weekday(), Opens: e.Opens, Closes: e.Closes})
+	}
+
+	if special != nil {
+		return special
+	}
+
+	var slots []models.OpeningSlot
+
+	for _, slot := range s.Slots {
+		if slot.Weekday == day.Weekday() {
+			slots = append(slots, slot)
+		}
+	}
+
+	return slots
+}
+
+// Closure returns the closure in effect on the day of t, or nil when the restaurant is not closed
+// for a holiday or vacation that day
+func (s Schedule) Closure(t time.Time) *models.OpeningException {
+	t = t.In(Location)
+
+	for i := range s.Exceptions {
+		if s.Exceptions[i].Closed && s.Exceptions[i].Covers(t) {
+			return &s.Exceptions[i]
+		}
+	}
+
+	return nil
+}
+
+// ExceptionsBetween returns the exceptions overlapping the days from from to until
+func (s Schedule) ExceptionsBetween(from, until time.Time) []models.OpeningException {
+	first := from.In(Location).Format("2006-01-02")
+	last := until.In(Location).Format("2006-01-02")
+
+	var exceptions []models.OpeningException
+
+	for _, e := range s.Exceptions {
+		if e.EndDate.Format("2006-01-02") >= first && e.StartDate.Format("2006-01-02") <= last {
+			exceptions = append(exceptions, e)
+		}
+	}
+
+	return exceptions
+}
+
+// clockOn returns the wall clock time "HH:MM" on day. A time skipped when the clocks go forward
+// is moved forward by the length of the gap, like time.Date does.
+func clockOn(day time.Time, clock string) time.Time {
+	hour, minute, _ := strings.Cut(clock, ":")
+	h, _ := strconv.Atoi(hour)
+	m, _ := strconv.Atoi(minute)
+
+	return time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, Location)
+}
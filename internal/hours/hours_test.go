@@ -0,0 +1,206 @@
+package hours
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+// berlin returns a wall clock time in the restaurant's time zone
+func berlin(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, Location)
+}
+
+// date returns a calendar day as stored for exceptions
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestSchedule_Status(t *testing.T) {
+	weekly := []models.OpeningSlot{
+		{Weekday: time.Tuesday, Opens: "17:00", Closes: "22:00"},
+		{Weekday: time.Friday, Opens: "17:00", Closes: "00:00"},
+		{Weekday: time.Saturday, Opens: "20:00", Closes: "03:00"},
+		{Weekday: time.Sunday, Opens: "11:30", Closes: "14:00"},
+		{Weekday: time.Sunday, Opens: "17:00", Closes: "21:30"},
+	}
+
+	tests := []struct {
+		name       string
+		exceptions []models.OpeningException
+		now        time.Time
+		wantOpen   bool
+		wantOpens  time.Time
+		wantCloses time.Time
+	}{
+		{
+			name:       "Open on Tuesday evening",
+			now:        berlin(2025, time.June, 17, 18, 0),
+			wantOpen:   true,
+			wantOpens:  berlin(2025, time.June, 20, 17, 0),
+			wantCloses: berlin(2025, time.June, 17, 22, 0),
+		},
+		{
+			name:       "Closing time is exclusive",
+			now:        berlin(2025, time.June, 17, 22, 0),
+			wantOpens:  berlin(2025, time.June, 20, 17, 0),
+			wantCloses: berlin(2025, time.June, 21, 0, 0),
+		},
+		{
+			name:       "Closed Monday opens on Tuesday",
+			now:        berlin(2025, time.June, 16, 12, 0),
+			wantOpens:  berlin(2025, time.June, 17, 17, 0),
+			wantCloses: berlin(2025, time.June, 17, 22, 0),
+		},
+		{
+			name:       "Open a minute before midnight",
+			now:        berlin(2025, time.June, 20, 23, 59),
+			wantOpen:   true,
+			wantOpens:  berlin(2025, time.June, 21, 20, 0),
+			wantCloses: berlin(2025, time.June, 21, 0, 0),
+		},
+		{
+			name:       "Closed at midnight",
+			now:        berlin(2025, time.June, 21, 0, 0),
+			wantOpens:  berlin(2025, time.June, 21, 20, 0),
+			wantCloses: berlin(2025, time.June, 22, 3, 0),
+		},
+		{
+			name:       "Saturday slot runs into Sunday",
+			now:        berlin(2025, time.June, 22, 1, 30),
+			wantOpen:   true,
+			wantOpens:  berlin(2025, time.June, 22, 11, 30),
+			wantCloses: berlin(2025, time.June, 22, 3, 0),
+		},
+		{
+			name:       "Between Sunday lunch and dinner",
+			now:        berlin(2025, time.June, 22, 15, 0),
+			wantOpens:  berlin(2025, time.June, 22, 17, 0),
+			wantCloses: berlin(2025, time.June, 22, 21, 30),
+		},
+		{
+			name:       "Time given in UTC during summer time",
+			now:        time.Date(2025, time.June, 17, 15, 30, 0, 0, time.UTC), // 17:30 CEST
+			wantOpen:   true,
+			wantOpens:  berlin(2025, time.June, 20, 17, 0),
+			wantCloses: berlin(2025, time.June, 17, 22, 0),
+		},
+		{
+			name:       "Time given in UTC during winter time",
+			now:        time.Date(2025, time.January, 14, 15, 30, 0, 0, time.UTC), // 16:30 CET
+			wantOpens:  berlin(2025, time.January, 14, 17, 0),
+			wantCloses: berlin(2025, time.January, 14, 22, 0),
+		},
+		{
+			// Clocks go forward at 02:00 on 30 March 2025, so the night is an hour shorter
+			name:       "Open across the switch to summer time",
+			now:        time.Date(2025, time.March, 29, 23, 30, 0, 0, time.UTC), // 00:30 CET
+			wantOpen:   true,
+			wantOpens:  berlin(2025, time.March, 30, 11, 30),
+			wantCloses: time.Date(2025, time.March, 30, 1, 0, 0, 0, time.UTC), // 03:00 CEST
+		},
+		{
+			name:       "Closed after the switch to summer time",
+			now:        time.Date(2025, time.March, 30, 1, 30, 0, 0, time.UTC), // 03:30 CEST
+			wantOpens:  time.Date(2025, time.March, 30, 9, 30, 0, 0, time.UTC), // 11:30 CEST
+			wantCloses: time.Date(2025, time.March, 30, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			// Clocks go back at 03:00 on 26 October 2025, so the night is an hour longer
+			name:       "Still open in the repeated hour",
+			now:        time.Date(2025, time.October, 26, 1, 30, 0, 0, time.UTC), // second 02:30, CET
+			wantOpen:   true,
+			wantOpens:  berlin(2025, time.October, 26, 11, 30),
+			wantCloses: time.Date(2025, time.October, 26, 2, 0, 0, 0, time.UTC), // 03:00 CET
+		},
+		{
+			name:       "Closed after the switch to winter time",
+			now:        time.Date(2025, time.October, 26, 2, 0, 0, 0, time.UTC),   // 03:00 CET
+			wantOpens:  time.Date(2025, time.October, 26, 10, 30, 0, 0, time.UTC), // 11:30 CET
+			wantCloses: time.Date(2025, time.October, 26, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "Holiday closes the whole day",
+			exceptions: []models.OpeningException{{StartDate: date(2025, time.June, 17), EndDate: date(2025, time.June, 17), Closed: true}},
+			now:        berlin(2025, time.June, 17, 18, 0),
+			wantOpens:  berlin(2025, time.June, 20, 17, 0),
+			wantCloses: berlin(2025, time.June, 21, 0, 0),
+		},
+		{
+			name:       "Vacation over several weeks",
+			exceptions: []models.OpeningException{{StartDate: date(2025, time.August, 1), EndDate: date(2025, time.August, 24), Closed: true}},
+			now:        berlin(2025, time.August, 1, 12, 0),
+			wantOpens:  berlin(2025, time.August, 26, 17, 0),
+			wantCloses: berlin(2025, time.August, 26, 22, 0),
+		},
+		{
+			name:       "Special hours replace the weekly hours",
+			exceptions: []models.OpeningException{{StartDate: date(2025, time.December, 31), EndDate: date(2025, time.December, 31), Opens: "12:00", Closes: "18:00"}},
+			now:        berlin(2025, time.December, 31, 12, 0),
+			wantOpen:   true,
+			wantOpens:  berlin(2026, time.January, 2, 17, 0),
+			wantCloses: berlin(2025, time.December, 31, 18, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Schedule{Slots: weekly, Exceptions: tt.exceptions}.Status(tt.now)
+
+			if got.Open != tt.wantOpen {
+				t.Errorf("Open = %v, want %v", got.Open, tt.wantOpen)
+			}
+
+			if !got.OpensAt.Equal(tt.wantOpens) {
+				t.Errorf("OpensAt = %v, want %v", got.OpensAt, tt.wantOpens)
+			}
+
+			if !got.ClosesAt.Equal(tt.wantCloses) {
+				t.Errorf("ClosesAt = %v, want %v", got.ClosesAt, tt.wantCloses)
+			}
+
+			if !got.OpensAt.IsZero() && got.OpensAt.Location() != Location {
+				t.Errorf("OpensAt is in %v, want %v", got.OpensAt.Location(), Location)
+			}
+		})
+	}
+}
+
+func TestSchedule_StatusWithoutHours(t *testing.T) {
+	got := Schedule{}.Status(berlin(2025, time.June, 17, 18, 0))
+
+	if got.Open || !got.OpensAt.IsZero() || !got.ClosesAt.IsZero() {
+		t.Errorf("Status() without opening hours = %+v, want closed with no times", got)
+	}
+}
+
+func TestStatus_OpensTodayTomorrow(t *testing.T) {
+	schedule := Schedule{Slots: []models.OpeningSlot{
+		{Weekday: time.Tuesday, Opens: "17:00", Closes: "22:00"},
+		{Weekday: time.Wednesday, Opens: "17:00", Closes: "22:00"},
+	}}
+
+	tests := []struct {
+		name         string
+		now          time.Time
+		wantToday    bool
+		wantTomorrow bool
+	}{
+		{name: "Tuesday afternoon", now: berlin(2025, time.June, 17, 12, 0), wantToday: true},
+		{name: "Tuesday night", now: berlin(2025, time.June, 17, 23, 0), wantTomorrow: true},
+		{name: "Open", now: berlin(2025, time.June, 17, 18, 0)},
+		{name: "Thursday", now: berlin(2025, time.June, 19, 12, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := schedule.Status(tt.now)
+
+			if got.OpensToday() != tt.wantToday || got.OpensTomorrow() != tt.wantTomorrow {
+				t.Errorf("OpensToday() = %v, OpensTomorrow() = %v, want %v, %v",
+					got.OpensToday(), got.OpensTomorrow(), tt.wantToday, tt.wantTomorrow)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path"
+	"strings"
+)
+
+// The message catalogs, one JSON file per language named after its code, e.g. "it.json".
+// A message is either a string or, when it depends on a number, an object with the plural
+// forms "one" and "other". Messages are fmt format strings.
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// message is a catalog entry. Messages without plural forms only have Other.
+type message struct {
+	One   string `json:"one"`
+	Other string `json:"other"`
+}
+
+// UnmarshalJSON accepts a plain string as well as an object with plural forms
+func (msg *message) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		msg.One = ""
+		return json.Unmarshal(data, &msg.Other)
+	}
+
+	type forms message
+
+	return json.Unmarshal(data, (*forms)(msg))
+}
+
+// catalogs holds the messages of every language by key
+var catalogs = mustLoadCatalogs()
+
+// mustLoadCatalogs reads the embedded catalogs. They are part of the binary, so a broken
+// catalog is a programming error and stops the program at startup.
+func mustLoadCatalogs() map[string]map[string]message {
+	loaded := make(map[string]map[string]message)
+
+	for _, lang := range Languages {
+		data, err := localeFiles.ReadFile(path.Join("locales", lang+".json"))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: missing catalog for %q: %v", lang, err))
+		}
+
+		var catalog map[string]message
+
+		err = json.Unmarshal(data, &catalog)
+		if err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s.json: %v", lang, err))
+		}
+
+		loaded[lang] = catalog
+	}
+
+	return loaded
+}
+
+// lookup finds a message in lang, falling back to the default language
+func lookup(lang, key string) (message, bool) {
+	if msg, ok := catalogs[lang][key]; ok {
+		return msg, true
+	}
+
+	msg, ok := catalogs[Default][key]
+
+	return msg, ok
+}
+
+// T returns the message for key in lang, formatted with args. Unknown keys are returned as they
+// are, so a missing message is visible on the page rather than an empty spot.
+func T(lang, key string, args ...any) string {
+	msg, ok := lookup(lang, key)
+	if !ok {
+		return key
+	}
+
+	if len(args) == 0 {
+		return msg.Other
+	}
+
+	return fmt.Sprintf(msg.Other, args...)
+}
+
+// N returns the plural form of the message for key that fits n, formatted with n and then args,
+// e.g. N("en", "filter.matching", 3) returns "3 dishes"
+func N(lang, key string, n int, args ...any) string {
+	msg, ok := lookup(lang, key)
+	if !ok {
+		return key
+	}
+
+	form := msg.Other
+	if n == 1 && msg.One != "" {
+		form = msg.One
+	}
+
+	return fmt.Sprintf(form, append([]any{n}, args...)...)
+}
+
+// Label translates a label entered by the admins, such as the size "klein", using the messages
+// "<group>.<label in lower case>". Labels without a message are returned unchanged.
+func Label(lang, group, label string) string {
+	msg, ok := lookup(lang, group+"."+strings.ToLower(strings.TrimSpace(label)))
+	if !ok {
+		return label
+	}
+
+	return msg.Other
+}
+
+// FuncMap returns the template functions translating into lang:
+//
+//	{{ t "menu.title" }}                   message
+//	{{ t "item.sold_out_until" .Date }}    message with arguments
+//	{{ tn "filter.matching" .Count }}      plural form for a number
+//	{{ tlabel "size" .Label }}             label entered by the admins
+func FuncMap(lang string) template.FuncMap {
+	return template.FuncMap{
+		"t": func(key string, args ...any) string {
+			return T(lang, key, args...)
+		},
+		"tn": func(key string, n int, args ...any) string {
+			return N(lang, key, n, args...)
+		},
+		"tlabel": func(group, label string) string {
+			return Label(lang, group, label)
+		},
+	}
+}
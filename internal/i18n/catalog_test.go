@@ -0,0 +1,107 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// formatVerb matches the fmt verbs of a message, e.g. "%s" or "%d"
+var formatVerb = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z]`)
+
+func TestCatalogsComplete(t *testing.T) {
+	for _, lang := range Languages {
+		if catalogs[lang] == nil {
+			t.Fatalf("no catalog for %q", lang)
+		}
+	}
+
+	// Every language has the same keys as the default one, with matching plural forms and arguments
+	for _, lang := range Translated() {
+		for key, want := range catalogs[Default] {
+			got, ok := catalogs[lang][key]
+			if !ok {
+				t.Errorf("%s.json: missing key %q", lang, key)
+				continue
+			}
+
+			if (got.One == "") != (want.One == "") {
+				t.Errorf("%s.json: key %q has plural forms in one catalog only", lang, key)
+			}
+
+			for _, form := range []struct{ got, want string }{{got.One, want.One}, {got.Other, want.Other}} {
+				if g, w := formatVerb.FindAllString(form.got, -1), formatVerb.FindAllString(form.want, -1); strings.Join(g, "") != strings.Join(w, "") {
+					t.Errorf("%s.json: key %q uses %v, %s.json uses %v", lang, key, g, Default, w)
+				}
+			}
+		}
+
+		for key := range catalogs[lang] {
+			if _, ok := catalogs[Default][key]; !ok {
+				t.Errorf("%s.json: key %q is not in %s.json", lang, key, Default)
+			}
+		}
+	}
+}
+
+func TestTemplateKeys(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "templates", "*.html"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no templates found: %v", err)
+	}
+
+	calls := regexp.MustCompile(`\b(t|tn|tlabel) "([^"]+)"`)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, call := range calls.FindAllStringSubmatch(string(data), -1) {
+			fn, key := call[1], call[2]
+
+			if fn == "tlabel" {
+				found := false
+
+				for k := range catalogs[Default] {
+					found = found || strings.HasPrefix(k, key+".")
+				}
+
+				if !found {
+					t.Errorf("%s: no messages for label group %q", filepath.Base(file), key)
+				}
+
+				continue
+			}
+
+			if _, ok := catalogs[Default][key]; !ok {
+				t.Errorf("%s: key %q is not in the catalogs", filepath.Base(file), key)
+			}
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{T(English, "menu.title"), "Our Menu"},
+		{T(Italian, "item.sold_out_until", "03.06."), "Esaurito fino al 03.06."},
+		{T(English, "no.such.key"), "no.such.key"},
+		{T("fr", "menu.title"), "Unsere Speisekarte"},
+		{N(German, "filter.matching", 1), "1 passendes Gericht"},
+		{N(German, "filter.matching", 0), "0 passende Gerichte"},
+		{N(English, "filter.matching", 12), "12 matching dishes"},
+		{Label(English, "size", "Klein"), "small"},
+		{Label(English, "size", "0,5l"), "0,5l"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("got %q, want %q", tt.got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Package i18n knows the languages the website is offered in and picks one for a visitor.
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Languages of the website, as ISO 639-1 codes
+const (
+	German  = "de"
+	Italian = "it"
+	English = "en"
+)
+
+// Default is the language the menu is written in. Text that has not been translated is shown in it.
+const Default = German
+
+// Languages lists the supported languages, the default first
+var Languages = []string{German, Italian, English}
+
+// names are the language names as shown in the language switcher, each in its own language
+var names = map[string]string{
+	German:  "Deutsch",
+	Italian: "Italiano",
+	English: "English",
+}
+
+// IsSupported reports whether lang is one of the supported languages
+func IsSupported(lang string) bool {
+	_, ok := names[lang]
+	return ok
+}
+
+// Name returns the name of a language in that language, e.g. "Italiano"
+func Name(lang string) string {
+	return names[lang]
+}
+
+// Translated returns the supported languages other than the default, i.e. those that need translations
+func Translated() []string {
+	return Languages[1:]
+}
+
+// Negotiate picks the supported language the visitor prefers most according to an
+// Accept-Language header, e.g. "it-IT,it;q=0.9,en;q=0.8". Regional variants match their
+// base language. Without a match the default language is returned.
+func Negotiate(acceptLanguage string) string {
+	type preference struct {
+		lang    string
+		quality float64
+	}
+
+	var prefs []preference
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		quality := 1.0
+
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+
+			quality = parsed
+		}
+
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if quality <= 0 || !IsSupported(base) {
+			continue
+		}
+
+		prefs = append(prefs, preference{base, quality})
+	}
+
+	// Languages with the same quality keep the order of the header
+	sort.SliceStable(prefs, func(i, j int) bool {
+		return prefs[i].quality > prefs[j].quality
+	})
+
+	if len(prefs) == 0 {
+		return Default
+	}
+
+	return prefs[0].lang
+}
@@ -0,0 +1,26 @@
+package i18n
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", German},
+		{"it-IT,it;q=0.9,en;q=0.8", Italian},
+		{"en-US,en;q=0.9,de;q=0.8", English},
+		{"fr-FR,fr;q=0.9,en;q=0.5,de;q=0.7", German},
+		{"fr, es", German},
+		{"de;q=0.2, EN-gb;q=0.8", English},
+		{"en;q=0, it", Italian},
+		{"en;q=abc, it;q=0.1", Italian},
+		{"*", German},
+	}
+
+	for _, tt := range tests {
+		if got := Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
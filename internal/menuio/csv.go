@@ -0,0 +1,201 @@
+package menuio
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// csvColumns are the columns of a CSV export. Lists of codes, tags and extras are comma separated;
+// prices and serving times are separated by semicolons because prices contain commas.
+var csvColumns = []string{
+	"id", "name", "description", "category", "prices", "tags", "allergens", "additives", "extras",
+	"availability", "sold_out_until", "serving_times", "image_url",
+}
+
+// WriteCSV writes the items as CSV with a header row. Prices are written as "klein 6,50; normal 8,00*"
+// with the default size marked by a star, or just "8,50" for a single price.
+func WriteCSV(w io.Writer, items []Item) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write(csvColumns)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		id := ""
+		if item.ID != 0 {
+			id = strconv.Itoa(item.ID)
+		}
+
+		err = cw.Write([]string{
+			id,
+			item.Name,
+			item.Description,
+			item.Category,
+			formatPrices(item.Prices),
+			strings.Join(item.Tags, ", "),
+			strings.Join(item.Allergens, ", "),
+			strings.Join(item.Additives, ", "),
+			strings.Join(item.Extras, ", "),
+			item.Availability,
+			item.SoldOutUntil,
+			strings.Join(item.ServingTimes, "; "),
+			item.ImageURL,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// ReadCSV reads items from CSV written by WriteCSV. Columns may be in any order and all but
+// name, category and prices may be left out.
+func ReadCSV(r io.Reader) ([]Item, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the file is empty")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+
+	for i, name := range header {
+		// Spreadsheet programs may start the file with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !slices.Contains(csvColumns, name) {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+
+		columns[name] = i
+	}
+
+	for _, required := range []string{"name", "category", "prices"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+
+	var items []Item
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+
+			return ""
+		}
+
+		item := Item{
+			Name:         field("name"),
+			Description:  field("description"),
+			Category:     field("category"),
+			Tags:         splitList(field("tags"), ","),
+			Allergens:    splitList(field("allergens"), ","),
+			Additives:    splitList(field("additives"), ","),
+			Extras:       splitList(field("extras"), ","),
+			Availability: field("availability"),
+			SoldOutUntil: field("sold_out_until"),
+			ServingTimes: splitList(field("serving_times"), ";"),
+			ImageURL:     field("image_url"),
+		}
+
+		if id := field("id"); id != "" {
+			item.ID, err = strconv.Atoi(id)
+			if err != nil {
+				line, _ := cr.FieldPos(0)
+				return nil, fmt.Errorf("line %d: invalid ID %q", line, id)
+			}
+		}
+
+		item.Prices = parsePrices(field("prices"))
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// formatPrices formats the prices of an item for the prices column
+func formatPrices(prices []Price) string {
+	parts := make([]string, 0, len(prices))
+
+	for _, p := range prices {
+		part := p.Price
+		if p.Label != "" {
+			part = p.Label + " " + part
+		}
+
+		if p.Default && len(prices) > 1 {
+			part += "*"
+		}
+
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// parsePrices parses the prices column. The amount is the last word of each entry and the
+// label everything before it; the amount is validated when the item is resolved.
+func parsePrices(s string) []Price {
+	var prices []Price
+
+	for _, part := range splitList(s, ";") {
+		var p Price
+
+		if strings.HasSuffix(part, "*") {
+			p.Default = true
+			part = strings.TrimSpace(strings.TrimSuffix(part, "*"))
+		}
+
+		if i := strings.LastIndex(part, " "); i >= 0 {
+			p.Label = strings.TrimSpace(part[:i])
+			p.Price = strings.TrimSpace(part[i+1:])
+		} else {
+			p.Price = part
+		}
+
+		prices = append(prices, p)
+	}
+
+	return prices
+}
+
+// splitList splits a list column and drops empty entries
+func splitList(s, sep string) []string {
+	var list []string
+
+	for _, part := range strings.Split(s, sep) {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,411 @@
+package menuio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+// Item is one menu item in an export file. Categories, tags and extras are referenced by slug or name
+// and allergens and additives by code, so a file can be edited by hand and imported into another database.
+type Item struct {
+	ID           int      `json:"id,omitempty"` // Empty for new items; items without ID are matched by name
+	Name         string   `json:"name"`
+	Description  string   `json:"description,omitempty"`
+	Category     string   `json:"category"` // Category slug
+	Prices       []Price  `json:"prices"`
+	Tags         []string `json:"tags,omitempty"`           // Tag slugs
+	Allergens    []string `json:"allergens,omitempty"`      // Allergen codes, e.g. "A"
+	Additives    []string `json:"additives,omitempty"`      // Additive codes, e.g. "1"
+	Extras       []string `json:"extras,omitempty"`         // Names of the extras assigned to the item itself
+	Availability string   `json:"availability,omitempty"`   // available, sold_out or hidden; empty means available
+	SoldOutUntil string   `json:"sold_out_until,omitempty"` // YYYY-MM-DD
+	ServingTimes []string `json:"serving_times,omitempty"`  // e.g. "Sun 11:30-14:00"
+	ImageURL     string   `json:"image_url,omitempty"`
+}
+
+// Price is one size of an item. Amounts are written German style ("8,50") and read in either notation.
+type Price struct {
+	Label   string `json:"label,omitempty"`
+	Price   string `json:"price"`
+	Default bool   `json:"default,omitempty"`
+}
+
+// document is the top level of a JSON export
+type document struct {
+	Items []Item `json:"items"`
+}
+
+// FromMenuItem converts a menu item for export
+func FromMenuItem(item models.MenuItem) Item {
+	out := Item{
+		ID:          item.ID,
+		Name:        item.Name,
+		Description: item.Description,
+		Category:    item.CategorySlug,
+		ImageURL:    item.ImageURL,
+	}
+
+	for _, v := range item.Variants {
+		out.Prices = append(out.Prices, Price{Label: v.Label, Price: v.Price.Amount(), Default: v.IsDefault})
+	}
+
+	for _, t := range item.Tags {
+		out.Tags = append(out.Tags, t.Slug)
+	}
+
+	for _, a := range item.Allergens {
+		out.Allergens = append(out.Allergens, a.Code)
+	}
+
+	for _, a := range item.Additives {
+		out.Additives = append(out.Additives, a.Code)
+	}
+
+	for _, e := range item.ItemExtras() {
+		out.Extras = append(out.Extras, e.Name)
+	}
+
+	if item.Availability != models.AvailabilityAvailable {
+		out.Availability = string(item.Availability)
+	}
+
+	if item.Availability == models.AvailabilitySoldOut && item.SoldOutUntil != nil {
+		out.SoldOutUntil = item.SoldOutUntil.Format(time.DateOnly)
+	}
+
+	for _, w := range item.Windows {
+		out.ServingTimes = append(out.ServingTimes, formatWindow(w))
+	}
+
+	return out
+}
+
+// FromMenuItems converts a whole menu for export
+func FromMenuItems(items []models.MenuItem) []Item {
+	out := make([]Item, 0, len(items))
+	for _, item := range items {
+		out = append(out, FromMenuItem(item))
+	}
+
+	return out
+}
+
+// WriteJSON writes the items as an indented JSON document
+func WriteJSON(w io.Writer, items []Item) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(document{Items: items})
+}
+
+// ReadJSON reads the items of a JSON document written by WriteJSON
+func ReadJSON(r io.Reader) ([]Item, error) {
+	var doc document
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	return doc.Items, nil
+}
+
+// formatWindow formats a serving time, e.g. "Sun 11:30-14:00"
+func formatWindow(w models.AvailabilityWindow) string {
+	return fmt.Sprintf("%s %s-%s", w.Weekday.String()[:3], w.Start, w.End)
+}
+
+// parseWindow parses a serving time written by formatWindow
+func parseWindow(s string) (models.AvailabilityWindow, error) {
+	var w models.AvailabilityWindow
+
+	day, span, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		return w, fmt.Errorf("invalid serving time %q", s)
+	}
+
+	weekday := -1
+
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(day, d.String()[:3]) {
+			weekday = int(d)
+		}
+	}
+
+	if weekday < 0 {
+		return w, fmt.Errorf("invalid weekday in serving time %q", s)
+	}
+
+	startStr, endStr, ok := strings.Cut(strings.TrimSpace(span), "-")
+	if !ok {
+		return w, fmt.Errorf("invalid serving time %q", s)
+	}
+
+	start, err := time.Parse("15:04", strings.TrimSpace(startStr))
+	if err != nil {
+		return w, fmt.Errorf("invalid serving time %q", s)
+	}
+
+	end, err := time.Parse("15:04", strings.TrimSpace(endStr))
+	if err != nil || !end.After(start) {
+		return w, fmt.Errorf("invalid serving time %q", s)
+	}
+
+	return models.AvailabilityWindow{
+		Weekday: time.Weekday(weekday),
+		Start:   start.Format("15:04"),
+		End:     end.Format("15:04"),
+	}, nil
+}
+
+// Catalog holds the categories, tags, declarations and extras that imported items refer to
+type Catalog struct {
+	Categories []models.Category
+	Tags       []models.Tag
+	Allergens  []models.Allergen
+	Additives  []models.Additive
+	Extras     []models.Extra
+}
+
+// ItemError is a problem with one item of an import file
+type ItemError struct {
+	Index int // 1-based position of the item in the file
+	Name  string
+	Err   error
+}
+
+func (e ItemError) Error() string {
+	return fmt.Sprintf("item %d (%q): %v", e.Index, e.Name, e.Err)
+}
+
+func (e ItemError) Unwrap() error {
+	return e.Err
+}
+
+// Resolve validates the imported items and converts them into menu items. Every item is checked,
+// so the returned errors list all problems of the file at once.
+func (c Catalog) Resolve(items []Item) ([]models.MenuItem, []error) {
+	var resolved []models.MenuItem
+
+	var errs []error
+
+	ids := make(map[int]bool)
+	names := make(map[string]bool)
+
+	for i, item := range items {
+		fail := func(err error) {
+			errs = append(errs, ItemError{Index: i + 1, Name: item.Name, Err: err})
+		}
+
+		menuItem, problems := c.resolveItem(item)
+		for _, p := range problems {
+			fail(p)
+		}
+
+		if item.ID != 0 {
+			if ids[item.ID] {
+				fail(fmt.Errorf("ID %d is used more than once", item.ID))
+			}
+
+			ids[item.ID] = true
+		}
+
+		// The same name may appear in different categories, e.g. a pizza and a calzone
+		key := strings.ToLower(strings.TrimSpace(item.Name)) + "\x00" + item.Category
+		if names[key] {
+			fail(errors.New("the category already has an item with this name"))
+		}
+
+		names[key] = true
+
+		if len(problems) == 0 {
+			resolved = append(resolved, menuItem)
+		}
+	}
+
+	return resolved, errs
+}
+
+// resolveItem converts one imported item, returning every problem found
+func (c Catalog) resolveItem(item Item) (models.MenuItem, []error) {
+	var problems []error
+
+	out := models.MenuItem{
+		ID:          item.ID,
+		Name:        strings.TrimSpace(item.Name),
+		Description: strings.TrimSpace(item.Description),
+		ImageURL:    strings.TrimSpace(item.ImageURL),
+	}
+
+	if out.Name == "" {
+		problems = append(problems, errors.New("name is required"))
+	}
+
+	categoryIndex := slices.IndexFunc(c.Categories, func(cat models.Category) bool { return cat.Slug == item.Category })
+	if categoryIndex < 0 {
+		problems = append(problems, fmt.Errorf("unknown category %q", item.Category))
+	} else {
+		out.CategoryID = c.Categories[categoryIndex].ID
+		out.Category = c.Categories[categoryIndex].NameIT
+		out.CategorySlug = c.Categories[categoryIndex].Slug
+	}
+
+	variants, err := resolvePrices(item.Prices)
+	if err != nil {
+		problems = append(problems, err)
+	}
+
+	out.Variants = variants
+
+	// Links are kept in catalog order, the order the menu lists them in
+	var unknown []string
+
+	out.Tags, unknown = pick(c.Tags, item.Tags, func(t models.Tag) string { return t.Slug })
+	for _, u := range unknown {
+		problems = append(problems, fmt.Errorf("unknown tag %q", u))
+	}
+
+	out.Allergens, unknown = pick(c.Allergens, item.Allergens, func(a models.Allergen) string { return a.Code })
+	for _, u := range unknown {
+		problems = append(problems, fmt.Errorf("unknown allergen %q", u))
+	}
+
+	out.Additives, unknown = pick(c.Additives, item.Additives, func(a models.Additive) string { return a.Code })
+	for _, u := range unknown {
+		problems = append(problems, fmt.Errorf("unknown additive %q", u))
+	}
+
+	out.Extras, unknown = pick(c.Extras, item.Extras, func(e models.Extra) string { return e.Name })
+	for _, u := range unknown {
+		problems = append(problems, fmt.Errorf("unknown extra %q", u))
+	}
+
+	out.Availability = models.Availability(item.Availability)
+	if out.Availability == "" {
+		out.Availability = models.AvailabilityAvailable
+	}
+
+	if !out.Availability.IsValid() {
+		problems = append(problems, fmt.Errorf("unknown availability %q", item.Availability))
+	}
+
+	if until := strings.TrimSpace(item.SoldOutUntil); until != "" && out.Availability == models.AvailabilitySoldOut {
+		date, err := time.Parse(time.DateOnly, until)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("invalid date %q", until))
+		} else {
+			out.SoldOutUntil = &date
+		}
+	}
+
+	for _, s := range item.ServingTimes {
+		w, err := parseWindow(s)
+		if err != nil {
+			problems = append(problems, err)
+			continue
+		}
+
+		out.Windows = append(out.Windows, w)
+	}
+
+	slices.SortStableFunc(out.Windows, func(a, b models.AvailabilityWindow) int {
+		if a.Weekday != b.Weekday {
+			return int(a.Weekday) - int(b.Weekday)
+		}
+
+		return strings.Compare(a.Start, b.Start)
+	})
+
+	return out, problems
+}
+
+// resolvePrices converts the prices of an item. Several prices need labels and exactly one
+// default; without one the first price is the default.
+func resolvePrices(prices []Price) ([]models.Variant, error) {
+	if len(prices) == 0 {
+		return nil, errors.New("at least one price is required")
+	}
+
+	variants := make([]models.Variant, 0, len(prices))
+	defaults := 0
+	labels := make(map[string]bool)
+
+	for _, p := range prices {
+		price, err := models.ParseMoney(p.Price)
+		if err != nil || price.Cents < 0 {
+			return nil, fmt.Errorf("invalid price %q", p.Price)
+		}
+
+		label := strings.TrimSpace(p.Label)
+
+		if len(prices) > 1 {
+			if label == "" {
+				return nil, errors.New("every size needs a label when there is more than one price")
+			}
+
+			if labels[strings.ToLower(label)] {
+				return nil, fmt.Errorf("size %q is listed twice", label)
+			}
+
+			labels[strings.ToLower(label)] = true
+		}
+
+		if p.Default {
+			defaults++
+		}
+
+		variants = append(variants, models.Variant{Label: label, Price: price, IsDefault: p.Default})
+	}
+
+	switch defaults {
+	case 0:
+		variants[0].IsDefault = true
+	case 1:
+	default:
+		return nil, errors.New("only one size can be the default")
+	}
+
+	return variants, nil
+}
+
+// pick returns the catalog entries whose key is listed, in catalog order, and the listed keys that
+// are not in the catalog. Keys are compared case-insensitively.
+func pick[T any](catalog []T, keys []string, key func(T) string) ([]T, []string) {
+	wanted := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		if k = strings.TrimSpace(k); k != "" {
+			wanted[strings.ToLower(k)] = true
+		}
+	}
+
+	var picked []T
+
+	for _, entry := range catalog {
+		k := strings.ToLower(key(entry))
+		if wanted[k] {
+			picked = append(picked, entry)
+			delete(wanted, k)
+		}
+	}
+
+	var unknown []string
+
+	for _, k := range keys {
+		if k = strings.TrimSpace(k); k != "" && wanted[strings.ToLower(k)] {
+			unknown = append(unknown, k)
+			delete(wanted, strings.ToLower(k))
+		}
+	}
+
+	return picked, unknown
+}
@@ -0,0 +1,154 @@
+package menuio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+func TestCSV_RoundTrip(t *testing.T) {
+	items := []Item{
+		{
+			ID:           7,
+			Name:         "Margherita",
+			Description:  "Tomaten, Mozzarella",
+			Category:     "pizza",
+			Prices:       []Price{{Label: "klein", Price: "6,50"}, {Label: "normal", Price: "8,00", Default: true}},
+			Tags:         []string{"vegetarian"},
+			Allergens:    []string{"A", "G"},
+			ServingTimes: []string{"Sun 11:30-14:00", "Sat 11:30-14:00"},
+		},
+		{
+			Name:         "Insalata",
+			Category:     "insalate",
+			Prices:       []Price{{Price: "7,50", Default: true}},
+			Availability: "sold_out",
+			SoldOutUntil: "2025-06-08",
+		},
+	}
+
+	var buf bytes.Buffer
+
+	err := WriteCSV(&buf, items)
+	if err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+
+	got, err := ReadCSV(&buf)
+	if err != nil {
+		t.Fatalf("ReadCSV() error = %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("ReadCSV() returned %d items, want 2", len(got))
+	}
+
+	if got[0].ID != 7 || got[0].Name != "Margherita" || len(got[0].Prices) != 2 ||
+		got[0].Prices[1] != (Price{Label: "normal", Price: "8,00", Default: true}) ||
+		strings.Join(got[0].Allergens, ",") != "A,G" || len(got[0].ServingTimes) != 2 {
+		t.Errorf("first item = %+v", got[0])
+	}
+
+	if got[1].ID != 0 || got[1].Prices[0].Price != "7,50" || got[1].Availability != "sold_out" || got[1].SoldOutUntil != "2025-06-08" {
+		t.Errorf("second item = %+v", got[1])
+	}
+}
+
+func TestReadCSV_Columns(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "minimal columns in any order", input: "prices,name,category\n\"8,50\",Margherita,pizza\n"},
+		{name: "byte order mark", input: "\ufeffname,category,prices\nMargherita,pizza,\"8,50\"\n"},
+		{name: "missing prices", input: "name,category\nMargherita,pizza\n", wantErr: `missing column "prices"`},
+		{name: "unknown column", input: "name,category,prices,colour\n", wantErr: `unknown column "colour"`},
+		{name: "invalid ID", input: "id,name,category,prices\nx,Margherita,pizza,1\n", wantErr: `line 2: invalid ID "x"`},
+		{name: "empty file", input: "", wantErr: "the file is empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadCSV(strings.NewReader(tt.input))
+
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("ReadCSV() error = %v", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("ReadCSV() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCatalog_Resolve(t *testing.T) {
+	catalog := Catalog{
+		Categories: []models.Category{{ID: 1, Slug: "pizza", NameIT: "Pizza"}},
+		Tags:       []models.Tag{{ID: 1, Slug: "vegetarian"}, {ID: 2, Slug: "spicy"}},
+		Allergens:  []models.Allergen{{ID: 1, Code: "A"}, {ID: 2, Code: "G"}},
+		Extras:     []models.Extra{{ID: 3, Name: "extra Käse"}},
+	}
+
+	items, errs := catalog.Resolve([]Item{{
+		Name:         "Diavola",
+		Category:     "pizza",
+		Prices:       []Price{{Label: "klein", Price: "7.50"}, {Label: "normal", Price: "9,50"}},
+		Tags:         []string{"Spicy", "vegetarian"},
+		Allergens:    []string{"g", "A"},
+		Extras:       []string{"extra käse"},
+		ServingTimes: []string{"sat 18:00-22:00", "Fri 18:00-22:00"},
+	}})
+
+	if len(errs) != 0 {
+		t.Fatalf("Resolve() errors = %v", errs)
+	}
+
+	item := items[0]
+
+	if item.CategoryID != 1 || item.Category != "Pizza" {
+		t.Errorf("category = %d %q, want 1 Pizza", item.CategoryID, item.Category)
+	}
+
+	// Without a marked default the first size is the default
+	if !item.Variants[0].IsDefault || item.Variants[1].IsDefault || item.Variants[0].Price != models.EUR(750) {
+		t.Errorf("variants = %+v", item.Variants)
+	}
+
+	// Links follow the catalog order, windows the week
+	if item.Tags[0].Slug != "vegetarian" || item.DeclarationCodes() != "A, G" || item.Extras[0].ID != 3 {
+		t.Errorf("links = %+v %q %+v", item.Tags, item.DeclarationCodes(), item.Extras)
+	}
+
+	if item.Windows[0].Weekday != time.Friday || item.Windows[1].Weekday != time.Saturday {
+		t.Errorf("windows = %+v", item.Windows)
+	}
+
+	_, errs = catalog.Resolve([]Item{
+		{Name: "Margherita", Category: "pizza", Prices: []Price{{Price: "8,00"}}},
+		{Name: "margherita ", Category: "pizza", Prices: []Price{{Label: "klein", Price: "6,00"}, {Price: "8,00"}}},
+		{Category: "calzone", Allergens: []string{"Z"}},
+	})
+
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+
+	want := []string{
+		`item 2 ("margherita "): every size needs a label when there is more than one price`,
+		`item 2 ("margherita "): the category already has an item with this name`,
+		`item 3 (""): name is required`,
+		`item 3 (""): unknown category "calzone"`,
+		`item 3 (""): at least one price is required`,
+		`item 3 (""): unknown allergen "Z"`,
+	}
+
+	if strings.Join(messages, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Resolve() errors =\n%s\nwant\n%s", strings.Join(messages, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// CookieKeySize is the length of a generated signing secret in bytes
+const CookieKeySize = 32
+
+// ErrNoCookieKeys is returned by LoadKeyring when no keys are configured
+var ErrNoCookieKeys = errors.New("no cookie signing keys configured: set COOKIE_KEYS or COOKIE_KEYS_FILE")
+
+// cookieKeyIDPattern restricts key IDs to characters that need no escaping in cookies and key lists
+var cookieKeyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// CookieKey is a secret used to sign session cookies. Its ID is written into every cookie it
+// signs, so the secret can be found again after newer keys have been added.
+type CookieKey struct {
+	ID     string
+	Secret []byte
+}
+
+// String formats the key as it is configured, "id:secret" with the secret in unpadded base64url
+func (k CookieKey) String() string {
+	return k.ID + ":" + base64.RawURLEncoding.EncodeToString(k.Secret)
+}
+
+// GenerateCookieKey creates a random key with an ID made of today's date and a random suffix
+func GenerateCookieKey() (CookieKey, error) {
+	secret := make([]byte, CookieKeySize)
+	if _, err := rand.Read(secret); err != nil {
+		return CookieKey{}, err
+	}
+
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return CookieKey{}, err
+	}
+
+	return CookieKey{ID: time.Now().UTC().Format("20060102") + "-" + hex.EncodeToString(suffix), Secret: secret}, nil
+}
+
+// Keyring holds the keys accepted for session cookies. The first key is the primary key that
+// signs new cookies; the others only verify cookies signed before a rotation.
+type Keyring struct {
+	keys []CookieKey
+}
+
+// NewKeyring creates a keyring with primary as its signing key
+func NewKeyring(primary CookieKey, older ...CookieKey) *Keyring {
+	return &Keyring{keys: append([]CookieKey{primary}, older...)}
+}
+
+// Primary returns the key that signs new cookies
+func (kr *Keyring) Primary() CookieKey {
+	return kr.keys[0]
+}
+
+// Lookup finds a key by its ID. A nil keyring has no keys.
+func (kr *Keyring) Lookup(id string) (CookieKey, bool) {
+	if kr == nil {
+		return CookieKey{}, false
+	}
+
+	for _, k := range kr.keys {
+		if k.ID == id {
+			return k, true
+		}
+	}
+
+	return CookieKey{}, false
+}
+
+// Keys returns all keys, primary first
+func (kr *Keyring) Keys() []CookieKey {
+	return append([]CookieKey(nil), kr.keys...)
+}
+
+// Rotate returns a keyring signing with key, keeping at most keep keys including the new one.
+// The oldest keys are dropped first; cookies they signed are no longer accepted.
+func (kr *Keyring) Rotate(key CookieKey, keep int) *Keyring {
+	keys := append([]CookieKey{key}, kr.keys...)
+	if keep > 0 && len(keys) > keep {
+		keys = keys[:keep]
+	}
+
+	return &Keyring{keys: keys}
+}
+
+// Format writes the keyring in the format read by ParseKeyring, one key per line
+func (kr *Keyring) Format() string {
+	var b strings.Builder
+
+	b.WriteString("# Session cookie signing keys, one \"id:secret\" per line.\n")
+	b.WriteString("# The first key signs new cookies; the others are still accepted.\n")
+
+	for _, k := range kr.keys {
+		b.WriteString(k.String())
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+// ParseKeyring reads keys written as "id:secret", separated by commas or newlines. Lines starting
+// with # are comments. The first key becomes the primary key.
+func ParseKeyring(text string) (*Keyring, error) {
+	var fields []string
+
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		fields = append(fields, strings.Split(line, ",")...)
+	}
+
+	var keys []CookieKey
+
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(field, ":")
+		if !ok {
+			return nil, fmt.Errorf("cookie key %q is not in the form id:secret", field)
+		}
+
+		if !cookieKeyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("cookie key ID %q may only contain letters, digits, - and _", id)
+		}
+
+		secret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+		if err != nil {
+			return nil, fmt.Errorf("cookie key %s: secret is not base64url: %w", id, err)
+		}
+
+		if len(secret) < CookieKeySize {
+			return nil, fmt.Errorf("cookie key %s: secret must be at least %d bytes, got %d", id, CookieKeySize, len(secret))
+		}
+
+		for _, k := range keys {
+			if k.ID == id {
+				return nil, fmt.Errorf("cookie key ID %s is used twice", id)
+			}
+		}
+
+		keys = append(keys, CookieKey{ID: id, Secret: secret})
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrNoCookieKeys
+	}
+
+	return &Keyring{keys: keys}, nil
+}
+
+// LoadKeyring reads the keyring from the file named by COOKIE_KEYS_FILE, or else from the
+// COOKIE_KEYS environment variable
+func LoadKeyring() (*Keyring, error) {
+	if path := os.Getenv("COOKIE_KEYS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading cookie keys: %w", err)
+		}
+
+		return ParseKeyring(string(data))
+	}
+
+	return ParseKeyring(os.Getenv("COOKIE_KEYS"))
+}
@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sessionCookieFrom returns the session cookie set on a response
+func sessionCookieFrom(t *testing.T, w *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == SessionCookieName {
+			return cookie
+		}
+	}
+
+	t.Fatal("Session cookie not found")
+
+	return nil
+}
+
+// verifyCookie runs VerifySecureSessionCookie on a request carrying cookie
+func verifyCookie(cookie *http.Cookie) (string, bool) {
+	req := httptest.NewRequest("GET", "/admin", nil)
+	req.AddCookie(cookie)
+
+	return VerifySecureSessionCookie(req)
+}
+
+func mustGenerateKey(t *testing.T) CookieKey {
+	t.Helper()
+
+	key, err := GenerateCookieKey()
+	if err != nil {
+		t.Fatalf("GenerateCookieKey() error = %v", err)
+	}
+
+	return key
+}
+
+func TestParseKeyring(t *testing.T) {
+	first, second := mustGenerateKey(t), mustGenerateKey(t)
+
+	tests := []struct {
+		name        string
+		text        string
+		wantPrimary string
+		wantKeys    int
+		wantErr     string
+	}{
+		{name: "Environment variable", text: first.String() + "," + second.String(), wantPrimary: first.ID, wantKeys: 2},
+		{name: "Key file", text: NewKeyring(second, first).Format(), wantPrimary: second.ID, wantKeys: 2},
+		{name: "Padded secret", text: first.String() + "=", wantPrimary: first.ID, wantKeys: 1},
+		{name: "Empty", text: " \n# no keys yet\n", wantErr: ErrNoCookieKeys.Error()},
+		{name: "Missing ID", text: "c2VjcmV0", wantErr: "not in the form id:secret"},
+		{name: "Invalid ID", text: "a.b:" + strings.Split(first.String(), ":")[1], wantErr: "may only contain"},
+		{name: "Short secret", text: "short:c2VjcmV0", wantErr: "at least 32 bytes"},
+		{name: "Not base64", text: "bad:!!!", wantErr: "not base64url"},
+		{name: "Duplicate ID", text: first.String() + "\n" + first.String(), wantErr: "used twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := ParseKeyring(tt.text)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseKeyring() error = %v, want %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseKeyring() error = %v", err)
+			}
+
+			if keyring.Primary().ID != tt.wantPrimary || len(keyring.Keys()) != tt.wantKeys {
+				t.Errorf("ParseKeyring() primary %s with %d keys, want %s with %d",
+					keyring.Primary().ID, len(keyring.Keys()), tt.wantPrimary, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestCookieKeyRotation(t *testing.T) {
+	oldKey, newKey := mustGenerateKey(t), mustGenerateKey(t)
+
+	SetCookieKeyring(NewKeyring(oldKey))
+
+	w := httptest.NewRecorder()
+	SetSecureSessionCookie(w, "test@example.com")
+	oldCookie := sessionCookieFrom(t, w)
+
+	if !strings.HasPrefix(oldCookie.Value, oldKey.ID+".") {
+		t.Fatalf("cookie %s does not name key %s", oldCookie.Value, oldKey.ID)
+	}
+
+	// After a rotation new cookies use the new key while existing ones stay valid
+	keyring := NewKeyring(oldKey).Rotate(newKey, 2)
+	SetCookieKeyring(keyring)
+
+	if email, valid := verifyCookie(oldCookie); !valid || email != "test@example.com" {
+		t.Errorf("cookie signed with the previous key rejected after rotation")
+	}
+
+	w = httptest.NewRecorder()
+	SetSecureSessionCookie(w, "test@example.com")
+
+	if newCookie := sessionCookieFrom(t, w); !strings.HasPrefix(newCookie.Value, newKey.ID+".") {
+		t.Errorf("cookie %s not signed with the new primary key %s", newCookie.Value, newKey.ID)
+	}
+
+	// Once the old key is dropped its cookies are rejected
+	SetCookieKeyring(keyring.Rotate(mustGenerateKey(t), 2))
+
+	if _, valid := verifyCookie(oldCookie); valid {
+		t.Errorf("cookie signed with a dropped key still accepted")
+	}
+
+	// A cookie cannot be moved to another key in the ring by changing its key ID
+	SetCookieKeyring(NewKeyring(newKey, oldKey))
+
+	parts := strings.SplitN(oldCookie.Value, ".", 2)
+	moved := &http.Cookie{Name: SessionCookieName, Value: newKey.ID + "." + parts[1]}
+
+	if _, valid := verifyCookie(moved); valid {
+		t.Errorf("cookie with a changed key ID accepted")
+	}
+}
+
+func TestInitializeCookieSecret(t *testing.T) {
+	key := mustGenerateKey(t)
+
+	t.Run("Key file wins over the environment", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cookie-keys")
+		if err := os.WriteFile(path, []byte(NewKeyring(key).Format()), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Setenv("COOKIE_KEYS_FILE", path)
+		t.Setenv("COOKIE_KEYS", mustGenerateKey(t).String())
+
+		if err := InitializeCookieSecret(); err != nil {
+			t.Fatalf("InitializeCookieSecret() error = %v", err)
+		}
+
+		if cookieKeys.Primary().ID != key.ID {
+			t.Errorf("primary key = %s, want %s from the file", cookieKeys.Primary().ID, key.ID)
+		}
+	})
+
+	t.Run("Environment variable", func(t *testing.T) {
+		t.Setenv("COOKIE_KEYS", key.String())
+
+		if err := InitializeCookieSecret(); err != nil {
+			t.Fatalf("InitializeCookieSecret() error = %v", err)
+		}
+
+		if cookieKeys.Primary().ID != key.ID {
+			t.Errorf("primary key = %s, want %s", cookieKeys.Primary().ID, key.ID)
+		}
+	})
+
+	t.Run("Temporary key in development", func(t *testing.T) {
+		t.Setenv("COOKIE_KEYS", "")
+		t.Setenv("APP_ENV", "development")
+
+		if err := InitializeCookieSecret(); err != nil {
+			t.Fatalf("InitializeCookieSecret() error = %v", err)
+		}
+	})
+
+	t.Run("Required in production", func(t *testing.T) {
+		t.Setenv("COOKIE_KEYS", "")
+		t.Setenv("APP_ENV", "production")
+
+		if err := InitializeCookieSecret(); !errors.Is(err, ErrNoCookieKeys) {
+			t.Errorf("InitializeCookieSecret() error = %v, want %v", err, ErrNoCookieKeys)
+		}
+	})
+
+	t.Run("Invalid keys are an error", func(t *testing.T) {
+		t.Setenv("COOKIE_KEYS", "broken")
+
+		if err := InitializeCookieSecret(); err == nil {
+			t.Errorf("InitializeCookieSecret() accepted an invalid key")
+		}
+	})
+}
@@ -2,10 +2,10 @@ package middleware
 
 import (
 	"context"
-	"log"
 	"net/http"
 
 	"github.com/AlexTLDR/pizzeria/internal/auth"
+	"github.com/AlexTLDR/pizzeria/internal/models"
 )
 
 // contextKey is a custom type for context keys to avoid collisions
@@ -14,6 +14,8 @@ type contextKey string
 // Context keys
 const (
 	userEmailKey contextKey = "user_email"
+	sessionIDKey contextKey = "session_id"
+	userRoleKey  contextKey = "user_role"
 )
 
 // Initialize OAuth config
@@ -24,14 +26,8 @@ func InitializeOAuth() error {
 	var err error
 
 	oauthConfig, err = auth.Initialize()
-	if err != nil {
-		return err
-	}
-
-	// Initialize cookie secret for secure sessions
-	InitializeCookieSecret()
 
-	return nil
+	return err
 }
 
 // GetOAuthConfig returns the OAuth configuration
@@ -39,33 +35,6 @@ func GetOAuthConfig() *auth.OAuthConfig {
 	return oauthConfig
 }
 
-// GoogleAuth is middleware that checks if the user is authenticated using Google OAuth
-func GoogleAuth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Verify the secure session cookie
-		userEmail, valid := VerifySecureSessionCookie(r)
-		if !valid {
-			log.Println("Invalid or expired session, redirecting to login")
-			http.Redirect(w, r, "/login", http.StatusSeeOther)
-
-			return
-		}
-
-		// Check if the user email is in the allowed list
-		if !oauthConfig.IsAllowedEmail(userEmail) {
-			log.Printf("Unauthorized access attempt by: %s", userEmail)
-			ClearSecureSessionCookie(w) // Clear invalid session
-			http.Error(w, "Unauthorized: You do not have permission to access this page", http.StatusForbidden)
-
-			return
-		}
-
-		// Set the email in the request context
-		ctx := context.WithValue(r.Context(), userEmailKey, userEmail)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
-}
-
 // GetUserEmail extracts the user's email from the request context
 func GetUserEmail(r *http.Request) string {
 	if email, ok := r.Context().Value(userEmailKey).(string); ok {
@@ -75,10 +44,46 @@ func GetUserEmail(r *http.Request) string {
 	return ""
 }
 
-// SetSessionCookie sets a Google session cookie with the user's email
-func SetSessionCookie(w http.ResponseWriter, email string) {
+// WithUserEmail returns a copy of the request that carries the user's email in its context
+func WithUserEmail(r *http.Request, email string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), userEmailKey, email))
+}
+
+// WithSession returns a copy of the request that carries the signed-in user's email and the ID of
+// their session in its context
+func WithSession(r *http.Request, email string, sessionID int) *http.Request {
+	ctx := context.WithValue(r.Context(), userEmailKey, email)
+	return r.WithContext(context.WithValue(ctx, sessionIDKey, sessionID))
+}
+
+// GetSessionID returns the ID of the session the request was made in, or 0 for requests
+// authenticated by other means than the session cookie
+func GetSessionID(r *http.Request) int {
+	if id, ok := r.Context().Value(sessionIDKey).(int); ok {
+		return id
+	}
+
+	return 0
+}
+
+// WithUserRole returns a copy of the request that carries the signed-in user's role in its context
+func WithUserRole(r *http.Request, role models.Role) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), userRoleKey, role))
+}
+
+// GetUserRole returns the role of the signed-in user, or "" if the request is not authenticated
+func GetUserRole(r *http.Request) models.Role {
+	if role, ok := r.Context().Value(userRoleKey).(models.Role); ok {
+		return role
+	}
+
+	return ""
+}
+
+// SetSessionCookie sets the Google session cookie carrying the token of a server-side session
+func SetSessionCookie(w http.ResponseWriter, sessionToken string) {
 	// Use the secure cookie implementation
-	SetSecureSessionCookie(w, email)
+	SetSecureSessionCookie(w, sessionToken)
 }
 
 // ClearSessionCookie clears the Google session cookie
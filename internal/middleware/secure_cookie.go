@@ -2,70 +2,103 @@ package middleware
 
 import (
 	"crypto/hmac"
-	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/models"
 )
 
 const (
 	// SessionCookieName is the name of the cookie used to store the session
 	SessionCookieName = "google_session"
-	// SessionDuration is how long the session cookie will be valid
-	SessionDuration = 24 * time.Hour
+	// SessionDuration is how long the session cookie will be valid. The server ends the session
+	// itself sooner when it is idle or revoked.
+	SessionDuration = models.SessionLifetime
 )
 
 var (
-	// cookieSecret is used to sign the session cookie
-	cookieSecret []byte
+	// cookieKeys holds the keys used to sign and verify the session cookie
+	cookieKeys *Keyring
 )
 
-// InitializeCookieSecret generates or loads a secret key for cookie signing
-func InitializeCookieSecret() {
-	// For a real application, you should use a stable secret from environment or config
-	// In this example, we'll generate a random secret if one doesn't exist
-	secret := make([]byte, 32) // 256 bits
+// InitializeCookieSecret loads the keyring used to sign session cookies from COOKIE_KEYS_FILE or
+// COOKIE_KEYS, so sessions survive restarts and are shared by all instances. Outside production a
+// missing configuration falls back to a random key that lasts until the next restart.
+func InitializeCookieSecret() error {
+	keyring, err := LoadKeyring()
 
-	_, err := rand.Read(secret)
-	if err != nil {
-		log.Printf("Warning: Failed to generate secure cookie secret: %v", err)
-		// Fallback to a fixed secret (not recommended for production)
-		secret = []byte("default-cookie-secret-please-change-me")
+	switch {
+	case errors.Is(err, ErrNoCookieKeys) && os.Getenv("APP_ENV") != "production":
+		key, genErr := GenerateCookieKey()
+		if genErr != nil {
+			return fmt.Errorf("generating temporary cookie key: %w", genErr)
+		}
+
+		keyring = NewKeyring(key)
+
+		log.Println("Warning: No cookie signing keys configured, using a temporary key; sessions end when the server restarts")
+
+	case err != nil:
+		return err
+
+	default:
+		log.Printf("Cookie signing keys loaded: %d key(s), primary %s", len(keyring.keys), keyring.Primary().ID)
 	}
 
-	cookieSecret = secret
+	SetCookieKeyring(keyring)
+
+	return nil
+}
+
+// SetCookieKeyring replaces the keys used for session cookies
+func SetCookieKeyring(keyring *Keyring) {
+	cookieKeys = keyring
+}
+
+// signCookiePayload computes the signature of a cookie payload. The key ID is signed along with
+// the payload, so a cookie cannot be moved to another key.
+func signCookiePayload(key CookieKey, payload []byte) []byte {
+	h := hmac.New(sha256.New, key.Secret)
+	h.Write([]byte(key.ID + "."))
+	h.Write(payload)
 
-	log.Println("Cookie signing secret initialized")
+	return h.Sum(nil)
 }
 
-// SetSecureSessionCookie creates and sets a signed session cookie with the user's email
-func SetSecureSessionCookie(w http.ResponseWriter, email string) {
+// SetSecureSessionCookie creates and sets a signed session cookie carrying the given value, the
+// token of a server-side session
+func SetSecureSessionCookie(w http.ResponseWriter, value string) {
 	// Current timestamp for the cookie
 	now := time.Now()
 	expires := now.Add(SessionDuration)
 
-	// Create the cookie payload: email|expiration_timestamp
+	// Create the cookie payload: value|expiration_timestamp
 	expiresStr := strconv.FormatInt(expires.Unix(), 10)
-	payload := fmt.Sprintf("%s|%s", email, expiresStr)
+	payload := fmt.Sprintf("%s|%s", value, expiresStr)
 
-	// Create HMAC signature
-	h := hmac.New(sha256.New, cookieSecret)
-	if _, err := h.Write([]byte(payload)); err != nil {
-		log.Printf("Error creating HMAC signature: %v", err)
+	if cookieKeys == nil {
+		log.Printf("ERROR: Cannot set session cookie: cookie signing keys not initialized")
+		return
 	}
-	signature := h.Sum(nil)
+
+	// Sign with the primary key and name it in the cookie
+	key := cookieKeys.Primary()
+	signature := signCookiePayload(key, []byte(payload))
 
 	// Encode the payload and signature for the cookie
 	encodedPayload := base64.URLEncoding.EncodeToString([]byte(payload))
 	encodedSignature := base64.URLEncoding.EncodeToString(signature)
 
-	// Final cookie value: base64(payload).base64(signature)
-	cookieValue := fmt.Sprintf("%s.%s", encodedPayload, encodedSignature)
+	// Final cookie value: keyID.base64(payload).base64(signature)
+	cookieValue := fmt.Sprintf("%s.%s.%s", key.ID, encodedPayload, encodedSignature)
 
 	// Set the cookie
 	cookie := http.Cookie{
@@ -80,10 +113,10 @@ func SetSecureSessionCookie(w http.ResponseWriter, email string) {
 	}
 
 	http.SetCookie(w, &cookie)
-	log.Printf("Set secure session cookie for %s, expires: %s", email, expires.Format(time.RFC3339))
+	log.Printf("Set secure session cookie, expires: %s", expires.Format(time.RFC3339))
 }
 
-// VerifySecureSessionCookie verifies the signature of a session cookie and returns the email
+// VerifySecureSessionCookie verifies the signature of a session cookie and returns its value
 func VerifySecureSessionCookie(r *http.Request) (string, bool) {
 	// Get the cookie
 	cookie, err := r.Cookie(SessionCookieName)
@@ -92,35 +125,35 @@ func VerifySecureSessionCookie(r *http.Request) (string, bool) {
 		return "", false
 	}
 
-	// Split the cookie value into payload and signature
+	// Split the cookie value into key ID, payload and signature
 	parts := strings.Split(cookie.Value, ".")
-	if len(parts) != 2 {
+	if len(parts) != 3 {
 		log.Printf("Invalid cookie format")
 		return "", false
 	}
 
+	// Any key in the keyring is accepted, so cookies survive a key rotation
+	key, ok := cookieKeys.Lookup(parts[0])
+	if !ok {
+		log.Printf("Cookie signed with unknown key %q", parts[0])
+		return "", false
+	}
+
 	// Decode the payload and signature
-	payloadBytes, err := base64.URLEncoding.DecodeString(parts[0])
+	payloadBytes, err := base64.URLEncoding.DecodeString(parts[1])
 	if err != nil {
 		log.Printf("Invalid payload encoding: %v", err)
 		return "", false
 	}
 
-	signatureBytes, err := base64.URLEncoding.DecodeString(parts[1])
+	signatureBytes, err := base64.URLEncoding.DecodeString(parts[2])
 	if err != nil {
 		log.Printf("Invalid signature encoding: %v", err)
 		return "", false
 	}
 
 	// Verify the signature
-	h := hmac.New(sha256.New, cookieSecret)
-	if _, err := h.Write(payloadBytes); err != nil {
-		log.Printf("Error verifying signature: %v", err)
-		return "", false
-	}
-	expectedSignature := h.Sum(nil)
-
-	if !hmac.Equal(signatureBytes, expectedSignature) {
+	if !hmac.Equal(signatureBytes, signCookiePayload(key, payloadBytes)) {
 		log.Printf("Cookie signature verification failed")
 		return "", false
 	}
@@ -134,7 +167,7 @@ func VerifySecureSessionCookie(r *http.Request) (string, bool) {
 		return "", false
 	}
 
-	email := payloadParts[0]
+	value := payloadParts[0]
 	expirationStr := payloadParts[1]
 
 	// Verify expiration
@@ -150,7 +183,7 @@ func VerifySecureSessionCookie(r *http.Request) (string, bool) {
 		return "", false
 	}
 
-	return email, true
+	return value, true
 }
 
 // ClearSecureSessionCookie removes the session cookie
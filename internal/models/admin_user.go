@@ -0,0 +1,274 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrLastOwner is returned when a change would leave no owner to manage the admin users
+var ErrLastOwner = errors.New("there must always be at least one owner")
+
+// Role decides which admin pages a user may use. Every role may do everything the roles below it
+// may do.
+type Role string
+
+const (
+	// RoleStaff can mark dishes as sold out and available again
+	RoleStaff Role = "staff"
+	// RoleManager manages the menu, prices, opening hours and flash messages
+	RoleManager Role = "manager"
+	// RoleOwner also manages the admin users and API tokens
+	RoleOwner Role = "owner"
+)
+
+// Roles lists all roles, highest first
+var Roles = []Role{RoleOwner, RoleManager, RoleStaff}
+
+// rank orders the roles; unknown roles rank below staff
+func (r Role) rank() int {
+	switch r {
+	case RoleOwner:
+		return 3
+	case RoleManager:
+		return 2
+	case RoleStaff:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsValid reports whether r is one of the known roles
+func (r Role) IsValid() bool {
+	return r.rank() > 0
+}
+
+// AtLeast reports whether r may do everything min may do
+func (r Role) AtLeast(min Role) bool {
+	return r.IsValid() && r.rank() >= min.rank()
+}
+
+// Label returns the role name for display, e.g. "Manager"
+func (r Role) Label() string {
+	if r == "" {
+		return ""
+	}
+
+	return strings.ToUpper(string(r[:1])) + string(r[1:])
+}
+
+// AdminUser is a person allowed to sign in to the admin pages
+type AdminUser struct {
+	ID        int
+	Email     string
+	Role      Role
+	CreatedBy string // Email of the owner who added the user; empty for users seeded at startup
+	CreatedAt time.Time
+}
+
+// NormalizeEmail trims an email address and converts it to lower case, as admin users are stored
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// adminUserColumns are the columns scanned by scanAdminUser
+const adminUserColumns = `id, email, role, created_by, created_at`
+
+// scanAdminUser scans a row selected with adminUserColumns
+func scanAdminUser(row interface{ Scan(...any) error }) (AdminUser, error) {
+	var u AdminUser
+
+	err := row.Scan(&u.ID, &u.Email, &u.Role, &u.CreatedBy, &u.CreatedAt)
+
+	return u, err
+}
+
+// GetAdminUsers retrieves all admin users, owners first
+func (m *DBModel) GetAdminUsers() ([]AdminUser, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT `+adminUserColumns+` FROM admin_users
+		ORDER BY CASE role WHEN 'owner' THEN 0 WHEN 'manager' THEN 1 ELSE 2 END, email`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []AdminUser
+
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// GetAdminUserByEmail retrieves the admin user with the given email, or sql.ErrNoRows if the
+// email may not sign in
+func (m *DBModel) GetAdminUserByEmail(email string) (AdminUser, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	row := m.DB.QueryRowContext(ctx, `SELECT `+adminUserColumns+` FROM admin_users WHERE email = ?`,
+		NormalizeEmail(email))
+
+	return scanAdminUser(row)
+}
+
+// InsertAdminUser adds an admin user and returns its ID
+func (m *DBModel) InsertAdminUser(user AdminUser) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if !user.Role.IsValid() {
+		return 0, errors.New("unknown role " + string(user.Role))
+	}
+
+	var id int
+
+	err := m.DB.QueryRowContext(ctx, `INSERT INTO admin_users (email, role, created_by, created_at)
+		VALUES (?, ?, ?, ?)
+		RETURNING id`,
+		NormalizeEmail(user.Email), user.Role, user.CreatedBy, time.Now()).Scan(&id)
+
+	return id, err
+}
+
+// keepsAnOwner returns ErrLastOwner if the user with the given ID is the only owner
+func keepsAnOwner(ctx context.Context, tx *sql.Tx, id int) error {
+	var role Role
+
+	err := tx.QueryRowContext(ctx, `SELECT role FROM admin_users WHERE id = ?`, id).Scan(&role)
+	if err != nil {
+		return err
+	}
+
+	if role != RoleOwner {
+		return nil
+	}
+
+	var owners int
+
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM admin_users WHERE role = ?`, RoleOwner).Scan(&owners)
+	if err != nil {
+		return err
+	}
+
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+
+	return nil
+}
+
+// UpdateAdminUserRole changes the role of an admin user. The last owner cannot be demoted.
+func (m *DBModel) UpdateAdminUserRole(id int, role Role) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if !role.IsValid() {
+		return errors.New("unknown role " + string(role))
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	if role != RoleOwner {
+		if err := keepsAnOwner(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+
+	if err := execOne(ctx, tx, `UPDATE admin_users SET role = ? WHERE id = ?`, role, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteAdminUser removes an admin user with their passkeys and returns it, so its sessions can be
+// ended. The last owner cannot be removed.
+func (m *DBModel) DeleteAdminUser(id int) (AdminUser, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return AdminUser{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	if err := keepsAnOwner(ctx, tx, id); err != nil {
+		return AdminUser{}, err
+	}
+
+	user, err := scanAdminUser(tx.QueryRowContext(ctx, `DELETE FROM admin_users WHERE id = ? RETURNING `+adminUserColumns, id))
+	if err != nil {
+		return AdminUser{}, err
+	}
+
+	// Passkeys would let the user sign in again if the email were added back
+	if _, err := tx.ExecContext(ctx, `DELETE FROM passkeys WHERE email = ?`, user.Email); err != nil {
+		return AdminUser{}, err
+	}
+
+	return user, tx.Commit()
+}
+
+// BootstrapAdminUsers adds the given emails as owners when there are no admin users yet, so the
+// first owners can sign in and add everyone else. It returns how many owners were added.
+func (m *DBModel) BootstrapAdminUsers(emails []string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	var existing int
+
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM admin_users`).Scan(&existing)
+	if err != nil || existing > 0 {
+		return 0, err
+	}
+
+	added := 0
+
+	for _, email := range emails {
+		email = NormalizeEmail(email)
+		if email == "" {
+			continue
+		}
+
+		result, err := tx.ExecContext(ctx, `INSERT INTO admin_users (email, role, created_at) VALUES (?, ?, ?)
+			ON CONFLICT (email) DO NOTHING`, email, RoleOwner, time.Now())
+		if err != nil {
+			return 0, err
+		}
+
+		if n, err := result.RowsAffected(); err == nil {
+			added += int(n)
+		}
+	}
+
+	return added, tx.Commit()
+}
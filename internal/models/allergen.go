@@ -0,0 +1,223 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Allergen is one of the 14 main allergens that must be declared, identified by a letter code
+type Allergen struct {
+	ID       int
+	Code     string
+	Name     string
+	Position int
+}
+
+// Additive is a declarable food additive (Zusatzstoff), identified by a number code
+type Additive struct {
+	ID       int
+	Code     string
+	Name     string
+	Position int
+}
+
+// DeclarationCodes returns the allergen and additive codes of the item, e.g. "A, G, 1"
+func (item MenuItem) DeclarationCodes() string {
+	codes := make([]string, 0, len(item.Allergens)+len(item.Additives))
+
+	for _, a := range item.Allergens {
+		codes = append(codes, a.Code)
+	}
+
+	for _, a := range item.Additives {
+		codes = append(codes, a.Code)
+	}
+
+	return strings.Join(codes, ", ")
+}
+
+// HasAllergen reports whether the item declares the allergen with the given ID
+func (item MenuItem) HasAllergen(id int) bool {
+	for _, a := range item.Allergens {
+		if a.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasAdditive reports whether the item declares the additive with the given ID
+func (item MenuItem) HasAdditive(id int) bool {
+	for _, a := range item.Additives {
+		if a.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetAllAllergens retrieves the allergen reference list in legend order
+func (m *DBModel) GetAllAllergens() ([]Allergen, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT id, code, name, position FROM allergens ORDER BY position, code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var allergens []Allergen
+
+	for rows.Next() {
+		var a Allergen
+
+		err := rows.Scan(&a.ID, &a.Code, &a.Name, &a.Position)
+		if err != nil {
+			return nil, err
+		}
+
+		allergens = append(allergens, a)
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return allergens, nil
+}
+
+// GetAllAdditives retrieves the additive reference list in legend order
+func (m *DBModel) GetAllAdditives() ([]Additive, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT id, code, name, position FROM additives ORDER BY position, code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var additives []Additive
+
+	for rows.Next() {
+		var a Additive
+
+		err := rows.Scan(&a.ID, &a.Code, &a.Name, &a.Position)
+		if err != nil {
+			return nil, err
+		}
+
+		additives = append(additives, a)
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return additives, nil
+}
+
+// loadDeclarations attaches allergens and additives to the given items.
+// The link tables are small, so they are read in one query each instead of once per item.
+func (m *DBModel) loadDeclarations(ctx context.Context, items []MenuItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*MenuItem, len(items))
+	for i := range items {
+		byID[items[i].ID] = &items[i]
+	}
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT l.menu_item_id, a.id, a.code, a.name, a.position
+		FROM menu_item_allergens l
+		JOIN allergens a ON a.id = l.allergen_id
+		ORDER BY a.position, a.code`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemID int
+
+		var a Allergen
+
+		err := rows.Scan(&itemID, &a.ID, &a.Code, &a.Name, &a.Position)
+		if err != nil {
+			return err
+		}
+
+		if item, ok := byID[itemID]; ok {
+			item.Allergens = append(item.Allergens, a)
+		}
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = m.DB.QueryContext(ctx, `SELECT l.menu_item_id, a.id, a.code, a.name, a.position
+		FROM menu_item_additives l
+		JOIN additives a ON a.id = l.additive_id
+		ORDER BY a.position, a.code`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemID int
+
+		var a Additive
+
+		err := rows.Scan(&itemID, &a.ID, &a.Code, &a.Name, &a.Position)
+		if err != nil {
+			return err
+		}
+
+		if item, ok := byID[itemID]; ok {
+			item.Additives = append(item.Additives, a)
+		}
+	}
+
+	// Check for errors encountered during iteration
+	return rows.Err()
+}
+
+// saveDeclarations replaces the allergen and additive links of a menu item inside a transaction
+func saveDeclarations(ctx context.Context, tx *sql.Tx, item MenuItem) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM menu_item_allergens WHERE menu_item_id = ?`, item.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM menu_item_additives WHERE menu_item_id = ?`, item.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range item.Allergens {
+		_, err = tx.ExecContext(ctx, `INSERT OR IGNORE INTO menu_item_allergens (menu_item_id, allergen_id) VALUES (?, ?)`, item.ID, a.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, a := range item.Additives {
+		_, err = tx.ExecContext(ctx, `INSERT OR IGNORE INTO menu_item_additives (menu_item_id, additive_id) VALUES (?, ?)`, item.ID, a.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
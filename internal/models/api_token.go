@@ -0,0 +1,154 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidAPIToken is returned when a token is unknown or has been revoked
+var ErrInvalidAPIToken = errors.New("invalid or revoked API token")
+
+// apiTokenPrefix starts every token secret, so leaked tokens are easy to recognise
+const apiTokenPrefix = "pzt_"
+
+// APIToken is a personal token for the admin API. The secret itself is only shown once when the
+// token is created; the database keeps a hash of it.
+type APIToken struct {
+	ID         int
+	Name       string
+	Prefix     string // First characters of the secret, e.g. "pzt_3Fk9"
+	CreatedBy  string // Email of the admin who created the token; API changes are made in their name
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// IsRevoked reports whether the token can no longer be used
+func (t APIToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// hashToken returns the hex encoded SHA-256 hash of an API token or session secret
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiTokenColumns are the columns scanned by scanAPIToken
+const apiTokenColumns = `id, name, prefix, created_by, created_at, last_used_at, revoked_at`
+
+// scanAPIToken scans a row selected with apiTokenColumns
+func scanAPIToken(row interface{ Scan(...any) error }) (APIToken, error) {
+	var t APIToken
+
+	var lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(&t.ID, &t.Name, &t.Prefix, &t.CreatedBy, &t.CreatedAt, &lastUsedAt, &revokedAt)
+
+	if lastUsedAt.Valid {
+		t.LastUsedAt = &lastUsedAt.Time
+	}
+
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Time
+	}
+
+	return t, err
+}
+
+// CreateAPIToken creates a token for the given admin and returns it together with its secret
+func (m *DBModel) CreateAPIToken(name, createdBy string) (APIToken, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return APIToken{}, "", errors.New("token name is required")
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return APIToken{}, "", err
+	}
+
+	secret := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(random)
+
+	row := m.DB.QueryRowContext(ctx, `INSERT INTO api_tokens (name, prefix, token_hash, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING `+apiTokenColumns,
+		name, secret[:len(apiTokenPrefix)+6], hashToken(secret), createdBy, time.Now())
+
+	token, err := scanAPIToken(row)
+	if err != nil {
+		return APIToken{}, "", err
+	}
+
+	return token, secret, nil
+}
+
+// GetAPITokens retrieves all tokens, including revoked ones, newest first
+func (m *DBModel) GetAPITokens() ([]APIToken, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT `+apiTokenColumns+` FROM api_tokens ORDER BY created_at DESC, id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []APIToken
+
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// RevokeAPIToken makes a token unusable. Revoked tokens stay listed so their use can be traced.
+func (m *DBModel) RevokeAPIToken(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return execOne(ctx, m.DB, `UPDATE api_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, time.Now(), id)
+}
+
+// AuthenticateAPIToken looks up the token with the given secret and records that it was used
+func (m *DBModel) AuthenticateAPIToken(secret string) (APIToken, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if !strings.HasPrefix(secret, apiTokenPrefix) {
+		return APIToken{}, ErrInvalidAPIToken
+	}
+
+	row := m.DB.QueryRowContext(ctx, `UPDATE api_tokens SET last_used_at = ?
+		WHERE token_hash = ? AND revoked_at IS NULL
+		RETURNING `+apiTokenColumns,
+		time.Now(), hashToken(secret))
+
+	token, err := scanAPIToken(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIToken{}, ErrInvalidAPIToken
+	}
+
+	return token, err
+}
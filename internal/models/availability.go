@@ -0,0 +1,200 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Availability is the state of a menu item as set by the staff
+type Availability string
+
+const (
+	AvailabilityAvailable Availability = "available"
+	AvailabilitySoldOut   Availability = "sold_out" // Shown greyed out, until SoldOutUntil if set
+	AvailabilityHidden    Availability = "hidden"   // Not shown on the public menu at all
+)
+
+// IsValid reports whether the availability is one of the known states
+func (a Availability) IsValid() bool {
+	return a == AvailabilityAvailable || a == AvailabilitySoldOut || a == AvailabilityHidden
+}
+
+// germanWeekdays are the weekday abbreviations used on the public menu, indexed by time.Weekday
+var germanWeekdays = [...]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"}
+
+// AvailabilityWindow is a weekly time span in which a menu item is offered, e.g. Sunday 11:30-14:00
+type AvailabilityWindow struct {
+	ID         int
+	MenuItemID int
+	Weekday    time.Weekday
+	Start      string // HH:MM
+	End        string // HH:MM, exclusive
+}
+
+// Contains reports whether t falls inside the window, using t's own location
+func (w AvailabilityWindow) Contains(t time.Time) bool {
+	clock := t.Format("15:04")
+
+	return t.Weekday() == w.Weekday && clock >= w.Start && clock < w.End
+}
+
+// String formats the window for guests, e.g. "So 11:30–14:00"
+func (w AvailabilityWindow) String() string {
+	return fmt.Sprintf("%s %s–%s", germanWeekdays[w.Weekday], w.Start, w.End)
+}
+
+// IsHidden reports whether the item is left off the public menu
+func (item MenuItem) IsHidden() bool {
+	return item.Availability == AvailabilityHidden
+}
+
+// IsSoldOutAt reports whether the item is sold out at t. An item sold out until a date is
+// available again from the following day.
+func (item MenuItem) IsSoldOutAt(t time.Time) bool {
+	if item.Availability != AvailabilitySoldOut {
+		return false
+	}
+
+	if item.SoldOutUntil == nil {
+		return true
+	}
+
+	today := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	until := time.Date(item.SoldOutUntil.Year(), item.SoldOutUntil.Month(), item.SoldOutUntil.Day(), 0, 0, 0, 0, time.UTC)
+
+	return !today.After(until)
+}
+
+// IsOfferedAt reports whether t falls into one of the item's windows; items without windows are always offered
+func (item MenuItem) IsOfferedAt(t time.Time) bool {
+	if len(item.Windows) == 0 {
+		return true
+	}
+
+	for _, w := range item.Windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsAvailableAt reports whether guests can order the item at t
+func (item MenuItem) IsAvailableAt(t time.Time) bool {
+	return !item.IsHidden() && !item.IsSoldOutAt(t) && item.IsOfferedAt(t)
+}
+
+// WindowsLabel lists the item's windows for guests, e.g. "So 11:30–14:00, Sa 11:30–14:00"
+func (item MenuItem) WindowsLabel() string {
+	labels := make([]string, 0, len(item.Windows))
+	for _, w := range item.Windows {
+		labels = append(labels, w.String())
+	}
+
+	return strings.Join(labels, ", ")
+}
+
+// SetMenuItemAvailability changes the availability of a menu item and records the change as a
+// revision; until is only kept for sold out items
+func (m *DBModel) SetMenuItemAvailability(id int, availability Availability, until *time.Time, changedBy string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if availability != AvailabilitySoldOut {
+		until = nil
+	}
+
+	item, err := m.menuItemByID(ctx, id, false)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	stmt := `UPDATE menu_items SET availability = ?, sold_out_until = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL`
+
+	err = execOne(ctx, tx, stmt, availability, dateValue(until), time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	item.Availability, item.SoldOutUntil = availability, until
+
+	err = writeRevision(ctx, tx, item, RevisionUpdate, changedBy)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// dateValue converts an optional date into a DATE column value
+func dateValue(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+
+	return t.Format(time.DateOnly)
+}
+
+// loadWindows attaches the availability windows to the given items, ordered by weekday and start time
+func (m *DBModel) loadWindows(ctx context.Context, items []MenuItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*MenuItem, len(items))
+	for i := range items {
+		byID[items[i].ID] = &items[i]
+	}
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT id, menu_item_id, weekday, start_time, end_time
+		FROM menu_item_windows
+		ORDER BY menu_item_id, weekday, start_time`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var w AvailabilityWindow
+
+		err := rows.Scan(&w.ID, &w.MenuItemID, &w.Weekday, &w.Start, &w.End)
+		if err != nil {
+			return err
+		}
+
+		if item, ok := byID[w.MenuItemID]; ok {
+			item.Windows = append(item.Windows, w)
+		}
+	}
+
+	// Check for errors encountered during iteration
+	return rows.Err()
+}
+
+// saveWindows replaces the availability windows of a menu item inside a transaction
+func saveWindows(ctx context.Context, tx *sql.Tx, item MenuItem) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM menu_item_windows WHERE menu_item_id = ?`, item.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range item.Windows {
+		_, err = tx.ExecContext(ctx, `INSERT INTO menu_item_windows (menu_item_id, weekday, start_time, end_time) VALUES (?, ?, ?, ?)`,
+			item.ID, int(w.Weekday), w.Start, w.End)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,316 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrCategoryInUse is returned when deleting a category that still has menu items
+var ErrCategoryInUse = errors.New("category still has menu items")
+
+// ErrCategoryInTrash is returned when deleting a category whose only menu items are in the trash.
+// They would lose their category if restored, so they have to be purged first.
+var ErrCategoryInTrash = errors.New("category still has menu items in the trash")
+
+// ErrCategorySlugTaken is returned when saving a category with the slug of another category
+var ErrCategorySlugTaken = errors.New("a category with this slug already exists")
+
+// Category represents a menu section such as "Antipasti / Vorspeisen"
+type Category struct {
+	ID          int
+	Slug        string
+	NameIT      string
+	NameDE      string
+	Description string
+	Position    int
+	Visible     bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+
+	// Translated name and description to save with the category, by language. Not loaded with the category.
+	Translations map[string]Translation
+}
+
+// Label returns the bilingual heading, e.g. "Carne / Fleisch", or just the Italian name
+func (c Category) Label() string {
+	if c.NameDE == "" || strings.EqualFold(c.NameDE, c.NameIT) {
+		return c.NameIT
+	}
+
+	return c.NameIT + " / " + c.NameDE
+}
+
+var slugInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify turns a category name into a URL anchor, e.g. "Pasta al Forno" -> "pasta-al-forno"
+func Slugify(name string) string {
+	replacer := strings.NewReplacer("ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss", "à", "a", "è", "e", "é", "e", "ì", "i", "ò", "o", "ù", "u")
+	slug := replacer.Replace(strings.ToLower(strings.TrimSpace(name)))
+	slug = slugInvalidChars.ReplaceAllString(slug, "-")
+
+	return strings.Trim(slug, "-")
+}
+
+const categoryColumns = `id, slug, name_it, name_de, description, position, visible, created_at, updated_at`
+
+// scanCategory scans a row selected with categoryColumns
+func scanCategory(row interface{ Scan(...any) error }) (Category, error) {
+	var c Category
+
+	err := row.Scan(
+		&c.ID,
+		&c.Slug,
+		&c.NameIT,
+		&c.NameDE,
+		&c.Description,
+		&c.Position,
+		&c.Visible,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+
+	return c, err
+}
+
+// GetAllCategories retrieves all categories ordered by position
+func (m *DBModel) GetAllCategories() ([]Category, error) {
+	return m.queryCategories(`SELECT ` + categoryColumns + ` FROM categories ORDER BY position, id`)
+}
+
+// GetVisibleCategories retrieves the categories shown on the public menu, ordered by position
+func (m *DBModel) GetVisibleCategories() ([]Category, error) {
+	return m.queryCategories(`SELECT ` + categoryColumns + ` FROM categories WHERE visible = 1 ORDER BY position, id`)
+}
+
+// queryCategories runs a category query and scans all rows
+func (m *DBModel) queryCategories(query string, args ...any) ([]Category, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []Category
+
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		categories = append(categories, c)
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// GetCategoryByID retrieves a category by its ID
+func (m *DBModel) GetCategoryByID(id int) (Category, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	row := m.DB.QueryRowContext(ctx, `SELECT `+categoryColumns+` FROM categories WHERE id = ?`, id)
+
+	return scanCategory(row)
+}
+
+// InsertCategory inserts a new category with its translations at the end of the list
+func (m *DBModel) InsertCategory(c Category) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	err = checkCategorySlug(ctx, tx, c.Slug, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt := `INSERT INTO categories (slug, name_it, name_de, description, position, visible, created_at, updated_at)
+             VALUES (?, ?, ?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM categories), ?, ?, ?)
+             RETURNING id`
+
+	var newID int
+	err = tx.QueryRowContext(ctx, stmt,
+		c.Slug,
+		c.NameIT,
+		c.NameDE,
+		c.Description,
+		c.Visible,
+		time.Now(),
+		time.Now(),
+	).Scan(&newID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = saveTranslations(ctx, tx, TranslationCategory, newID, c.Translations)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// UpdateCategory updates the names, slug, description, visibility and translations of a category
+func (m *DBModel) UpdateCategory(c Category) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	err = checkCategorySlug(ctx, tx, c.Slug, c.ID)
+	if err != nil {
+		return err
+	}
+
+	stmt := `UPDATE categories SET
+             slug = ?,
+             name_it = ?,
+             name_de = ?,
+             description = ?,
+             visible = ?,
+             updated_at = ?
+             WHERE id = ?`
+
+	_, err = tx.ExecContext(ctx, stmt,
+		c.Slug,
+		c.NameIT,
+		c.NameDE,
+		c.Description,
+		c.Visible,
+		time.Now(),
+		c.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = saveTranslations(ctx, tx, TranslationCategory, c.ID, c.Translations)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkCategorySlug returns ErrCategorySlugTaken if a category other than id already uses the slug
+func checkCategorySlug(ctx context.Context, tx *sql.Tx, slug string, id int) error {
+	var taken int
+
+	err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM categories WHERE slug = ? AND id != ?`, slug, id).Scan(&taken)
+	if err != nil {
+		return err
+	}
+
+	if taken > 0 {
+		return ErrCategorySlugTaken
+	}
+
+	return nil
+}
+
+// SetCategoryVisibility shows or hides a category on the public menu
+func (m *DBModel) SetCategoryVisibility(id int, visible bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE categories SET visible = ?, updated_at = ? WHERE id = ?`
+	_, err := m.DB.ExecContext(ctx, stmt, visible, time.Now(), id)
+
+	return err
+}
+
+// ReorderCategories rewrites the positions of all categories in the given order
+func (m *DBModel) ReorderCategories(ids []int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	for i, id := range ids {
+		_, err := tx.ExecContext(ctx, `UPDATE categories SET position = ?, updated_at = ? WHERE id = ?`, i+1, time.Now(), id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteCategory deletes a category, refusing if menu items still reference it.
+// Items in the trash count too, so they can still be restored into their category; they are
+// reported with ErrCategoryInTrash, so the admin knows to purge them.
+func (m *DBModel) DeleteCategory(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	var live, trashed int
+
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) - COUNT(deleted_at), COUNT(deleted_at) FROM menu_items WHERE category_id = ?`,
+		id).Scan(&live, &trashed)
+	if err != nil {
+		return err
+	}
+
+	if live > 0 {
+		return ErrCategoryInUse
+	}
+
+	if trashed > 0 {
+		return ErrCategoryInTrash
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM extra_assignments WHERE category_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	err = deleteTranslations(ctx, tx, TranslationCategory, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM categories WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,314 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Extra is a topping or side that can be added to a dish for a surcharge, e.g. "extra Käse"
+type Extra struct {
+	ID           int
+	Name         string
+	Price        Money  // Surcharge for the normal size
+	SmallPrice   *Money // Surcharge for the "klein" size; nil means the same as Price
+	Position     int
+	CategoryIDs  []int // Categories the extra applies to as a whole
+	FromCategory bool  // Set on MenuItem.Extras when the extra applies through the item's category
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// HasSmallPrice reports whether the small size has its own surcharge
+func (e Extra) HasSmallPrice() bool {
+	return e.SmallPrice != nil && e.SmallPrice.Cents != e.Price.Cents
+}
+
+// SmallSurcharge returns the surcharge for the small size
+func (e Extra) SmallSurcharge() Money {
+	if e.SmallPrice != nil {
+		return *e.SmallPrice
+	}
+
+	return e.Price
+}
+
+// SurchargeFor returns the surcharge for a variant; variants labelled "klein" use the small surcharge
+func (e Extra) SurchargeFor(v Variant) Money {
+	if strings.EqualFold(strings.TrimSpace(v.Label), "klein") {
+		return e.SmallSurcharge()
+	}
+
+	return e.Price
+}
+
+// AppliesToCategory reports whether the extra is assigned to the given category
+func (e Extra) AppliesToCategory(categoryID int) bool {
+	return slices.Contains(e.CategoryIDs, categoryID)
+}
+
+// HasExtra reports whether the extra is assigned directly to the item
+func (item MenuItem) HasExtra(id int) bool {
+	for _, e := range item.Extras {
+		if e.ID == id && !e.FromCategory {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ItemExtras returns the extras assigned directly to the item, without those of its category
+func (item MenuItem) ItemExtras() []Extra {
+	var extras []Extra
+
+	for _, e := range item.Extras {
+		if !e.FromCategory {
+			extras = append(extras, e)
+		}
+	}
+
+	return extras
+}
+
+// GetAllExtras retrieves all extras with their category assignments, ordered by position
+func (m *DBModel) GetAllExtras() ([]Extra, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT id, name, price, small_price, position, created_at, updated_at
+		FROM extras ORDER BY position, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var extras []Extra
+
+	byID := make(map[int]int)
+
+	for rows.Next() {
+		var e Extra
+
+		err := rows.Scan(&e.ID, &e.Name, &e.Price, &e.SmallPrice, &e.Position, &e.CreatedAt, &e.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		byID[e.ID] = len(extras)
+		extras = append(extras, e)
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = m.DB.QueryContext(ctx, `SELECT extra_id, category_id FROM extra_assignments
+		WHERE category_id IS NOT NULL ORDER BY category_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var extraID, categoryID int
+
+		err := rows.Scan(&extraID, &categoryID)
+		if err != nil {
+			return nil, err
+		}
+
+		if i, ok := byID[extraID]; ok {
+			extras[i].CategoryIDs = append(extras[i].CategoryIDs, categoryID)
+		}
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return extras, nil
+}
+
+// InsertExtra inserts a new extra at the end of the list together with its category assignments
+func (m *DBModel) InsertExtra(e Extra) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	stmt := `INSERT INTO extras (name, price, small_price, position, created_at, updated_at)
+             VALUES (?, ?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM extras), ?, ?)
+             RETURNING id`
+
+	var newID int
+
+	err = tx.QueryRowContext(ctx, stmt, e.Name, e.Price, e.SmallPrice, time.Now(), time.Now()).Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	e.ID = newID
+
+	err = saveExtraCategories(ctx, tx, e)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// UpdateExtra updates an extra and replaces its category assignments
+func (m *DBModel) UpdateExtra(e Extra) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	_, err = tx.ExecContext(ctx, `UPDATE extras SET name = ?, price = ?, small_price = ?, updated_at = ? WHERE id = ?`,
+		e.Name, e.Price, e.SmallPrice, time.Now(), e.ID)
+	if err != nil {
+		return err
+	}
+
+	err = saveExtraCategories(ctx, tx, e)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteExtra deletes an extra and all of its assignments
+func (m *DBModel) DeleteExtra(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM extra_assignments WHERE extra_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM extras WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// saveExtraCategories replaces the category assignments of an extra inside a transaction
+func saveExtraCategories(ctx context.Context, tx *sql.Tx, e Extra) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM extra_assignments WHERE extra_id = ? AND category_id IS NOT NULL`, e.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, categoryID := range e.CategoryIDs {
+		_, err = tx.ExecContext(ctx, `INSERT OR IGNORE INTO extra_assignments (extra_id, category_id) VALUES (?, ?)`, e.ID, categoryID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadExtras attaches the extras that apply to each item, either directly or through its category
+func (m *DBModel) loadExtras(ctx context.Context, items []MenuItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT COALESCE(a.menu_item_id, 0), COALESCE(a.category_id, 0),
+		e.id, e.name, e.price, e.small_price, e.position, e.created_at, e.updated_at
+		FROM extra_assignments a
+		JOIN extras e ON e.id = a.extra_id
+		ORDER BY e.position, e.id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byItem := make(map[int][]Extra)
+	byCategory := make(map[int][]Extra)
+
+	for rows.Next() {
+		var itemID, categoryID int
+
+		var e Extra
+
+		err := rows.Scan(&itemID, &categoryID, &e.ID, &e.Name, &e.Price, &e.SmallPrice, &e.Position, &e.CreatedAt, &e.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		if itemID != 0 {
+			byItem[itemID] = append(byItem[itemID], e)
+		} else {
+			e.FromCategory = true
+			byCategory[categoryID] = append(byCategory[categoryID], e)
+		}
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range items {
+		extras := slices.Clone(byItem[items[i].ID])
+
+		// Category extras are added unless the item already has the same extra directly
+		for _, e := range byCategory[items[i].CategoryID] {
+			if !slices.ContainsFunc(extras, func(x Extra) bool { return x.ID == e.ID }) {
+				extras = append(extras, e)
+			}
+		}
+
+		slices.SortStableFunc(extras, func(a, b Extra) int { return a.Position - b.Position })
+		items[i].Extras = extras
+	}
+
+	return nil
+}
+
+// saveItemExtras replaces the extras assigned directly to a menu item inside a transaction
+func saveItemExtras(ctx context.Context, tx *sql.Tx, item MenuItem) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM extra_assignments WHERE menu_item_id = ?`, item.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range item.ItemExtras() {
+		_, err = tx.ExecContext(ctx, `INSERT OR IGNORE INTO extra_assignments (extra_id, menu_item_id) VALUES (?, ?)`, e.ID, item.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -16,6 +16,9 @@ type FlashMessage struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Status    string
+
+	// Translated message to save with the flash message, by language. Not loaded with the message.
+	Translations map[string]Translation
 }
 
 // GetStatus returns the appropriate status for the flash message based on date range
@@ -50,17 +53,23 @@ func (f *FlashMessage) GetStatus() string {
 	return "Active"
 }
 
-// CreateFlashMessage creates a new flash message in the database
+// CreateFlashMessage creates a new flash message with its translations in the database
 func (m *DBModel) CreateFlashMessage(message FlashMessage) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
 	stmt := `INSERT INTO flash_messages (type, message, start_date, end_date, active, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
 		RETURNING id`
 
 	var newID int
-	err := m.DB.QueryRowContext(ctx, stmt,
+	err = tx.QueryRowContext(ctx, stmt,
 		message.Type,
 		message.Message,
 		message.StartDate,
@@ -74,6 +83,16 @@ func (m *DBModel) CreateFlashMessage(message FlashMessage) (int, error) {
 		return 0, err
 	}
 
+	err = saveTranslations(ctx, tx, TranslationFlashMessage, newID, message.Translations)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
 	return newID, nil
 }
 
@@ -175,13 +194,93 @@ func (m *DBModel) GetAllFlashMessages() ([]FlashMessage, error) {
 	return messages, nil
 }
 
-// DeleteFlashMessage deletes a flash message from the database
+// GetFlashMessageByID retrieves a single flash message
+func (m *DBModel) GetFlashMessageByID(id int) (FlashMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT id, type, message, start_date, end_date, active, created_at, updated_at
+		FROM flash_messages
+		WHERE id = ?`
+
+	var msg FlashMessage
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&msg.ID,
+		&msg.Type,
+		&msg.Message,
+		&msg.StartDate,
+		&msg.EndDate,
+		&msg.Active,
+		&msg.CreatedAt,
+		&msg.UpdatedAt,
+	)
+	if err != nil {
+		return FlashMessage{}, err
+	}
+
+	msg.Status = msg.GetStatus()
+
+	return msg, nil
+}
+
+// UpdateFlashMessage changes the text, type, dates, active flag and translations of a flash message
+func (m *DBModel) UpdateFlashMessage(message FlashMessage) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	stmt := `UPDATE flash_messages SET type = ?, message = ?, start_date = ?, end_date = ?, active = ?, updated_at = ?
+		WHERE id = ?`
+
+	err = execOne(ctx, tx, stmt,
+		message.Type,
+		message.Message,
+		message.StartDate,
+		message.EndDate,
+		message.Active,
+		time.Now(),
+		message.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = saveTranslations(ctx, tx, TranslationFlashMessage, message.ID, message.Translations)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteFlashMessage deletes a flash message and its translations from the database
 func (m *DBModel) DeleteFlashMessage(id int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
 	stmt := `DELETE FROM flash_messages WHERE id = ?`
-	_, err := m.DB.ExecContext(ctx, stmt, id)
 
-	return err
+	_, err = tx.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+
+	err = deleteTranslations(ctx, tx, TranslationFlashMessage, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
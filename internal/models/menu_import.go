@@ -0,0 +1,195 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrImportMismatch is returned when an imported item cannot be matched to the current menu
+var ErrImportMismatch = errors.New("cannot match imported item")
+
+// MenuImport is the outcome of importing a whole menu: the items it adds, changes and removes
+type MenuImport struct {
+	New       []MenuItem
+	Changed   []ImportChange
+	Removed   []MenuItem // Items missing from the import, which are moved to the trash
+	Unchanged int
+}
+
+// ImportChange is an existing menu item that the import changes
+type ImportChange struct {
+	Before  MenuItem
+	After   MenuItem
+	Changes []FieldChange
+}
+
+// IsEmpty reports whether the import leaves the menu as it is
+func (p MenuImport) IsEmpty() bool {
+	return len(p.New) == 0 && len(p.Changed) == 0 && len(p.Removed) == 0
+}
+
+// planMenuImport matches imported items to the current menu, by ID where given and otherwise by name.
+// A name shared by several current items is narrowed down by category. Matched items keep the IDs of
+// variants with the same label, so scheduled prices stay attached.
+func planMenuImport(current, imported []MenuItem) (MenuImport, error) {
+	var plan MenuImport
+
+	byID := make(map[int]MenuItem, len(current))
+	for _, item := range current {
+		byID[item.ID] = item
+	}
+
+	matched := make(map[int]bool, len(imported))
+
+	for _, item := range imported {
+		var before MenuItem
+
+		switch {
+		case item.ID != 0:
+			existing, ok := byID[item.ID]
+			if !ok {
+				return plan, fmt.Errorf("%w: no menu item with ID %d", ErrImportMismatch, item.ID)
+			}
+
+			before = existing
+		default:
+			candidates := matchByName(current, item)
+
+			switch len(candidates) {
+			case 0:
+				plan.New = append(plan.New, item)
+				continue
+			case 1:
+				before = candidates[0]
+			default:
+				return plan, fmt.Errorf("%w: %q is on the menu more than once, add the ID", ErrImportMismatch, item.Name)
+			}
+		}
+
+		if matched[before.ID] {
+			return plan, fmt.Errorf("%w: %q (ID %d) is imported twice", ErrImportMismatch, before.Name, before.ID)
+		}
+
+		matched[before.ID] = true
+
+		after := item
+		after.ID = before.ID
+		after.Variants = keepVariantIDs(before.Variants, item.Variants)
+
+		changes := DiffMenuItems(before, after)
+		if len(changes) == 0 {
+			plan.Unchanged++
+			continue
+		}
+
+		plan.Changed = append(plan.Changed, ImportChange{Before: before, After: after, Changes: changes})
+	}
+
+	for _, item := range current {
+		if !matched[item.ID] {
+			plan.Removed = append(plan.Removed, item)
+		}
+	}
+
+	return plan, nil
+}
+
+// matchByName finds the current items with the name of an imported item, preferring those in its category
+func matchByName(current []MenuItem, item MenuItem) []MenuItem {
+	var candidates []MenuItem
+
+	for _, c := range current {
+		if strings.EqualFold(strings.TrimSpace(c.Name), strings.TrimSpace(item.Name)) {
+			candidates = append(candidates, c)
+		}
+	}
+
+	if len(candidates) < 2 {
+		return candidates
+	}
+
+	var sameCategory []MenuItem
+
+	for _, c := range candidates {
+		if c.CategoryID == item.CategoryID {
+			sameCategory = append(sameCategory, c)
+		}
+	}
+
+	if len(sameCategory) == 0 {
+		return candidates
+	}
+
+	return sameCategory
+}
+
+// keepVariantIDs gives imported variants the ID of the current variant with the same label
+func keepVariantIDs(current, imported []Variant) []Variant {
+	ids := make(map[string]int, len(current))
+	for _, v := range current {
+		ids[strings.ToLower(v.Label)] = v.ID
+	}
+
+	variants := make([]Variant, len(imported))
+
+	for i, v := range imported {
+		v.ID = ids[strings.ToLower(v.Label)]
+		variants[i] = v
+	}
+
+	return variants
+}
+
+// PlanMenuImport compares imported items with the current menu without changing anything
+func (m *DBModel) PlanMenuImport(imported []MenuItem) (MenuImport, error) {
+	current, err := m.GetAllMenuItems()
+	if err != nil {
+		return MenuImport{}, err
+	}
+
+	return planMenuImport(current, imported)
+}
+
+// ApplyMenuImport makes the menu match the imported items in one transaction: new items are inserted,
+// changed ones updated and items missing from the import moved to the trash, each with a revision
+func (m *DBModel) ApplyMenuImport(imported []MenuItem, changedBy string) (MenuImport, error) {
+	plan, err := m.PlanMenuImport(imported)
+	if err != nil {
+		return plan, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return plan, err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	for _, item := range plan.New {
+		_, err = insertMenuItem(ctx, tx, item, changedBy)
+		if err != nil {
+			return plan, fmt.Errorf("adding %q: %w", item.Name, err)
+		}
+	}
+
+	for _, c := range plan.Changed {
+		err = saveMenuItem(ctx, tx, c.After, RevisionUpdate, changedBy)
+		if err != nil {
+			return plan, fmt.Errorf("updating %q: %w", c.After.Name, err)
+		}
+	}
+
+	for _, item := range plan.Removed {
+		err = trashMenuItem(ctx, tx, item, changedBy)
+		if err != nil {
+			return plan, fmt.Errorf("removing %q: %w", item.Name, err)
+		}
+	}
+
+	return plan, tx.Commit()
+}
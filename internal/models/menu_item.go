@@ -2,31 +2,168 @@ package models
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 )
 
+// ErrInvalidOrdering is returned when a reorder request does not list exactly the items of a category
+var ErrInvalidOrdering = errors.New("ordering must list every item of the category exactly once")
+
 // MenuItem represents a menu item in the database
 type MenuItem struct {
-	ID          int
-	Name        string
-	Description string
-	Price       float64
-	SmallPrice  *float64
-	Category    string
-	ImageURL    string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-}
-
-// GetAllMenuItems retrieves all menu items from the database
+	ID           int
+	Name         string
+	Description  string
+	CategoryID   int
+	Category     string // Italian name of the category, joined from the categories table
+	CategorySlug string
+	ImageURL     string
+	Position     int       // Sort order within the category
+	Variants     []Variant // Sizes or portions with their prices, in display order
+	Allergens    []Allergen
+	Additives    []Additive
+	Tags         []Tag
+	Extras       []Extra // Extras for this item, including those of its category
+	Availability Availability
+	SoldOutUntil *time.Time           // Last day a sold out item stays sold out; nil means until switched back
+	Windows      []AvailabilityWindow // Weekly times the item is offered; empty means always
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    *time.Time // Set while the item is in the trash
+
+	// Translated name and description to save with the item, by language. Not loaded with the item
+	// and not part of its revisions.
+	Translations map[string]Translation `json:"-"`
+}
+
+// menuItemSelect selects menu items together with their category
+const menuItemSelect = `SELECT m.id, m.name, m.description,
+              COALESCE(m.category_id, 0), COALESCE(c.name_it, ''), COALESCE(c.slug, ''),
+              m.image_url, m.position, m.availability, m.sold_out_until, m.created_at, m.updated_at, m.deleted_at
+              FROM menu_items m
+              LEFT JOIN categories c ON c.id = m.category_id`
+
+// scanMenuItem scans a row selected with menuItemSelect
+func scanMenuItem(row interface{ Scan(...any) error }) (MenuItem, error) {
+	var item MenuItem
+
+	var soldOutUntil, deletedAt sql.NullTime
+
+	err := row.Scan(
+		&item.ID,
+		&item.Name,
+		&item.Description,
+		&item.CategoryID,
+		&item.Category,
+		&item.CategorySlug,
+		&item.ImageURL,
+		&item.Position,
+		&item.Availability,
+		&soldOutUntil,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+		&deletedAt,
+	)
+
+	if soldOutUntil.Valid {
+		item.SoldOutUntil = &soldOutUntil.Time
+	}
+
+	if deletedAt.Valid {
+		item.DeletedAt = &deletedAt.Time
+	}
+
+	return item, err
+}
+
+// loadRelations attaches variants, allergens, additives, tags, extras and availability windows to the given items.
+// Variant prices are the ones in effect at the given time.
+func (m *DBModel) loadRelations(ctx context.Context, items []MenuItem, at time.Time) error {
+	err := m.loadVariants(ctx, items, at)
+	if err != nil {
+		return err
+	}
+
+	err = m.loadDeclarations(ctx, items)
+	if err != nil {
+		return err
+	}
+
+	err = m.loadTags(ctx, items)
+	if err != nil {
+		return err
+	}
+
+	err = m.loadExtras(ctx, items)
+	if err != nil {
+		return err
+	}
+
+	return m.loadWindows(ctx, items)
+}
+
+// saveRelations replaces the allergen, additive, tag and extra links and the availability windows of a menu item
+// inside a transaction
+func saveRelations(ctx context.Context, tx *sql.Tx, item MenuItem) error {
+	err := saveDeclarations(ctx, tx, item)
+	if err != nil {
+		return err
+	}
+
+	err = saveTags(ctx, tx, item)
+	if err != nil {
+		return err
+	}
+
+	err = saveItemExtras(ctx, tx, item)
+	if err != nil {
+		return err
+	}
+
+	return saveWindows(ctx, tx, item)
+}
+
+// availability returns the item's availability, treating an unset one as available
+func (item MenuItem) availability() Availability {
+	if item.Availability == "" {
+		return AvailabilityAvailable
+	}
+
+	return item.Availability
+}
+
+// soldOutUntil returns the sold out date, which only applies to sold out items
+func (item MenuItem) soldOutUntil() *time.Time {
+	if item.availability() != AvailabilitySoldOut {
+		return nil
+	}
+
+	return item.SoldOutUntil
+}
+
+// GetAllMenuItems retrieves all menu items that are not in the trash, ordered by category and item position
 func (m *DBModel) GetAllMenuItems() ([]MenuItem, error) {
+	return m.GetAllMenuItemsAt(time.Now())
+}
+
+// GetAllMenuItemsAt retrieves all menu items like GetAllMenuItems, with the prices in effect at the given time
+func (m *DBModel) GetAllMenuItemsAt(at time.Time) ([]MenuItem, error) {
+	return m.queryMenuItems(at, menuItemSelect+` WHERE m.deleted_at IS NULL ORDER BY c.position, m.position, m.name`)
+}
+
+// GetTrashedMenuItems retrieves the menu items in the trash, most recently deleted first
+func (m *DBModel) GetTrashedMenuItems() ([]MenuItem, error) {
+	return m.queryMenuItems(time.Now(), menuItemSelect+` WHERE m.deleted_at IS NOT NULL ORDER BY m.deleted_at DESC, m.id`)
+}
+
+// queryMenuItems runs a menu item query and attaches the related rows, with prices in effect at the given time
+func (m *DBModel) queryMenuItems(at time.Time, query string, args ...any) ([]MenuItem, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `SELECT id, name, description, price, small_price, category, image_url, 
-              created_at, updated_at FROM menu_items ORDER BY category, name`
-
-	rows, err := m.DB.QueryContext(ctx, query)
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -35,18 +172,7 @@ func (m *DBModel) GetAllMenuItems() ([]MenuItem, error) {
 	var items []MenuItem
 
 	for rows.Next() {
-		var item MenuItem
-		err := rows.Scan(
-			&item.ID,
-			&item.Name,
-			&item.Description,
-			&item.Price,
-			&item.SmallPrice,
-			&item.Category,
-			&item.ImageURL,
-			&item.CreatedAt,
-			&item.UpdatedAt,
-		)
+		item, err := scanMenuItem(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -59,57 +185,88 @@ func (m *DBModel) GetAllMenuItems() ([]MenuItem, error) {
 		return nil, err
 	}
 
+	err = m.loadRelations(ctx, items, at)
+	if err != nil {
+		return nil, err
+	}
+
 	return items, nil
 }
 
-// GetMenuItemByID retrieves a menu item by its ID
+// GetMenuItemByID retrieves a menu item by its ID; items in the trash are not found
 func (m *DBModel) GetMenuItemByID(id int) (MenuItem, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `SELECT id, name, description, price, small_price, category, image_url, 
-              created_at, updated_at FROM menu_items WHERE id = ?`
+	return m.menuItemByID(ctx, id, false)
+}
 
-	var item MenuItem
+// menuItemByID retrieves a live menu item, or one from the trash when trashed is set
+func (m *DBModel) menuItemByID(ctx context.Context, id int, trashed bool) (MenuItem, error) {
+	query := menuItemSelect + ` WHERE m.id = ? AND m.deleted_at IS NULL`
+	if trashed {
+		query = menuItemSelect + ` WHERE m.id = ? AND m.deleted_at IS NOT NULL`
+	}
 
 	row := m.DB.QueryRowContext(ctx, query, id)
 
-	err := row.Scan(
-		&item.ID,
-		&item.Name,
-		&item.Description,
-		&item.Price,
-		&item.SmallPrice,
-		&item.Category,
-		&item.ImageURL,
-		&item.CreatedAt,
-		&item.UpdatedAt,
-	)
+	item, err := scanMenuItem(row)
+	if err != nil {
+		return item, err
+	}
+
+	items := []MenuItem{item}
 
+	err = m.loadRelations(ctx, items, time.Now())
 	if err != nil {
 		return item, err
 	}
 
-	return item, nil
+	return items[0], nil
 }
 
-// InsertMenuItem inserts a new menu item into the database
-func (m *DBModel) InsertMenuItem(item MenuItem) (int, error) {
+// InsertMenuItem inserts a new menu item together with its variants, allergens, additives, tags, extras and translations,
+// recording who created it in the revision history
+func (m *DBModel) InsertMenuItem(item MenuItem, changedBy string) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	stmt := `INSERT INTO menu_items (name, description, price, small_price, category, image_url, created_at, updated_at)
-             VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	newID, err := insertMenuItem(ctx, tx, item, changedBy)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// insertMenuItem inserts a menu item with its relations and create revision inside a transaction
+func insertMenuItem(ctx context.Context, tx *sql.Tx, item MenuItem, changedBy string) (int, error) {
+	// New items are appended to the end of their category
+	stmt := `INSERT INTO menu_items (name, description, category_id, image_url, position, availability, sold_out_until,
+             created_at, updated_at)
+             VALUES (?, ?, ?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM menu_items WHERE category_id = ? AND deleted_at IS NULL), ?, ?, ?, ?)
              RETURNING id`
 
 	var newID int
-	err := m.DB.QueryRowContext(ctx, stmt,
+	err := tx.QueryRowContext(ctx, stmt,
 		item.Name,
 		item.Description,
-		item.Price,
-		item.SmallPrice,
-		item.Category,
+		item.CategoryID,
 		item.ImageURL,
+		item.CategoryID,
+		item.availability(),
+		dateValue(item.soldOutUntil()),
 		time.Now(),
 		time.Now(),
 	).Scan(&newID)
@@ -118,45 +275,313 @@ func (m *DBModel) InsertMenuItem(item MenuItem) (int, error) {
 		return 0, err
 	}
 
+	item.ID = newID
+
+	err = saveVariants(ctx, tx, item)
+	if err != nil {
+		return 0, err
+	}
+
+	err = saveRelations(ctx, tx, item)
+	if err != nil {
+		return 0, err
+	}
+
+	err = saveTranslations(ctx, tx, TranslationMenuItem, newID, item.Translations)
+	if err != nil {
+		return 0, err
+	}
+
+	err = writeRevision(ctx, tx, item, RevisionCreate, changedBy)
+	if err != nil {
+		return 0, err
+	}
+
 	return newID, nil
 }
 
-// UpdateMenuItem updates an existing menu item in the database
-func (m *DBModel) UpdateMenuItem(item MenuItem) error {
+// UpdateMenuItem updates an existing menu item together with its variants, allergens, additives, tags, extras and translations,
+// recording who changed it in the revision history
+func (m *DBModel) UpdateMenuItem(item MenuItem, changedBy string) error {
+	return m.updateMenuItem(item, RevisionUpdate, changedBy)
+}
+
+// RevertMenuItem rolls a menu item back to the state stored in an earlier revision
+func (m *DBModel) RevertMenuItem(item MenuItem, changedBy string) error {
+	return m.updateMenuItem(item, RevisionRevert, changedBy)
+}
+
+// updateMenuItem updates a menu item and records the change as the given revision action
+func (m *DBModel) updateMenuItem(item MenuItem, action RevisionAction, changedBy string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	err = saveMenuItem(ctx, tx, item, action, changedBy)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// saveMenuItem updates a live menu item with its relations inside a transaction and records the revision
+func saveMenuItem(ctx context.Context, tx *sql.Tx, item MenuItem, action RevisionAction, changedBy string) error {
 	stmt := `UPDATE menu_items SET
              name = ?,
              description = ?,
-             price = ?,
-             small_price = ?,
-             category = ?,
+             position = CASE WHEN category_id = ? THEN position
+                        ELSE (SELECT COALESCE(MAX(position), 0) + 1 FROM menu_items WHERE category_id = ? AND deleted_at IS NULL) END,
+             category_id = ?,
              image_url = ?,
+             availability = ?,
+             sold_out_until = ?,
              updated_at = ?
-             WHERE id = ?`
+             WHERE id = ? AND deleted_at IS NULL`
 
-	_, err := m.DB.ExecContext(ctx, stmt,
+	// An item moved to another category goes to the end of that category
+	err := execOne(ctx, tx, stmt,
 		item.Name,
 		item.Description,
-		item.Price,
-		item.SmallPrice,
-		item.Category,
+		item.CategoryID,
+		item.CategoryID,
+		item.CategoryID,
 		item.ImageURL,
+		item.availability(),
+		dateValue(item.soldOutUntil()),
 		time.Now(),
 		item.ID,
 	)
+	if err != nil {
+		return err
+	}
+
+	err = saveVariants(ctx, tx, item)
+	if err != nil {
+		return err
+	}
+
+	err = saveRelations(ctx, tx, item)
+	if err != nil {
+		return err
+	}
+
+	err = saveTranslations(ctx, tx, TranslationMenuItem, item.ID, item.Translations)
+	if err != nil {
+		return err
+	}
+
+	return writeRevision(ctx, tx, item, action, changedBy)
+}
+
+// DeleteMenuItem moves a menu item to the trash. It keeps its variants, links and image so it can be restored.
+func (m *DBModel) DeleteMenuItem(id int, changedBy string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	item, err := m.menuItemByID(ctx, id, false)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	err = trashMenuItem(ctx, tx, item, changedBy)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// trashMenuItem moves a live menu item to the trash inside a transaction and records the revision
+func trashMenuItem(ctx context.Context, tx *sql.Tx, item MenuItem, changedBy string) error {
+	err := execOne(ctx, tx, `UPDATE menu_items SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`, time.Now(), item.ID)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return writeRevision(ctx, tx, item, RevisionDelete, changedBy)
 }
 
-// DeleteMenuItem deletes a menu item from the database
-func (m *DBModel) DeleteMenuItem(id int) error {
+// RestoreMenuItem takes a menu item out of the trash and puts it at the end of its category
+func (m *DBModel) RestoreMenuItem(id int, changedBy string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	stmt := `DELETE FROM menu_items WHERE id = ?`
-	_, err := m.DB.ExecContext(ctx, stmt, id)
+	item, err := m.menuItemByID(ctx, id, true)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	stmt := `UPDATE menu_items SET
+             deleted_at = NULL,
+             position = (SELECT COALESCE(MAX(position), 0) + 1 FROM menu_items o
+                         WHERE o.category_id = menu_items.category_id AND o.deleted_at IS NULL),
+             updated_at = ?
+             WHERE id = ? AND deleted_at IS NOT NULL`
+
+	err = execOne(ctx, tx, stmt, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	err = writeRevision(ctx, tx, item, RevisionRestore, changedBy)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PurgeMenuItem permanently deletes a menu item from the trash together with its variants, windows,
+// revision history and its allergen, additive, tag and extra links
+func (m *DBModel) PurgeMenuItem(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	// Only items in the trash can be purged
+	err = execOne(ctx, tx, `DELETE FROM menu_items WHERE id = ? AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	// SQLite does not enforce the ON DELETE CASCADE without the foreign_keys pragma
+	_, err = tx.ExecContext(ctx, `DELETE FROM menu_item_allergens WHERE menu_item_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM menu_item_additives WHERE menu_item_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM menu_item_tags WHERE menu_item_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM extra_assignments WHERE menu_item_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM menu_item_windows WHERE menu_item_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	err = deleteVariants(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM menu_item_revisions WHERE menu_item_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	err = deleteTranslations(ctx, tx, TranslationMenuItem, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// execOne runs a statement that must affect exactly one row, returning sql.ErrNoRows otherwise
+func execOne(ctx context.Context, db execer, query string, args ...any) error {
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ReorderMenuItems rewrites the positions of all items in a category in one transaction
+func (m *DBModel) ReorderMenuItems(categoryID int, ids []int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	var count int
+
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM menu_items WHERE category_id = ? AND deleted_at IS NULL`, categoryID).Scan(&count)
+	if err != nil {
+		return err
+	}
+
+	if count != len(ids) {
+		return ErrInvalidOrdering
+	}
+
+	seen := make(map[int]bool, len(ids))
+
+	for i, id := range ids {
+		if seen[id] {
+			return ErrInvalidOrdering
+		}
+
+		seen[id] = true
+
+		result, err := tx.ExecContext(ctx, `UPDATE menu_items SET position = ?, updated_at = ?
+			WHERE id = ? AND category_id = ? AND deleted_at IS NULL`,
+			i+1, time.Now(), id, categoryID)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected != 1 {
+			return fmt.Errorf("menu item %d is not in category %d: %w", id, categoryID, ErrInvalidOrdering)
+		}
+	}
 
-	return err
+	return tx.Commit()
 }
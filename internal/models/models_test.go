@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -80,4 +81,281 @@ func TestFlashMessage_GetStatus(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestCategory_Label(t *testing.T) {
+	tests := []struct {
+		name     string
+		category Category
+		want     string
+	}{
+		{name: "Bilingual", category: Category{NameIT: "Carne", NameDE: "Fleisch"}, want: "Carne / Fleisch"},
+		{name: "Italian only", category: Category{NameIT: "Pizza"}, want: "Pizza"},
+		{name: "Same name in both languages", category: Category{NameIT: "Pasta", NameDE: "pasta"}, want: "Pasta"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.category.Label(); got != tt.want {
+				t.Errorf("Category.Label() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Pasta al Forno":            "pasta-al-forno",
+		"  Pesce Fritto  ":          "pesce-fritto",
+		"Nudelgerichte überbacken": "nudelgerichte-ueberbacken",
+		"Antipasti / Vorspeisen":    "antipasti-vorspeisen",
+		"":                          "",
+	}
+
+	for input, want := range tests {
+		if got := Slugify(input); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestMenuItem_DeclarationCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		item MenuItem
+		want string
+	}{
+		{name: "Nothing declared", item: MenuItem{}, want: ""},
+		{name: "Allergens only", item: MenuItem{Allergens: []Allergen{{Code: "A"}, {Code: "G"}}}, want: "A, G"},
+		{
+			name: "Allergens before additives",
+			item: MenuItem{Allergens: []Allergen{{Code: "G"}}, Additives: []Additive{{Code: "1"}, {Code: "11"}}},
+			want: "G, 1, 11",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.item.DeclarationCodes(); got != tt.want {
+				t.Errorf("MenuItem.DeclarationCodes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMenuFilter_Matches(t *testing.T) {
+	vegan := Tag{ID: 1, Slug: "vegan"}
+	spicy := Tag{ID: 2, Slug: "spicy"}
+	glutenFree := Tag{ID: 3, Slug: "gluten-free"}
+
+	marinara := MenuItem{Name: "Marinara", CategorySlug: "pizza", Tags: []Tag{vegan}}
+	diavola := MenuItem{Name: "Diavola", CategorySlug: "pizza", Tags: []Tag{spicy}}
+	arrabbiata := MenuItem{Name: "Arrabbiata", CategorySlug: "penne", Tags: []Tag{vegan, spicy, glutenFree}}
+
+	tests := []struct {
+		name   string
+		filter MenuFilter
+		want   []string
+	}{
+		{name: "Empty filter", filter: MenuFilter{}, want: []string{"Marinara", "Diavola", "Arrabbiata"}},
+		{name: "Required tag", filter: MenuFilter{Require: []string{"vegan"}}, want: []string{"Marinara", "Arrabbiata"}},
+		{name: "All required tags", filter: MenuFilter{Require: []string{"vegan", "gluten-free"}}, want: []string{"Arrabbiata"}},
+		{name: "Excluded tag", filter: MenuFilter{Exclude: []string{"spicy"}}, want: []string{"Marinara"}},
+		{name: "Category and tags", filter: MenuFilter{Category: "pizza", Require: []string{"vegan"}, Exclude: []string{"spicy"}}, want: []string{"Marinara"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+
+			for _, item := range []MenuItem{marinara, diavola, arrabbiata} {
+				if tt.filter.Matches(item) {
+					got = append(got, item.Name)
+				}
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("MenuFilter.Matches() kept %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtra_SurchargeFor(t *testing.T) {
+	small := EUR(100)
+	cheese := Extra{Name: "extra Käse", Price: EUR(150), SmallPrice: &small}
+	basil := Extra{Name: "Basilikum", Price: EUR(50)}
+
+	tests := []struct {
+		name    string
+		extra   Extra
+		variant Variant
+		want    Money
+	}{
+		{name: "Normal size", extra: cheese, variant: Variant{Label: "normal"}, want: EUR(150)},
+		{name: "Small size", extra: cheese, variant: Variant{Label: "Klein"}, want: EUR(100)},
+		{name: "Small size without own surcharge", extra: basil, variant: Variant{Label: "klein"}, want: EUR(50)},
+		{name: "Unlabelled variant", extra: cheese, variant: Variant{}, want: EUR(150)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.extra.SurchargeFor(tt.variant); got != tt.want {
+				t.Errorf("Extra.SurchargeFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{input: "12,99", want: 1299},
+		{input: "12.99", want: 1299},
+		{input: "12,9", want: 1290},
+		{input: "7", want: 700},
+		{input: ",50", want: 50},
+		{input: "1.234,50", want: 123450},
+		{input: "€ 3", want: 300},
+		{input: "4,50 €", want: 450},
+		{input: "-1,00", want: -100},
+		{input: "1.234", wantErr: true},
+		{input: "1,234.50", wantErr: true},
+		{input: "abc", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseMoney(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMoney(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+
+			if !tt.wantErr && got.Cents != tt.want {
+				t.Errorf("ParseMoney(%q) = %d cents, want %d", tt.input, got.Cents, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_Format(t *testing.T) {
+	tests := []struct {
+		name   string
+		money  Money
+		locale string
+		want   string
+	}{
+		{name: "German", money: EUR(1299), locale: "de", want: "12,99 €"},
+		{name: "German thousands", money: EUR(123450), locale: "de", want: "1.234,50 €"},
+		{name: "English", money: EUR(123450), locale: "en", want: "€1,234.50"},
+		{name: "English negative", money: EUR(-50), locale: "en", want: "-€0.50"},
+		{name: "Zero", money: Money{}, locale: "de", want: "0,00 €"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.money.Format(tt.locale); got != tt.want {
+				t.Errorf("Money.Format(%q) = %q, want %q", tt.locale, got, tt.want)
+			}
+		})
+	}
+
+	if got := EUR(123450).Amount(); got != "1.234,50" {
+		t.Errorf("Money.Amount() = %q, want %q", got, "1.234,50")
+	}
+}
+
+func TestMoney_Scan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     any
+		want    int64
+		wantErr bool
+	}{
+		{name: "Integer cents", src: int64(1299), want: 1299},
+		{name: "Float is rounded", src: 1298.6, want: 1299},
+		{name: "Text cents", src: []byte("450"), want: 450},
+		{name: "Not a number", src: "12,99", wantErr: true},
+		{name: "Unsupported type", src: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Money
+
+			err := m.Scan(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Money.Scan(%v) error = %v, wantErr %v", tt.src, err, tt.wantErr)
+			}
+
+			if !tt.wantErr && m.Cents != tt.want {
+				t.Errorf("Money.Scan(%v) = %d cents, want %d", tt.src, m.Cents, tt.want)
+			}
+		})
+	}
+}
+
+func TestMenuItem_IsAvailableAt(t *testing.T) {
+	// Sunday 15 June 2025, 12:00
+	sunday := time.Date(2025, time.June, 15, 12, 0, 0, 0, time.UTC)
+	yesterday := sunday.AddDate(0, 0, -1)
+	lunch := []AvailabilityWindow{{Weekday: time.Sunday, Start: "11:30", End: "14:00"}}
+
+	tests := []struct {
+		name string
+		item MenuItem
+		at   time.Time
+		want bool
+	}{
+		{name: "Available", item: MenuItem{Availability: AvailabilityAvailable}, at: sunday, want: true},
+		{name: "Hidden", item: MenuItem{Availability: AvailabilityHidden}, at: sunday, want: false},
+		{name: "Sold out without date", item: MenuItem{Availability: AvailabilitySoldOut}, at: sunday, want: false},
+		{name: "Sold out until today", item: MenuItem{Availability: AvailabilitySoldOut, SoldOutUntil: &sunday}, at: sunday, want: false},
+		{name: "Sold out until yesterday", item: MenuItem{Availability: AvailabilitySoldOut, SoldOutUntil: &yesterday}, at: sunday, want: true},
+		{name: "Inside window", item: MenuItem{Availability: AvailabilityAvailable, Windows: lunch}, at: sunday, want: true},
+		{name: "Window end is exclusive", item: MenuItem{Availability: AvailabilityAvailable, Windows: lunch}, at: sunday.Add(2 * time.Hour), want: false},
+		{name: "Other weekday", item: MenuItem{Availability: AvailabilityAvailable, Windows: lunch}, at: yesterday, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.item.IsAvailableAt(tt.at); got != tt.want {
+				t.Errorf("MenuItem.IsAvailableAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceAdjustment_Apply(t *testing.T) {
+	tests := []struct {
+		name       string
+		adjustment PriceAdjustment
+		price      int64
+		want       int64
+	}{
+		{name: "percent", adjustment: PriceAdjustment{Percent: 500}, price: 800, want: 840},
+		{name: "percent rounds half up to the cent", adjustment: PriceAdjustment{Percent: 250}, price: 850, want: 871},
+		{name: "negative percent", adjustment: PriceAdjustment{Percent: -1000}, price: 1200, want: 1080},
+		{name: "amount", adjustment: PriceAdjustment{Amount: EUR(50)}, price: 800, want: 850},
+		{name: "negative amount", adjustment: PriceAdjustment{Amount: EUR(-100)}, price: 650, want: 550},
+		{name: "never below zero", adjustment: PriceAdjustment{Amount: EUR(-1000)}, price: 650, want: 0},
+		{name: "round to .50 down", adjustment: PriceAdjustment{Percent: 300, Rounding: Round50}, price: 800, want: 800},
+		{name: "round to .50 up", adjustment: PriceAdjustment{Percent: 500, Rounding: Round50}, price: 800, want: 850},
+		{name: "round to .90", adjustment: PriceAdjustment{Percent: 300, Rounding: Round90}, price: 800, want: 790},
+		{name: "round to .90 halfway", adjustment: PriceAdjustment{Amount: EUR(40), Rounding: Round90}, price: 800, want: 890},
+		{name: "round to .99", adjustment: PriceAdjustment{Percent: 1000, Rounding: Round99}, price: 1000, want: 1099},
+		{name: "round small amount to .90", adjustment: PriceAdjustment{Amount: EUR(-70), Rounding: Round90}, price: 100, want: 90},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.adjustment.Apply(EUR(tt.price))
+			if got != EUR(tt.want) {
+				t.Errorf("Apply(%d) = %d, want %d", tt.price, got.Cents, tt.want)
+			}
+		})
+	}
+}
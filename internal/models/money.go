@@ -0,0 +1,201 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency of all prices stored in the database
+const DefaultCurrency = "EUR"
+
+// ErrInvalidMoney is returned when a price cannot be parsed
+var ErrInvalidMoney = errors.New("invalid amount")
+
+// Money is an amount in cents. Prices are stored as INTEGER cents so sums never pick up float rounding errors.
+type Money struct {
+	Cents    int64
+	Currency string // ISO 4217 code; empty means DefaultCurrency
+}
+
+// EUR returns an amount in euro cents
+func EUR(cents int64) Money {
+	return Money{Cents: cents, Currency: DefaultCurrency}
+}
+
+// ParseMoney parses a user-entered amount. Both "12,99" and "12.99" are accepted, as are German
+// thousands separators ("1.234,50"), a leading sign and a € sign or EUR code around the number.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(s, "€"), "€"))
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(s, "EUR"), "EUR"))
+
+	negative := false
+
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		negative = s[0] == '-'
+		s = strings.TrimSpace(s[1:])
+	}
+
+	if s == "" {
+		return Money{}, ErrInvalidMoney
+	}
+
+	// The last separator is the decimal separator when both are used, e.g. "1.234,50"
+	integer, fraction := s, ""
+
+	switch {
+	case strings.Contains(s, ",") && strings.Contains(s, "."):
+		if strings.LastIndex(s, ",") < strings.LastIndex(s, ".") {
+			return Money{}, ErrInvalidMoney
+		}
+
+		integer, fraction, _ = strings.Cut(s, ",")
+		integer = strings.ReplaceAll(integer, ".", "")
+	case strings.Contains(s, ","):
+		integer, fraction, _ = strings.Cut(s, ",")
+	case strings.Contains(s, "."):
+		integer, fraction, _ = strings.Cut(s, ".")
+	}
+
+	if integer == "" {
+		integer = "0"
+	}
+
+	if len(fraction) > 2 || !isDigits(integer) || !isDigits(fraction) {
+		return Money{}, ErrInvalidMoney
+	}
+
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+
+	units, err := strconv.ParseInt(integer, 10, 64)
+	if err != nil || units > math.MaxInt64/100-1 {
+		return Money{}, ErrInvalidMoney
+	}
+
+	cents, _ := strconv.ParseInt(fraction, 10, 64)
+	cents += units * 100
+
+	if negative {
+		cents = -cents
+	}
+
+	return EUR(cents), nil
+}
+
+// isDigits reports whether s consists only of ASCII digits
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// currency returns the currency code, falling back to DefaultCurrency
+func (m Money) currency() string {
+	if m.Currency == "" {
+		return DefaultCurrency
+	}
+
+	return m.Currency
+}
+
+// Add returns the sum of two amounts
+func (m Money) Add(other Money) Money {
+	return Money{Cents: m.Cents + other.Cents, Currency: m.currency()}
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Cents == 0
+}
+
+// Amount formats the number German style without a currency sign, e.g. "1.234,50".
+// This is also the format used to prefill price inputs.
+func (m Money) Amount() string {
+	return formatAmount(m.Cents, ",", ".")
+}
+
+// String formats the amount German style, e.g. "12,99 €"
+func (m Money) String() string {
+	return m.Format("de")
+}
+
+// Format formats the amount for a locale: "12,99 €" for German and Italian, "€12.99" for English
+func (m Money) Format(locale string) string {
+	symbol := m.currency()
+	if symbol == "EUR" {
+		symbol = "€"
+	}
+
+	if strings.HasPrefix(locale, "en") {
+		amount := formatAmount(m.Cents, ".", ",")
+		if strings.HasPrefix(amount, "-") {
+			return "-" + symbol + amount[1:]
+		}
+
+		return symbol + amount
+	}
+
+	return formatAmount(m.Cents, ",", ".") + " " + symbol
+}
+
+// formatAmount formats cents with the given decimal and thousands separators
+func formatAmount(cents int64, decimal, thousands string) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	units := strconv.FormatInt(cents/100, 10)
+
+	// Group the integer part in threes
+	var grouped strings.Builder
+
+	for i, r := range units {
+		if i > 0 && (len(units)-i)%3 == 0 {
+			grouped.WriteString(thousands)
+		}
+
+		grouped.WriteRune(r)
+	}
+
+	return fmt.Sprintf("%s%s%s%02d", sign, grouped.String(), decimal, cents%100)
+}
+
+// Scan implements sql.Scanner. Columns hold cents; floats from SQL arithmetic are rounded to whole cents.
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case int64:
+		*m = EUR(v)
+	case float64:
+		*m = EUR(int64(math.Round(v)))
+	case []byte:
+		return m.Scan(string(v))
+	case string:
+		cents, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("scanning money %q: %w", v, err)
+		}
+
+		*m = EUR(cents)
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+
+	return nil
+}
+
+// Value implements driver.Valuer, storing the amount as integer cents
+func (m Money) Value() (driver.Value, error) {
+	return m.Cents, nil
+}
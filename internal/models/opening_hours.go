@@ -0,0 +1,248 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	_ "time/tzdata" // The Docker image has no time zone database
+)
+
+// Location is the time zone of the restaurant. Opening hours, price schedules and other dates
+// are wall clock dates and times in it, whatever the zone of the server.
+var Location = mustLoadLocation("Europe/Berlin")
+
+// mustLoadLocation loads a time zone from the embedded database
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(fmt.Sprintf("models: loading time zone %s: %v", name, err))
+	}
+
+	return loc
+}
+
+// Today returns the current date in the restaurant, formatted like DATE columns
+func Today() string {
+	return time.Now().In(Location).Format(time.DateOnly)
+}
+
+// OpeningSlot is a weekly recurring time span in which the restaurant is open, e.g. Sunday 11:30-14:00.
+// A slot closing at or before its opening time runs past midnight into the next day.
+type OpeningSlot struct {
+	ID      int
+	Weekday time.Weekday
+	Opens   string // HH:MM
+	Closes  string // HH:MM
+}
+
+// OpeningException is a date range in which the weekly hours do not apply: a holiday or vacation
+// when the restaurant is closed, or special hours such as a shorter New Year's Eve.
+type OpeningException struct {
+	ID        int
+	StartDate time.Time
+	EndDate   time.Time // inclusive
+	Closed    bool
+	Opens     string // HH:MM, only with special hours
+	Closes    string // HH:MM, only with special hours
+	Note      string
+	CreatedAt time.Time
+}
+
+// Covers reports whether the exception applies on the calendar day of t
+func (e OpeningException) Covers(t time.Time) bool {
+	day := t.Format("2006-01-02")
+
+	return day >= e.StartDate.Format("2006-01-02") && day <= e.EndDate.Format("2006-01-02")
+}
+
+// IsSingleDay reports whether the exception starts and ends on the same day
+func (e OpeningException) IsSingleDay() bool {
+	return e.StartDate.Format("2006-01-02") == e.EndDate.Format("2006-01-02")
+}
+
+// ScheduleRow is a line of the printed opening hours: consecutive weekdays sharing the same
+// slots, e.g. Tuesday to Saturday 17:00-22:00. A row without slots is a rest day.
+type ScheduleRow struct {
+	From  time.Weekday
+	To    time.Weekday
+	Slots []OpeningSlot
+}
+
+// weekFromMonday lists the weekdays in the order guests read them
+var weekFromMonday = [...]time.Weekday{
+	time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday,
+}
+
+// WeeklySchedule groups slots into the rows of the printed opening hours, starting on Monday
+func WeeklySchedule(slots []OpeningSlot) []ScheduleRow {
+	var rows []ScheduleRow
+
+	for _, day := range weekFromMonday {
+		var daySlots []OpeningSlot
+
+		for _, s := range slots {
+			if s.Weekday == day {
+				daySlots = append(daySlots, s)
+			}
+		}
+
+		slices.SortFunc(daySlots, func(a, b OpeningSlot) int {
+			return strings.Compare(a.Opens, b.Opens)
+		})
+
+		if n := len(rows); n > 0 && sameHours(rows[n-1].Slots, daySlots) {
+			rows[n-1].To = day
+			continue
+		}
+
+		rows = append(rows, ScheduleRow{From: day, To: day, Slots: daySlots})
+	}
+
+	return rows
+}
+
+// sameHours reports whether two days have identical slots
+func sameHours(a, b []OpeningSlot) bool {
+	return slices.EqualFunc(a, b, func(x, y OpeningSlot) bool {
+		return x.Opens == y.Opens && x.Closes == y.Closes
+	})
+}
+
+// GetOpeningHours retrieves all weekly opening slots ordered by weekday and time
+func (m *DBModel) GetOpeningHours() ([]OpeningSlot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT id, weekday, opens, closes FROM opening_hours ORDER BY weekday, opens, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var slots []OpeningSlot
+
+	for rows.Next() {
+		var s OpeningSlot
+
+		err := rows.Scan(&s.ID, &s.Weekday, &s.Opens, &s.Closes)
+		if err != nil {
+			return nil, err
+		}
+
+		slots = append(slots, s)
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return slots, nil
+}
+
+// InsertOpeningSlot adds a weekly opening slot
+func (m *DBModel) InsertOpeningSlot(s OpeningSlot) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var newID int
+
+	err := m.DB.QueryRowContext(ctx, `INSERT INTO opening_hours (weekday, opens, closes) VALUES (?, ?, ?) RETURNING id`,
+		int(s.Weekday), s.Opens, s.Closes).Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// DeleteOpeningSlot removes a weekly opening slot
+func (m *DBModel) DeleteOpeningSlot(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return execOne(ctx, m.DB, `DELETE FROM opening_hours WHERE id = ?`, id)
+}
+
+// GetOpeningExceptions retrieves all holidays, vacations and special hours, latest first
+func (m *DBModel) GetOpeningExceptions() ([]OpeningException, error) {
+	return m.queryOpeningExceptions(`SELECT id, start_date, end_date, closed, opens, closes, note, created_at
+		FROM opening_exceptions ORDER BY start_date DESC, id DESC`)
+}
+
+// GetUpcomingOpeningExceptions retrieves the exceptions overlapping the days from from to until, in date order
+func (m *DBModel) GetUpcomingOpeningExceptions(from, until time.Time) ([]OpeningException, error) {
+	return m.queryOpeningExceptions(`SELECT id, start_date, end_date, closed, opens, closes, note, created_at
+		FROM opening_exceptions
+		WHERE date(end_date) >= ? AND date(start_date) <= ?
+		ORDER BY start_date, id`, from.Format("2006-01-02"), until.Format("2006-01-02"))
+}
+
+// queryOpeningExceptions runs a query selecting the columns of opening_exceptions
+func (m *DBModel) queryOpeningExceptions(query string, args ...any) ([]OpeningException, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exceptions []OpeningException
+
+	for rows.Next() {
+		var e OpeningException
+
+		err := rows.Scan(&e.ID, &e.StartDate, &e.EndDate, &e.Closed, &e.Opens, &e.Closes, &e.Note, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		exceptions = append(exceptions, e)
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return exceptions, nil
+}
+
+// InsertOpeningException adds a holiday, vacation or day with special hours
+func (m *DBModel) InsertOpeningException(e OpeningException) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `INSERT INTO opening_exceptions (start_date, end_date, closed, opens, closes, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING id`
+
+	var newID int
+
+	err := m.DB.QueryRowContext(ctx, stmt,
+		e.StartDate.Format("2006-01-02"),
+		e.EndDate.Format("2006-01-02"),
+		e.Closed,
+		e.Opens,
+		e.Closes,
+		e.Note,
+		time.Now(),
+	).Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// DeleteOpeningException removes a holiday, vacation or day with special hours
+func (m *DBModel) DeleteOpeningException(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return execOne(ctx, m.DB, `DELETE FROM opening_exceptions WHERE id = ?`, id)
+}
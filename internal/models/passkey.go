@@ -0,0 +1,202 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+// ErrInvalidChallenge is returned for passkey challenges that are unknown, already used or expired
+var ErrInvalidChallenge = errors.New("unknown, used or expired passkey challenge")
+
+// ErrTooManyChallenges is returned when a client already has the maximum number of passkey challenges open
+var ErrTooManyChallenges = errors.New("too many open passkey challenges")
+
+// PasskeyChallengeLifetime is how long an admin has to complete a passkey registration or sign-in
+const PasskeyChallengeLifetime = 5 * time.Minute
+
+// MaxPasskeyChallengesPerClient limits the open challenges of one client address, as anyone can
+// start a sign-in
+const MaxPasskeyChallengesPerClient = 5
+
+// Passkey is a WebAuthn credential an admin can sign in with when their OpenID Connect provider
+// is unavailable
+type Passkey struct {
+	ID           int
+	Email        string
+	Name         string // Chosen by the admin, e.g. "iPhone"
+	CredentialID []byte
+	PublicKey    []byte // PKIX (DER) encoded
+	SignCount    uint32 // Signature counter reported by the authenticator; 0 if it keeps none
+	CreatedAt    time.Time
+	LastUsedAt   *time.Time
+}
+
+// passkeyColumns are the columns scanned by scanPasskey
+const passkeyColumns = `id, email, name, credential_id, public_key, sign_count, created_at, last_used_at`
+
+// scanPasskey scans a row selected with passkeyColumns
+func scanPasskey(row interface{ Scan(...any) error }) (Passkey, error) {
+	var p Passkey
+
+	var lastUsedAt sql.NullTime
+
+	err := row.Scan(&p.ID, &p.Email, &p.Name, &p.CredentialID, &p.PublicKey, &p.SignCount, &p.CreatedAt, &lastUsedAt)
+
+	if lastUsedAt.Valid {
+		p.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return p, err
+}
+
+// GetPasskeysByEmail retrieves the passkeys of an admin, oldest first
+func (m *DBModel) GetPasskeysByEmail(email string) ([]Passkey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT `+passkeyColumns+` FROM passkeys WHERE email = ? ORDER BY id`, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var passkeys []Passkey
+
+	for rows.Next() {
+		passkey, err := scanPasskey(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		passkeys = append(passkeys, passkey)
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return passkeys, nil
+}
+
+// GetPasskeyByCredentialID retrieves the passkey with the given credential ID, or sql.ErrNoRows
+func (m *DBModel) GetPasskeyByCredentialID(credentialID []byte) (Passkey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	row := m.DB.QueryRowContext(ctx, `SELECT `+passkeyColumns+` FROM passkeys WHERE credential_id = ?`, credentialID)
+
+	return scanPasskey(row)
+}
+
+// InsertPasskey stores a registered passkey and returns its ID
+func (m *DBModel) InsertPasskey(passkey Passkey) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var id int
+
+	err := m.DB.QueryRowContext(ctx, `INSERT INTO passkeys (email, name, credential_id, public_key, sign_count, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id`,
+		passkey.Email, passkey.Name, passkey.CredentialID, passkey.PublicKey, passkey.SignCount, time.Now()).Scan(&id)
+
+	return id, err
+}
+
+// RecordPasskeyUse stores the signature counter of a sign-in with a passkey
+func (m *DBModel) RecordPasskeyUse(id int, signCount uint32) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return execOne(ctx, m.DB, `UPDATE passkeys SET sign_count = ?, last_used_at = ? WHERE id = ?`, signCount, time.Now(), id)
+}
+
+// DeletePasskey removes a passkey of the given admin
+func (m *DBModel) DeletePasskey(id int, email string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return execOne(ctx, m.DB, `DELETE FROM passkeys WHERE id = ? AND email = ?`, id, email)
+}
+
+// CreatePasskeyChallenge returns a random challenge for a passkey registration by the given admin,
+// or for a sign-in when email is empty. Expired challenges are deleted along the way, and
+// ErrTooManyChallenges is returned when the client already has MaxPasskeyChallengesPerClient open.
+func (m *DBModel) CreatePasskeyChallenge(email, clientIP string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	now := time.Now()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM passkey_challenges WHERE julianday(expires_at) < julianday(?)`, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var open int
+
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM passkey_challenges WHERE client_ip = ?`, clientIP).Scan(&open)
+	if err != nil {
+		return nil, err
+	}
+
+	if open >= MaxPasskeyChallengesPerClient {
+		return nil, ErrTooManyChallenges
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO passkey_challenges (challenge_hash, email, client_ip, expires_at) VALUES (?, ?, ?, ?)`,
+		hashToken(base64.RawURLEncoding.EncodeToString(challenge)), email, clientIP, now.Add(PasskeyChallengeLifetime))
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// ConsumePasskeyChallenge uses up a challenge and returns the email it was created for.
+// ErrInvalidChallenge is returned if it is unknown, was used before or has expired.
+func (m *DBModel) ConsumePasskeyChallenge(challenge []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var email string
+
+	var expiresAt time.Time
+
+	err := m.DB.QueryRowContext(ctx, `DELETE FROM passkey_challenges WHERE challenge_hash = ? RETURNING email, expires_at`,
+		hashToken(base64.RawURLEncoding.EncodeToString(challenge))).Scan(&email, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrInvalidChallenge
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if time.Now().After(expiresAt) {
+		return "", ErrInvalidChallenge
+	}
+
+	return email, nil
+}
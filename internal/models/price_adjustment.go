@@ -0,0 +1,221 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// ErrNoPriceChanges is returned when an adjustment would not change any price
+var ErrNoPriceChanges = errors.New("no prices to change")
+
+// PriceRounding is the rule applied to adjusted prices so they end in a familiar way
+type PriceRounding string
+
+const (
+	RoundNone PriceRounding = ""    // Round to the cent only
+	Round50   PriceRounding = ".50" // Nearest half euro, e.g. 8,50 or 9,00
+	Round90   PriceRounding = ".90" // Nearest price ending in ,90
+	Round99   PriceRounding = ".99" // Nearest price ending in ,99
+)
+
+// IsValid reports whether the rounding is one of the known rules
+func (r PriceRounding) IsValid() bool {
+	return r == RoundNone || r == Round50 || r == Round90 || r == Round99
+}
+
+// Round applies the rule to an amount in cents. Halfway amounts round up and results never go below zero.
+func (r PriceRounding) Round(cents int64) int64 {
+	var step, offset int64
+
+	switch r {
+	case Round50:
+		step, offset = 50, 0
+	case Round90:
+		step, offset = 100, 90
+	case Round99:
+		step, offset = 100, 99
+	default:
+		return max(cents, 0)
+	}
+
+	// Nearest value of the form n*step + offset
+	base := cents - offset + step/2
+	n := base / step
+
+	if base < 0 && base%step != 0 {
+		n-- // Floor division for negative amounts
+	}
+
+	rounded := n*step + offset
+	if rounded < 0 {
+		rounded += step
+	}
+
+	return rounded
+}
+
+// PriceAdjustment describes a change applied to many prices at once, either by a percentage or a fixed amount
+type PriceAdjustment struct {
+	Percent  int   // In hundredths of a percent, e.g. 550 for +5,5 %; used when Amount is zero
+	Amount   Money // Fixed amount added to every price, may be negative
+	Rounding PriceRounding
+}
+
+// Apply returns the adjusted price
+func (a PriceAdjustment) Apply(price Money) Money {
+	cents := price.Cents
+
+	if a.Amount.Cents != 0 {
+		cents += a.Amount.Cents
+	} else {
+		// Round half away from zero to the cent before the rounding rule is applied
+		scaled := cents * int64(10000+a.Percent)
+		if scaled >= 0 {
+			cents = (scaled + 5000) / 10000
+		} else {
+			cents = (scaled - 5000) / 10000
+		}
+	}
+
+	return Money{Cents: a.Rounding.Round(cents), Currency: price.Currency}
+}
+
+// PriceSelection selects the menu items an adjustment applies to. Exactly one of the fields is expected to be set.
+type PriceSelection struct {
+	CategoryID int
+	TagID      int
+	ItemIDs    []int
+}
+
+// Matches reports whether the item is part of the selection
+func (s PriceSelection) Matches(item MenuItem) bool {
+	switch {
+	case s.CategoryID != 0:
+		return item.CategoryID == s.CategoryID
+	case s.TagID != 0:
+		return slices.ContainsFunc(item.Tags, func(t Tag) bool { return t.ID == s.TagID })
+	default:
+		return slices.Contains(s.ItemIDs, item.ID)
+	}
+}
+
+// PriceChange is the old and new price of one variant in an adjustment
+type PriceChange struct {
+	MenuItemID   int
+	MenuItemName string
+	Category     string
+	VariantID    int
+	VariantLabel string
+	Old          Money
+	New          Money
+}
+
+// planPriceAdjustment computes the adjusted prices of the selected items. Variants whose
+// price stays the same are left out; the changed items are returned with their new prices.
+func planPriceAdjustment(items []MenuItem, selection PriceSelection, adjustment PriceAdjustment) ([]PriceChange, []MenuItem) {
+	var changes []PriceChange
+
+	var changed []MenuItem
+
+	for _, item := range items {
+		if !selection.Matches(item) {
+			continue
+		}
+
+		item.Variants = slices.Clone(item.Variants)
+		touched := false
+
+		for i, v := range item.Variants {
+			price := adjustment.Apply(v.Price)
+			if price == v.Price {
+				continue
+			}
+
+			changes = append(changes, PriceChange{
+				MenuItemID:   item.ID,
+				MenuItemName: item.Name,
+				Category:     item.Category,
+				VariantID:    v.ID,
+				VariantLabel: v.Label,
+				Old:          v.Price,
+				New:          price,
+			})
+
+			item.Variants[i].Price = price
+			touched = true
+		}
+
+		if touched {
+			changed = append(changed, item)
+		}
+	}
+
+	return changes, changed
+}
+
+// PreviewPriceAdjustment lists the price changes an adjustment would make, without saving them
+func (m *DBModel) PreviewPriceAdjustment(selection PriceSelection, adjustment PriceAdjustment) ([]PriceChange, error) {
+	items, err := m.GetAllMenuItems()
+	if err != nil {
+		return nil, err
+	}
+
+	changes, _ := planPriceAdjustment(items, selection, adjustment)
+
+	return changes, nil
+}
+
+// ApplyPriceAdjustment changes the prices of all selected items in one transaction and records
+// a revision for each changed item. Prices scheduled for later dates are left as they are.
+func (m *DBModel) ApplyPriceAdjustment(selection PriceSelection, adjustment PriceAdjustment, changedBy string) ([]PriceChange, error) {
+	items, err := m.GetAllMenuItems()
+	if err != nil {
+		return nil, err
+	}
+
+	changes, changed := planPriceAdjustment(items, selection, adjustment)
+	if len(changes) == 0 {
+		return nil, ErrNoPriceChanges
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	now := time.Now()
+	today := Today()
+
+	for _, c := range changes {
+		_, err = tx.ExecContext(ctx, `UPDATE menu_item_variants SET price = ? WHERE id = ?`, c.New, c.VariantID)
+		if err != nil {
+			return nil, err
+		}
+
+		// The new price replaces any scheduled price that already took effect, like an edit does
+		_, err = tx.ExecContext(ctx, `DELETE FROM price_schedules WHERE variant_id = ? AND effective_from <= ?`, c.VariantID, today)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, item := range changed {
+		_, err = tx.ExecContext(ctx, `UPDATE menu_items SET updated_at = ? WHERE id = ?`, now, item.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = writeRevision(ctx, tx, item, RevisionUpdate, changedBy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return changes, tx.Commit()
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrScheduleInPast is returned when scheduling a price for today or an earlier day
+var ErrScheduleInPast = errors.New("scheduled prices must start after today")
+
+// PriceSchedule is a future price of a variant that takes effect at the start of a day
+type PriceSchedule struct {
+	ID            int
+	VariantID     int
+	Price         Money
+	EffectiveFrom time.Time // Date only
+	CreatedAt     time.Time
+
+	// Joined for display
+	MenuItemID   int
+	MenuItemName string
+	VariantLabel string
+	CurrentPrice Money // The variant's price today
+}
+
+// GetUpcomingPriceSchedules retrieves the scheduled prices that have not taken effect yet,
+// ordered by date and menu order
+func (m *DBModel) GetUpcomingPriceSchedules() ([]PriceSchedule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	today := Today()
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT s.id, s.variant_id, s.price, s.effective_from, s.created_at,
+		mi.id, mi.name, v.label,
+		COALESCE((SELECT p.price FROM price_schedules p
+		          WHERE p.variant_id = v.id AND p.effective_from <= ?
+		          ORDER BY p.effective_from DESC LIMIT 1), v.price)
+		FROM price_schedules s
+		JOIN menu_item_variants v ON v.id = s.variant_id
+		JOIN menu_items mi ON mi.id = v.menu_item_id
+		LEFT JOIN categories c ON c.id = mi.category_id
+		WHERE s.effective_from > ? AND mi.deleted_at IS NULL
+		ORDER BY s.effective_from, c.position, mi.position, v.position`, today, today)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []PriceSchedule
+
+	for rows.Next() {
+		var s PriceSchedule
+
+		err := rows.Scan(&s.ID, &s.VariantID, &s.Price, &s.EffectiveFrom, &s.CreatedAt,
+			&s.MenuItemID, &s.MenuItemName, &s.VariantLabel, &s.CurrentPrice)
+		if err != nil {
+			return nil, err
+		}
+
+		schedules = append(schedules, s)
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// SchedulePrices stores a batch of future prices in one transaction.
+// A variant that already has a price scheduled for the same day gets the new price instead.
+func (m *DBModel) SchedulePrices(schedules []PriceSchedule) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	today := Today()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	for _, s := range schedules {
+		day := s.EffectiveFrom.Format(time.DateOnly)
+		if day <= today {
+			return ErrScheduleInPast
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO price_schedules (variant_id, price, effective_from, created_at)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT (variant_id, effective_from) DO UPDATE SET price = excluded.price, created_at = excluded.created_at`,
+			s.VariantID, s.Price, day, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeletePriceSchedule deletes a scheduled price
+func (m *DBModel) DeletePriceSchedule(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `DELETE FROM price_schedules WHERE id = ?`, id)
+
+	return err
+}
+
+// deleteOrphanedSchedules removes scheduled prices of variants that no longer exist
+func deleteOrphanedSchedules(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM price_schedules WHERE variant_id NOT IN (SELECT id FROM menu_item_variants)`)
+
+	return err
+}
@@ -0,0 +1,218 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RevisionAction describes what happened to a menu item in a revision
+type RevisionAction string
+
+const (
+	RevisionCreate  RevisionAction = "create"
+	RevisionUpdate  RevisionAction = "update"
+	RevisionDelete  RevisionAction = "delete"  // Moved to the trash
+	RevisionRestore RevisionAction = "restore" // Taken out of the trash
+	RevisionRevert  RevisionAction = "revert"  // Rolled back to an earlier revision
+)
+
+// Revision is a recorded change of a menu item together with the item as it was after the change
+type Revision struct {
+	ID         int
+	MenuItemID int
+	Action     RevisionAction
+	Item       MenuItem
+	ChangedBy  string // Email of the admin who made the change
+	CreatedAt  time.Time
+	Changes    []FieldChange // Differences to the previous revision, filled by GetMenuItemRevisions
+	IsFirst    bool          // Set on the oldest revision of an item that existed before the history was kept
+}
+
+// FieldChange is one field that differs between two revisions, formatted for display
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// revisionFields are the fields compared between revisions, each with its display format
+var revisionFields = []struct {
+	name   string
+	format func(MenuItem) string
+}{
+	{"Name", func(item MenuItem) string { return item.Name }},
+	{"Description", func(item MenuItem) string { return item.Description }},
+	{"Category", func(item MenuItem) string { return item.Category }},
+	{"Prices", formatVariants},
+	{"Image", func(item MenuItem) string { return item.ImageURL }},
+	{"Allergens & additives", MenuItem.DeclarationCodes},
+	{"Tags", func(item MenuItem) string {
+		names := make([]string, 0, len(item.Tags))
+		for _, t := range item.Tags {
+			names = append(names, t.Name)
+		}
+
+		return strings.Join(names, ", ")
+	}},
+	{"Extras", func(item MenuItem) string {
+		names := make([]string, 0, len(item.Extras))
+		for _, e := range item.ItemExtras() {
+			names = append(names, e.Name)
+		}
+
+		return strings.Join(names, ", ")
+	}},
+	{"Availability", formatAvailability},
+	{"Serving times", MenuItem.WindowsLabel},
+}
+
+// formatVariants lists the prices of an item, e.g. "klein 6,00 €, normal 8,00 € (default)"
+func formatVariants(item MenuItem) string {
+	if item.HasSingleUnlabelledPrice() {
+		return item.Variants[0].Price.String()
+	}
+
+	parts := make([]string, 0, len(item.Variants))
+
+	for _, v := range item.Variants {
+		part := v.Label + " " + v.Price.String()
+		if v.IsDefault {
+			part += " (default)"
+		}
+
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// formatAvailability describes the availability set by the staff, e.g. "sold out until 2025-06-08"
+func formatAvailability(item MenuItem) string {
+	switch item.availability() {
+	case AvailabilitySoldOut:
+		if item.SoldOutUntil != nil {
+			return "sold out until " + item.SoldOutUntil.Format(time.DateOnly)
+		}
+
+		return "sold out"
+	case AvailabilityHidden:
+		return "hidden"
+	default:
+		return "available"
+	}
+}
+
+// DiffMenuItems lists the fields that differ between two states of a menu item
+func DiffMenuItems(before, after MenuItem) []FieldChange {
+	var changes []FieldChange
+
+	for _, f := range revisionFields {
+		old, current := f.format(before), f.format(after)
+		if old != current {
+			changes = append(changes, FieldChange{Field: f.name, Old: old, New: current})
+		}
+	}
+
+	return changes
+}
+
+// writeRevision records a change of a menu item inside the transaction that made it
+func writeRevision(ctx context.Context, tx *sql.Tx, item MenuItem, action RevisionAction, changedBy string) error {
+	// The category name is kept in the snapshot so the history still reads well after a rename
+	err := tx.QueryRowContext(ctx, `SELECT name_it FROM categories WHERE id = ?`, item.CategoryID).Scan(&item.Category)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// Extras inherited from the category are not part of the item itself
+	item.Extras = item.ItemExtras()
+
+	snapshot, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO menu_item_revisions (menu_item_id, action, snapshot, changed_by, created_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		item.ID, action, snapshot, changedBy, time.Now())
+
+	return err
+}
+
+// scanRevision scans a revision row and decodes its snapshot
+func scanRevision(row interface{ Scan(...any) error }) (Revision, error) {
+	var r Revision
+
+	var snapshot []byte
+
+	err := row.Scan(&r.ID, &r.MenuItemID, &r.Action, &snapshot, &r.ChangedBy, &r.CreatedAt)
+	if err != nil {
+		return r, err
+	}
+
+	err = json.Unmarshal(snapshot, &r.Item)
+
+	return r, err
+}
+
+// GetMenuItemRevisions retrieves the revisions of a menu item, newest first, each with its changes
+// compared to the revision before it
+func (m *DBModel) GetMenuItemRevisions(menuItemID int) ([]Revision, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT id, menu_item_id, action, snapshot, changed_by, created_at
+		FROM menu_item_revisions WHERE menu_item_id = ? ORDER BY id`, menuItemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []Revision
+
+	for rows.Next() {
+		r, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		// A create revision lists everything that was set. Items older than the history start
+		// with whatever revision came first, which has nothing to compare with.
+		switch {
+		case len(revisions) > 0:
+			r.Changes = DiffMenuItems(revisions[len(revisions)-1].Item, r.Item)
+		case r.Action == RevisionCreate:
+			r.Changes = DiffMenuItems(MenuItem{Availability: AvailabilityAvailable}, r.Item)
+		default:
+			r.IsFirst = true
+		}
+
+		revisions = append(revisions, r)
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Newest first
+	slices.Reverse(revisions)
+
+	return revisions, nil
+}
+
+// GetRevisionByID retrieves a single revision
+func (m *DBModel) GetRevisionByID(id int) (Revision, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	row := m.DB.QueryRowContext(ctx, `SELECT id, menu_item_id, action, snapshot, changed_by, created_at
+		FROM menu_item_revisions WHERE id = ?`, id)
+
+	return scanRevision(row)
+}
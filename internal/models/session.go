@@ -0,0 +1,199 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+// ErrInvalidSession is returned when a session is unknown, has expired or has been revoked
+var ErrInvalidSession = errors.New("invalid, expired or revoked session")
+
+const (
+	// SessionIdleTimeout ends a session that has not been used for this long
+	SessionIdleTimeout = 24 * time.Hour
+	// SessionLifetime is the longest a session can last, however often it is used
+	SessionLifetime = 7 * 24 * time.Hour
+)
+
+// Session is a signed-in admin. The session cookie only carries a random token; the database
+// keeps a hash of it, so sessions can be listed and revoked.
+type Session struct {
+	ID         int
+	Email      string
+	IP         string
+	UserAgent  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time // Moves forward with every request, up to SessionLifetime after CreatedAt
+	RevokedAt  *time.Time
+}
+
+// IsActiveAt reports whether the session can still be used at the given time
+func (s Session) IsActiveAt(t time.Time) bool {
+	return s.RevokedAt == nil && t.Before(s.ExpiresAt)
+}
+
+// sessionExpiry returns when a session created at created and last used at seen expires
+func sessionExpiry(created, seen time.Time) time.Time {
+	expires := seen.Add(SessionIdleTimeout)
+	if limit := created.Add(SessionLifetime); expires.After(limit) {
+		return limit
+	}
+
+	return expires
+}
+
+// sessionColumns are the columns scanned by scanSession
+const sessionColumns = `id, email, ip, user_agent, created_at, last_seen_at, expires_at, revoked_at`
+
+// scanSession scans a row selected with sessionColumns
+func scanSession(row interface{ Scan(...any) error }) (Session, error) {
+	var s Session
+
+	var revokedAt sql.NullTime
+
+	err := row.Scan(&s.ID, &s.Email, &s.IP, &s.UserAgent, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &revokedAt)
+
+	if revokedAt.Valid {
+		s.RevokedAt = &revokedAt.Time
+	}
+
+	return s, err
+}
+
+// CreateSession starts a session for the given admin and returns it together with the token
+// for the session cookie
+func (m *DBModel) CreateSession(email, ip, userAgent string) (Session, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return Session{}, "", err
+	}
+
+	secret := base64.RawURLEncoding.EncodeToString(random)
+	now := time.Now()
+
+	row := m.DB.QueryRowContext(ctx, `INSERT INTO sessions (token_hash, email, ip, user_agent, created_at, last_seen_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING `+sessionColumns,
+		hashToken(secret), email, ip, userAgent, now, now, sessionExpiry(now, now))
+
+	session, err := scanSession(row)
+	if err != nil {
+		return Session{}, "", err
+	}
+
+	return session, secret, nil
+}
+
+// AuthenticateSession looks up the active session with the given token and records that it was
+// used, which extends it by SessionIdleTimeout
+func (m *DBModel) AuthenticateSession(secret string) (Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	row := m.DB.QueryRowContext(ctx, `SELECT `+sessionColumns+` FROM sessions
+		WHERE token_hash = ? AND revoked_at IS NULL`, hashToken(secret))
+
+	session, err := scanSession(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Session{}, ErrInvalidSession
+	}
+
+	if err != nil {
+		return Session{}, err
+	}
+
+	now := time.Now()
+	if !session.IsActiveAt(now) {
+		return Session{}, ErrInvalidSession
+	}
+
+	session.LastSeenAt = now
+	session.ExpiresAt = sessionExpiry(session.CreatedAt, now)
+
+	// The session may have been revoked in the meantime
+	err = execOne(ctx, m.DB, `UPDATE sessions SET last_seen_at = ?, expires_at = ? WHERE id = ? AND revoked_at IS NULL`,
+		session.LastSeenAt, session.ExpiresAt, session.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Session{}, ErrInvalidSession
+	}
+
+	return session, err
+}
+
+// GetActiveSessions retrieves the sessions that have neither expired nor been revoked, most
+// recently used first
+func (m *DBModel) GetActiveSessions() ([]Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT `+sessionColumns+` FROM sessions
+		WHERE revoked_at IS NULL
+		ORDER BY last_seen_at DESC, id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+
+	now := time.Now()
+
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		if session.IsActiveAt(now) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession ends a session; its cookie is no longer accepted
+func (m *DBModel) RevokeSession(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return execOne(ctx, m.DB, `UPDATE sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, time.Now(), id)
+}
+
+// RevokeSessionsByEmail ends all sessions of an admin and returns how many were still open
+func (m *DBModel) RevokeSessionsByEmail(email string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, `UPDATE sessions SET revoked_at = ? WHERE email = ? AND revoked_at IS NULL`,
+		time.Now(), email)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// DeleteEndedSessions removes sessions that expired or were revoked before the given time
+func (m *DBModel) DeleteEndedSessions(before time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `DELETE FROM sessions
+		WHERE julianday(expires_at) < julianday(?) OR julianday(revoked_at) < julianday(?)`, before, before)
+
+	return err
+}
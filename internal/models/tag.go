@@ -0,0 +1,211 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Tag is a dietary label such as "vegan" or "spicy" that guests can filter the menu by
+type Tag struct {
+	ID       int
+	Slug     string
+	Name     string
+	Position int
+}
+
+// MenuFilter selects the menu items shown on the public menu
+type MenuFilter struct {
+	Category string   // Category slug; empty means all categories
+	Require  []string // Tag slugs an item must all have
+	Exclude  []string // Tag slugs an item must not have
+}
+
+// IsEmpty reports whether the filter lets every item through
+func (f MenuFilter) IsEmpty() bool {
+	return f.Category == "" && len(f.Require) == 0 && len(f.Exclude) == 0
+}
+
+// Matches reports whether a menu item passes the filter
+func (f MenuFilter) Matches(item MenuItem) bool {
+	if f.Category != "" && item.CategorySlug != f.Category {
+		return false
+	}
+
+	for _, slug := range f.Require {
+		if !item.HasTagSlug(slug) {
+			return false
+		}
+	}
+
+	for _, slug := range f.Exclude {
+		if item.HasTagSlug(slug) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// HasTag reports whether the item carries the tag with the given ID
+func (item MenuItem) HasTag(id int) bool {
+	for _, t := range item.Tags {
+		if t.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasTagSlug reports whether the item carries the tag with the given slug
+func (item MenuItem) HasTagSlug(slug string) bool {
+	for _, t := range item.Tags {
+		if t.Slug == slug {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetAllTags retrieves all tags ordered by position
+func (m *DBModel) GetAllTags() ([]Tag, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT id, slug, name, position FROM tags ORDER BY position, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []Tag
+
+	for rows.Next() {
+		var t Tag
+
+		err := rows.Scan(&t.ID, &t.Slug, &t.Name, &t.Position)
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, t)
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// InsertTag inserts a new tag at the end of the tag list
+func (m *DBModel) InsertTag(t Tag) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `INSERT INTO tags (slug, name, position)
+             VALUES (?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM tags))
+             RETURNING id`
+
+	var newID int
+
+	err := m.DB.QueryRowContext(ctx, stmt, t.Slug, t.Name).Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// UpdateTag updates the slug and name of a tag
+func (m *DBModel) UpdateTag(t Tag) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `UPDATE tags SET slug = ?, name = ? WHERE id = ?`, t.Slug, t.Name, t.ID)
+
+	return err
+}
+
+// DeleteTag deletes a tag and removes it from all menu items
+func (m *DBModel) DeleteTag(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after a successful commit
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM menu_item_tags WHERE tag_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM tags WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// loadTags attaches tags to the given items
+func (m *DBModel) loadTags(ctx context.Context, items []MenuItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*MenuItem, len(items))
+	for i := range items {
+		byID[items[i].ID] = &items[i]
+	}
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT l.menu_item_id, t.id, t.slug, t.name, t.position
+		FROM menu_item_tags l
+		JOIN tags t ON t.id = l.tag_id
+		ORDER BY t.position, t.id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemID int
+
+		var t Tag
+
+		err := rows.Scan(&itemID, &t.ID, &t.Slug, &t.Name, &t.Position)
+		if err != nil {
+			return err
+		}
+
+		if item, ok := byID[itemID]; ok {
+			item.Tags = append(item.Tags, t)
+		}
+	}
+
+	// Check for errors encountered during iteration
+	return rows.Err()
+}
+
+// saveTags replaces the tag links of a menu item inside a transaction
+func saveTags(ctx context.Context, tx *sql.Tx, item MenuItem) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM menu_item_tags WHERE menu_item_id = ?`, item.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range item.Tags {
+		_, err = tx.ExecContext(ctx, `INSERT OR IGNORE INTO menu_item_tags (menu_item_id, tag_id) VALUES (?, ?)`, item.ID, t.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Kinds of records that can be translated
+const (
+	TranslationMenuItem     = "menu_item"
+	TranslationCategory     = "category"
+	TranslationFlashMessage = "flash_message"
+)
+
+// TranslatableFields lists the fields that can be translated for each kind of record. The
+// name of a category is the name shown next to the Italian one, like NameDE in German.
+var TranslatableFields = map[string][]string{
+	TranslationMenuItem:     {"name", "description"},
+	TranslationCategory:     {"name", "description"},
+	TranslationFlashMessage: {"message"},
+}
+
+// Translation holds the translated fields of one record in one language, e.g. {"description": "Spicy salami"}
+type Translation map[string]string
+
+// Get returns the translation of a field, or fallback when the field has not been translated
+func (t Translation) Get(field, fallback string) string {
+	if value := t[field]; value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+// Localize returns the menu item with its name and description in the language of t
+func (item MenuItem) Localize(t Translation) MenuItem {
+	item.Name = t.Get("name", item.Name)
+	item.Description = t.Get("description", item.Description)
+
+	return item
+}
+
+// Localize returns the category with its second name and description in the language of t
+func (c Category) Localize(t Translation) Category {
+	c.NameDE = t.Get("name", c.NameDE)
+	c.Description = t.Get("description", c.Description)
+
+	return c
+}
+
+// Localize returns the flash message with its text in the language of t
+func (f FlashMessage) Localize(t Translation) FlashMessage {
+	f.Message = t.Get("message", f.Message)
+	return f
+}
+
+// GetTranslations retrieves the translations of all records of one kind into one language, keyed by record ID
+func (m *DBModel) GetTranslations(entityType, lang string) (map[int]Translation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT entity_id, field, value FROM translations
+		WHERE entity_type = ? AND lang = ?`, entityType, lang)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	translations := make(map[int]Translation)
+
+	for rows.Next() {
+		var (
+			id           int
+			field, value string
+		)
+
+		err := rows.Scan(&id, &field, &value)
+		if err != nil {
+			return nil, err
+		}
+
+		if translations[id] == nil {
+			translations[id] = make(Translation)
+		}
+
+		translations[id][field] = value
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return translations, nil
+}
+
+// GetRecordTranslations retrieves the translations of one record into all languages, keyed by language
+func (m *DBModel) GetRecordTranslations(entityType string, id int) (map[string]Translation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, `SELECT lang, field, value FROM translations
+		WHERE entity_type = ? AND entity_id = ?`, entityType, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	translations := make(map[string]Translation)
+
+	for rows.Next() {
+		var lang, field, value string
+
+		err := rows.Scan(&lang, &field, &value)
+		if err != nil {
+			return nil, err
+		}
+
+		if translations[lang] == nil {
+			translations[lang] = make(Translation)
+		}
+
+		translations[lang][field] = value
+	}
+
+	// Check for errors encountered during iteration
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return translations, nil
+}
+
+// saveTranslations sets the given translated fields of one record inside the transaction that saves
+// the record. Fields set to an empty string are removed, so the record falls back to the default
+// language for them; others are left alone.
+func saveTranslations(ctx context.Context, db execer, entityType string, id int, translations map[string]Translation) error {
+	now := time.Now()
+
+	for lang, fields := range translations {
+		for field, value := range fields {
+			if !slices.Contains(TranslatableFields[entityType], field) {
+				return fmt.Errorf("field %q of %s cannot be translated", field, entityType)
+			}
+
+			value = strings.TrimSpace(value)
+
+			var err error
+
+			if value == "" {
+				_, err = db.ExecContext(ctx, `DELETE FROM translations
+					WHERE entity_type = ? AND entity_id = ? AND field = ? AND lang = ?`,
+					entityType, id, field, lang)
+			} else {
+				_, err = db.ExecContext(ctx, `INSERT INTO translations (entity_type, entity_id, field, lang, value, updated_at)
+					VALUES (?, ?, ?, ?, ?, ?)
+					ON CONFLICT (entity_type, entity_id, field, lang) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+					entityType, id, field, lang, value, now)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// deleteTranslations removes all translations of a record
+func deleteTranslations(ctx context.Context, db execer, entityType string, id int) error {
+	_, err := db.ExecContext(ctx, `DELETE FROM translations WHERE entity_type = ? AND entity_id = ?`, entityType, id)
+	return err
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrNoVariants is returned when saving a menu item without any price
+var ErrNoVariants = errors.New("a menu item needs at least one price")
+
+// Variant is one orderable size or portion of a menu item, e.g. "klein" or "0,5l"
+type Variant struct {
+	ID         int
+	MenuItemID int
+	Label      string // Empty for dishes with a single price
+	Price      Money  // Price in effect when the variant was loaded, including scheduled changes
+	Position   int
+	IsDefault  bool
+}
+
+// DefaultVariant returns the variant marked as default, or the first one
+func (item MenuItem) DefaultVariant() Variant {
+	for _, v := range item.Variants {
+		if v.IsDefault {
+			return v
+		}
+	}
+
+	if len(item.Variants) > 0 {
+		return item.Variants[0]
+	}
+
+	return Variant{}
+}
+
+// HasSingleUnlabelledPrice reports whether the item has just one price that needs no label
+func (item MenuItem) HasSingleUnlabelledPrice() bool {
+	return len(item.Variants) == 1 && item.Variants[0].Label == ""
+}
+
+// loadVariants attaches the variants to the given items, in position order.
+// Each price is the latest scheduled price that took effect by the given time, or the variant's own price.
+func (m *DBModel) loadVariants(ctx context.Context, items []MenuItem, at time.Time) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*MenuItem, len(items))
+	args := make([]any, 0, len(items)+1)
+	args = append(args, at.In(Location).Format(time.DateOnly))
+
+	for i := range items {
+		byID[items[i].ID] = &items[i]
+		args = append(args, items[i].ID)
+	}
+
+	// Only the variants of the given items are loaded, each with one schedule lookup
+	rows, err := m.DB.QueryContext(ctx, `SELECT v.id, v.menu_item_id, v.label,
+		COALESCE((SELECT s.price FROM price_schedules s
+		          WHERE s.variant_id = v.id AND s.effective_from <= ?
+		          ORDER BY s.effective_from DESC LIMIT 1), v.price),
+		v.position, v.is_default
+		FROM menu_item_variants v
+		WHERE v.menu_item_id IN (?`+strings.Repeat(", ?", len(items)-1)+`)
+		ORDER BY v.menu_item_id, v.position, v.id`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v Variant
+
+		err := rows.Scan(&v.ID, &v.MenuItemID, &v.Label, &v.Price, &v.Position, &v.IsDefault)
+		if err != nil {
+			return err
+		}
+
+		if item, ok := byID[v.MenuItemID]; ok {
+			item.Variants = append(item.Variants, v)
+		}
+	}
+
+	// Check for errors encountered during iteration
+	return rows.Err()
+}
+
+// saveVariants stores the variants of a menu item inside a transaction.
+// Variants with a known ID are updated in place so their IDs stay stable, new ones are inserted
+// and variants missing from the list are deleted. Exactly one variant ends up as the default.
+// The saved price replaces any scheduled price that already took effect; future ones are kept.
+func saveVariants(ctx context.Context, tx *sql.Tx, item MenuItem) error {
+	if len(item.Variants) == 0 {
+		return ErrNoVariants
+	}
+
+	defaultIndex := 0
+
+	for i, v := range item.Variants {
+		if v.IsDefault {
+			defaultIndex = i
+			break
+		}
+	}
+
+	keep := make([]any, 0, len(item.Variants)+1)
+	keep = append(keep, item.ID)
+
+	for i, v := range item.Variants {
+		isDefault := i == defaultIndex
+
+		if v.ID != 0 {
+			result, err := tx.ExecContext(ctx, `UPDATE menu_item_variants SET label = ?, price = ?, position = ?, is_default = ?
+				WHERE id = ? AND menu_item_id = ?`,
+				v.Label, v.Price, i+1, isDefault, v.ID, item.ID)
+			if err != nil {
+				return err
+			}
+
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			// Only keep the ID if it really belongs to this item, otherwise insert a fresh row
+			if affected == 1 {
+				_, err = tx.ExecContext(ctx, `DELETE FROM price_schedules WHERE variant_id = ? AND effective_from <= ?`,
+					v.ID, Today())
+				if err != nil {
+					return err
+				}
+
+				keep = append(keep, v.ID)
+
+				continue
+			}
+		}
+
+		var newID int
+
+		err := tx.QueryRowContext(ctx, `INSERT INTO menu_item_variants (menu_item_id, label, price, position, is_default)
+			VALUES (?, ?, ?, ?, ?) RETURNING id`,
+			item.ID, v.Label, v.Price, i+1, isDefault).Scan(&newID)
+		if err != nil {
+			return err
+		}
+
+		keep = append(keep, newID)
+	}
+
+	stmt := `DELETE FROM menu_item_variants WHERE menu_item_id = ? AND id NOT IN (?` + strings.Repeat(", ?", len(keep)-2) + `)`
+
+	_, err := tx.ExecContext(ctx, stmt, keep...)
+	if err != nil {
+		return err
+	}
+
+	return deleteOrphanedSchedules(ctx, tx)
+}
+
+// deleteVariants removes all variants of a menu item inside a transaction
+func deleteVariants(ctx context.Context, tx *sql.Tx, menuItemID int) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM menu_item_variants WHERE menu_item_id = ?`, menuItemID)
+	if err != nil {
+		return err
+	}
+
+	return deleteOrphanedSchedules(ctx, tx)
+}
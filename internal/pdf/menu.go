@@ -0,0 +1,375 @@
+package pdf
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+// Format is the paper layout of the printed menu
+type Format string
+
+const (
+	// A4 prints one menu page per A4 portrait sheet
+	A4 Format = "a4"
+	// A5Folded prints A5 pages two to an A4 landscape sheet, ordered so that the sheets printed
+	// on both sides and folded in the middle make a booklet
+	A5Folded Format = "a5"
+)
+
+// IsValid reports whether f is a known format
+func (f Format) IsValid() bool {
+	return f == A4 || f == A5Folded
+}
+
+// Section is one category of the printed menu
+type Section struct {
+	Category models.Category
+	Items    []models.MenuItem
+	Extras   []models.Extra // Extras offered for the whole category
+}
+
+// Menu is the content of the printed menu
+type Menu struct {
+	Title     string
+	Date      time.Time // Date the prices are valid from, printed in the footer
+	Sections  []Section
+	Allergens []models.Allergen
+	Additives []models.Additive
+}
+
+// row is one line of the layout. draw gets the baseline; rows without draw are spacing.
+type row struct {
+	height float64
+	draw   func(p *Page, baseline float64)
+}
+
+// menuLayout flows rows onto pages of one size, starting a new page when a block does not fit
+type menuLayout struct {
+	width, height float64
+	margin        float64
+	scale         float64 // Font sizes are given for A4 and scaled for smaller pages
+	pages         []*Page
+	page          *Page
+	y             float64 // Top of the free space on the current page
+}
+
+// Font sizes and spacing for A4 pages, in points
+const (
+	titleSize   = 26
+	headingSize = 15
+	nameSize    = 11
+	textSize    = 9
+	codeSize    = 6.5
+	footerSize  = 7
+)
+
+// size returns an A4 font size scaled to the page
+func (l *menuLayout) size(a4 float64) float64 {
+	return a4 * l.scale
+}
+
+// textRow returns a row with one piece of text at x
+func (l *menuLayout) textRow(x float64, font Font, a4Size float64, s string) row {
+	size := l.size(a4Size)
+
+	return row{height: size * 1.35, draw: func(p *Page, baseline float64) {
+		p.Text(x, baseline, font, size, s)
+	}}
+}
+
+// space returns an empty row
+func (l *menuLayout) space(a4Height float64) row {
+	return row{height: l.size(a4Height)}
+}
+
+// wrapped returns the rows of a text wrapped to the given width
+func (l *menuLayout) wrapped(x, width float64, font Font, a4Size float64, s string) []row {
+	var rows []row
+	for _, line := range wrap(s, font, l.size(a4Size), width) {
+		rows = append(rows, l.textRow(x, font, a4Size, line))
+	}
+
+	return rows
+}
+
+// block places rows on the current page, or on a new page if they do not fit. keep is the height
+// of the following block that should stay on the same page, e.g. the first item after a heading.
+func (l *menuLayout) block(rows []row, keep float64) {
+	height := rowsHeight(rows) + keep
+	bottom := l.margin + l.size(footerSize)*3
+	top := l.height - l.margin
+
+	// A block taller than a page is placed anyway rather than starting empty pages forever
+	if l.page == nil || (l.y-height < bottom && l.y < top) {
+		l.page = &Page{}
+		l.pages = append(l.pages, l.page)
+		l.y = top
+	}
+
+	for _, r := range rows {
+		if r.draw != nil {
+			r.draw(l.page, l.y-r.height*0.75)
+		}
+
+		l.y -= r.height
+	}
+}
+
+// rowsHeight returns the total height of rows
+func rowsHeight(rows []row) float64 {
+	height := 0.0
+	for _, r := range rows {
+		height += r.height
+	}
+
+	return height
+}
+
+// Render lays out the menu and writes it as PDF in the given format
+func (m Menu) Render(w io.Writer, format Format) error {
+	l := &menuLayout{width: A4Width, height: A4Height, margin: 48, scale: 1}
+	if format == A5Folded {
+		l = &menuLayout{width: A4Height / 2, height: A4Width, margin: 30, scale: 0.8}
+	}
+
+	left := l.margin
+	right := l.width - l.margin
+
+	// Title on the first page
+	title := []row{l.space(10)}
+	titleWidth := TextWidth(Bold, l.size(titleSize), m.Title)
+	title = append(title, l.textRow((l.width-titleWidth)/2, Bold, titleSize, m.Title))
+
+	subtitle := "Speisekarte"
+	subtitleWidth := TextWidth(Italic, l.size(headingSize), subtitle)
+	title = append(title, l.textRow((l.width-subtitleWidth)/2, Italic, headingSize, subtitle), l.space(12))
+	l.block(title, 0)
+
+	for _, section := range m.Sections {
+		if len(section.Items) == 0 {
+			continue
+		}
+
+		heading := []row{l.space(14), l.textRow(left, Bold, headingSize, section.Category.Label())}
+		heading = append(heading, row{height: l.size(6), draw: func(p *Page, baseline float64) {
+			p.Line(left, baseline+l.size(3), right, baseline+l.size(3), 0.6)
+		}})
+
+		if section.Category.Description != "" {
+			heading = append(heading, l.wrapped(left, right-left, Italic, textSize, section.Category.Description)...)
+		}
+
+		if len(section.Extras) > 0 {
+			heading = append(heading, l.wrapped(left, right-left, Regular, textSize, "Extras: "+extrasLabel(section.Extras))...)
+		}
+
+		heading = append(heading, l.space(4))
+
+		items := make([][]row, 0, len(section.Items))
+		for _, item := range section.Items {
+			items = append(items, l.itemRows(item, left, right))
+		}
+
+		l.block(heading, rowsHeight(items[0]))
+
+		for _, rows := range items {
+			l.block(rows, 0)
+		}
+	}
+
+	if legend := l.legendRows(m.Allergens, m.Additives, left, right); len(legend) > 0 {
+		l.block(legend, 0)
+	}
+
+	footer := m.Title
+	if !m.Date.IsZero() {
+		footer += " · Stand " + m.Date.Format("02.01.2006")
+	}
+
+	for i, p := range l.pages {
+		size := l.size(footerSize)
+		number := strconv.Itoa(i + 1)
+
+		p.Gray(0.4)
+		p.Text(left, l.margin, Regular, size, footer)
+		p.Text(right-TextWidth(Regular, size, number), l.margin, Regular, size, number)
+	}
+
+	var doc *Document
+
+	if format == A5Folded {
+		doc = impose(l.pages)
+	} else {
+		doc = New(A4Width, A4Height)
+		for _, p := range l.pages {
+			doc.AddPage().Place(p, 0, 0)
+		}
+	}
+
+	doc.Title = m.Title
+	_, err := doc.WriteTo(w)
+
+	return err
+}
+
+// itemRows lays out one menu item: name, declaration codes and prices on the first line, then the
+// serving times and the description
+func (l *menuLayout) itemRows(item models.MenuItem, left, right float64) []row {
+	nameSz := l.size(nameSize)
+	codeSz := l.size(codeSize)
+	codes := item.DeclarationCodes()
+	prices := pricesLabel(item)
+	nameWidth := TextWidth(Bold, nameSz, item.Name)
+	priceWidth := TextWidth(Bold, nameSz, prices)
+
+	first := row{height: nameSz * 1.4, draw: func(p *Page, baseline float64) {
+		p.Text(left, baseline, Bold, nameSz, item.Name)
+
+		if codes != "" {
+			p.Text(left+nameWidth+2, baseline+nameSz*0.4, Regular, codeSz, codes)
+		}
+	}}
+
+	rows := []row{first}
+	priceRow := row{height: nameSz * 1.4, draw: func(p *Page, baseline float64) {
+		p.Text(right-priceWidth, baseline, Bold, nameSz, prices)
+	}}
+
+	// Prices of several sizes may not fit next to a long name; they then get their own line
+	if nameWidth+TextWidth(Regular, codeSz, codes)+priceWidth+l.size(16) > right-left {
+		rows = append(rows, priceRow)
+	} else {
+		draw := first.draw
+		rows[0].draw = func(p *Page, baseline float64) {
+			draw(p, baseline)
+			priceRow.draw(p, baseline)
+		}
+	}
+
+	if len(item.Windows) > 0 {
+		rows = append(rows, l.textRow(left, Italic, textSize, "Nur "+item.WindowsLabel()+" Uhr"))
+	}
+
+	if item.Description != "" {
+		rows = append(rows, l.wrapped(left, right-left-l.size(60), Italic, textSize, item.Description)...)
+	}
+
+	return append(rows, l.space(6))
+}
+
+// legendRows lists the allergen and additive codes used on the menu
+func (l *menuLayout) legendRows(allergens []models.Allergen, additives []models.Additive, left, right float64) []row {
+	var rows []row
+
+	if len(allergens) > 0 {
+		parts := make([]string, 0, len(allergens))
+		for _, a := range allergens {
+			parts = append(parts, a.Code+" "+a.Name)
+		}
+
+		rows = append(rows, l.textRow(left, Bold, textSize, "Allergene"))
+		rows = append(rows, l.wrapped(left, right-left, Regular, codeSize+1, strings.Join(parts, " · "))...)
+	}
+
+	if len(additives) > 0 {
+		parts := make([]string, 0, len(additives))
+		for _, a := range additives {
+			parts = append(parts, a.Code+" "+a.Name)
+		}
+
+		rows = append(rows, l.space(4), l.textRow(left, Bold, textSize, "Zusatzstoffe"))
+		rows = append(rows, l.wrapped(left, right-left, Regular, codeSize+1, strings.Join(parts, " · "))...)
+	}
+
+	if len(rows) > 0 {
+		rows = append([]row{l.space(18)}, rows...)
+	}
+
+	return rows
+}
+
+// pricesLabel formats the prices of an item, e.g. "8,50 €" or "klein 6,50 € · normal 8,00 €"
+func pricesLabel(item models.MenuItem) string {
+	if item.HasSingleUnlabelledPrice() {
+		return item.Variants[0].Price.String()
+	}
+
+	parts := make([]string, 0, len(item.Variants))
+	for _, v := range item.Variants {
+		parts = append(parts, strings.TrimSpace(v.Label+" "+v.Price.String()))
+	}
+
+	return strings.Join(parts, " · ")
+}
+
+// extrasLabel lists extras with their surcharges the way the website does
+func extrasLabel(extras []models.Extra) string {
+	parts := make([]string, 0, len(extras))
+
+	for _, e := range extras {
+		part := e.Name + " +" + e.Price.String()
+		if e.HasSmallPrice() {
+			part += " (klein +" + e.SmallSurcharge().String() + ")"
+		}
+
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, " · ")
+}
+
+// wrap breaks text into lines no wider than width. Words longer than a line are not split.
+func wrap(s string, font Font, size, width float64) []string {
+	var lines []string
+
+	line := ""
+
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+
+		if line != "" && TextWidth(font, size, candidate) > width {
+			lines = append(lines, line)
+			candidate = word
+		}
+
+		line = candidate
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// impose prints A5 pages on A4 landscape sheets in booklet order: the first sheet carries the
+// last and first page on its front and the second and second to last on its back, and so on.
+// Blank pages fill the booklet up to a multiple of four.
+func impose(pages []*Page) *Document {
+	for len(pages)%4 != 0 {
+		pages = append(pages, &Page{})
+	}
+
+	doc := New(A4Height, A4Width)
+	n := len(pages)
+	half := A4Height / 2
+
+	for sheet := 0; sheet < n/4; sheet++ {
+		front := doc.AddPage()
+		front.Place(pages[n-1-2*sheet], 0, 0)
+		front.Place(pages[2*sheet], half, 0)
+
+		back := doc.AddPage()
+		back.Place(pages[2*sheet+1], 0, 0)
+		back.Place(pages[n-2-2*sheet], half, 0)
+	}
+
+	return doc
+}
@@ -0,0 +1,153 @@
+// Package pdf writes simple PDF documents: text in the standard Helvetica fonts and lines.
+// It is just enough for the printed menu and needs no external programs or font files.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+// A4 page size in points (1/72 inch)
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Page collects the drawing operations of one page. The origin is the bottom left corner.
+type Page struct {
+	content bytes.Buffer
+}
+
+// Text draws s with its baseline starting at x, y
+func (p *Page) Text(x, y float64, font Font, size float64, s string) {
+	fmt.Fprintf(&p.content, "BT /F%d %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font+1, size, x, y, escape(encode(s)))
+}
+
+// Line draws a straight line of the given width
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, y1, x2, y2)
+}
+
+// Gray sets the color of the following text and lines, from 0 (black) to 1 (white)
+func (p *Page) Gray(level float64) {
+	fmt.Fprintf(&p.content, "%.2f g %.2f G\n", level, level)
+}
+
+// Place draws the content of another page with its origin moved to x, y. This is used to print
+// several small pages on one sheet.
+func (p *Page) Place(src *Page, x, y float64) {
+	fmt.Fprintf(&p.content, "q 1 0 0 1 %.2f %.2f cm\n", x, y)
+	p.content.Write(src.content.Bytes())
+	p.content.WriteString("Q\n")
+}
+
+// Document is a PDF document whose pages all have the same size
+type Document struct {
+	Title  string
+	Width  float64
+	Height float64
+	pages  []*Page
+}
+
+// New creates an empty document with the given page size
+func New(width, height float64) *Document {
+	return &Document{Width: width, Height: height}
+}
+
+// AddPage appends a blank page and returns it for drawing
+func (d *Document) AddPage() *Page {
+	p := &Page{}
+	d.pages = append(d.pages, p)
+
+	return p
+}
+
+// PageCount returns the number of pages
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// WriteTo writes the document as PDF
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+
+	// Object numbers: 1 catalog, 2 page tree, 3 info, then the fonts, then a page and its content for every page
+	fontObj := 4
+	pageObj := fontObj + len(baseFonts)
+	offsets := make([]int, pageObj-1+2*len(d.pages))
+
+	object := func(n int, body string) {
+		offsets[n-1] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", n, body)
+	}
+
+	// The binary comment tells transfer programs that the file is not plain text
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+
+	var kids bytes.Buffer
+	for i := range d.pages {
+		fmt.Fprintf(&kids, "%d 0 R ", pageObj+2*i)
+	}
+
+	object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %.2f %.2f] >>",
+		bytes.TrimSpace(kids.Bytes()), len(d.pages), d.Width, d.Height))
+	object(3, fmt.Sprintf("<< /Title (%s) /Producer (pizzeria) >>", escape(encode(d.Title))))
+
+	var fonts bytes.Buffer
+	for i, name := range baseFonts {
+		object(fontObj+i, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+		fmt.Fprintf(&fonts, "/F%d %d 0 R ", i+1, fontObj+i)
+	}
+
+	for i, p := range d.pages {
+		n := pageObj + 2*i
+		object(n, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Resources << /Font << %s>> >> /Contents %d 0 R >>",
+			fonts.String(), n+1))
+
+		var stream bytes.Buffer
+
+		zw := zlib.NewWriter(&stream)
+		if _, err := zw.Write(p.content.Bytes()); err != nil {
+			return 0, err
+		}
+
+		if err := zw.Close(); err != nil {
+			return 0, err
+		}
+
+		object(n+1, fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", stream.Len(), stream.Bytes()))
+	}
+
+	xref := buf.Len()
+
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	n, err := w.Write(buf.Bytes())
+
+	return int64(n), err
+}
+
+// escape escapes the characters with a special meaning in PDF strings
+func escape(s []byte) []byte {
+	out := make([]byte, 0, len(s))
+
+	for _, c := range s {
+		if c == '\\' || c == '(' || c == ')' {
+			out = append(out, '\\')
+		}
+
+		out = append(out, c)
+	}
+
+	return out
+}
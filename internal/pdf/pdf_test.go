@@ -0,0 +1,168 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AlexTLDR/pizzeria/internal/models"
+)
+
+func TestTextWidth(t *testing.T) {
+	tests := []struct {
+		font Font
+		text string
+		want float64
+	}{
+		{Regular, "Pizza", 667 + 222 + 500 + 500 + 556},
+		{Bold, "Pizza", 667 + 278 + 500 + 500 + 556},
+		{Italic, "Käse", 667 + 556 + 500 + 556},
+		{Regular, "8,50 €", 556 + 278 + 556 + 556 + 278 + 556},
+	}
+
+	for _, tt := range tests {
+		if got := TextWidth(tt.font, 1000, tt.text); got != tt.want {
+			t.Errorf("TextWidth(%d, %q) = %v, want %v", tt.font, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	got := encode("Käse (3,50 €) – 你")
+	want := []byte("K\xe4se (3,50 \x80) \x96 ?")
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("encode() = %q, want %q", got, want)
+	}
+
+	if got := string(escape([]byte(`a(b)\c`))); got != `a\(b\)\\c` {
+		t.Errorf("escape() = %q", got)
+	}
+}
+
+func TestDocument_WriteTo(t *testing.T) {
+	doc := New(A4Width, A4Height)
+	doc.Title = "Menu"
+	doc.AddPage().Text(50, 800, Bold, 12, "Margherita")
+	doc.AddPage().Line(50, 50, 100, 50, 1)
+
+	var buf bytes.Buffer
+
+	_, err := doc.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "%PDF-1.4") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Fatalf("output is not a PDF file")
+	}
+
+	// Every cross reference entry has to point at the start of its object
+	startxref := regexp.MustCompile(`startxref\n(\d+)`).FindStringSubmatch(out)
+	if startxref == nil {
+		t.Fatalf("startxref missing")
+	}
+
+	xref, _ := strconv.Atoi(startxref[1])
+	lines := strings.Split(out[xref:], "\n")
+
+	count, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	if count != 11 { // free entry, catalog, pages, info, 3 fonts, 2 pages and their content
+		t.Fatalf("xref has %d entries, want 11", count)
+	}
+
+	for n := 1; n < count; n++ {
+		offset, _ := strconv.Atoi(strings.Fields(lines[2+n])[0])
+		if prefix := fmt.Sprintf("%d 0 obj", n); !strings.HasPrefix(out[offset:], prefix) {
+			t.Errorf("xref entry %d points at %q", n, out[offset:offset+10])
+		}
+	}
+}
+
+func TestImpose(t *testing.T) {
+	var pages []*Page
+
+	for i := 1; i <= 5; i++ {
+		p := &Page{}
+		p.Text(0, 0, Regular, 10, fmt.Sprintf("page %d", i))
+		pages = append(pages, p)
+	}
+
+	doc := impose(pages)
+
+	// Five pages are filled up to a booklet of eight: two sheets printed on both sides
+	if doc.PageCount() != 4 {
+		t.Fatalf("impose() made %d sheet sides, want 4", doc.PageCount())
+	}
+
+	want := [][]string{{"", "page 1"}, {"page 2", ""}, {"", "page 3"}, {"page 4", "page 5"}}
+	pagePattern := regexp.MustCompile(`\(page (\d)\)`)
+
+	for i, side := range doc.pages {
+		placed := strings.Split(side.content.String(), "q 1 0 0 1")[1:]
+
+		for j, content := range placed {
+			got := ""
+			if m := pagePattern.FindStringSubmatch(content); m != nil {
+				got = "page " + m[1]
+			}
+
+			if got != want[i][j] {
+				t.Errorf("side %d position %d = %q, want %q", i+1, j+1, got, want[i][j])
+			}
+		}
+	}
+}
+
+func TestMenu_Render(t *testing.T) {
+	section := Section{Category: models.Category{NameIT: "Pizze", NameDE: "Pizzen"}}
+
+	for i := 0; i < 40; i++ {
+		section.Items = append(section.Items, models.MenuItem{
+			Name:        fmt.Sprintf("Pizza %d", i+1),
+			Description: "Tomaten, Mozzarella, Schinken, Pilze, Artischocken, Oliven und frisches Basilikum aus dem Garten",
+			Variants:    []models.Variant{{Label: "klein", Price: models.EUR(650)}, {Label: "normal", Price: models.EUR(850)}},
+			Allergens:   []models.Allergen{{Code: "A"}},
+		})
+	}
+
+	menu := Menu{Title: "La Piccola Sardegna", Sections: []Section{section}, Allergens: []models.Allergen{{Code: "A", Name: "Gluten"}}}
+
+	for _, format := range []Format{A4, A5Folded} {
+		var buf bytes.Buffer
+
+		err := menu.Render(&buf, format)
+		if err != nil {
+			t.Fatalf("Render(%s) error = %v", format, err)
+		}
+
+		pages := strings.Count(buf.String(), "/Type /Page ")
+
+		// Forty items with descriptions need several A4 pages; the booklet always has whole sheets
+		if format == A4 && pages < 2 {
+			t.Errorf("Render(a4) made %d pages", pages)
+		}
+
+		if format == A5Folded && (pages == 0 || pages%2 != 0) {
+			t.Errorf("Render(a5) made %d sheet sides", pages)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	lines := wrap("Tomaten, Mozzarella und Basilikum", Regular, 10, 100)
+
+	if strings.Join(lines, "|") != "Tomaten, Mozzarella|und Basilikum" {
+		t.Errorf("wrap() = %q", lines)
+	}
+
+	for _, line := range lines {
+		if TextWidth(Regular, 10, line) > 100 {
+			t.Errorf("line %q is wider than 100pt", line)
+		}
+	}
+}